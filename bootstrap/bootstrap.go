@@ -3,10 +3,12 @@ package bootstrap
 import (
 	"chronosphere/config"
 	"chronosphere/delivery"
+	"chronosphere/gateway"
 	"chronosphere/middleware"
 	"chronosphere/repository"
 	"chronosphere/service"
 	"chronosphere/utils"
+	"context"
 	"log"
 	"os"
 
@@ -52,6 +54,9 @@ func InitializeAppWithoutWhatsappNotification() (*gin.Engine, *gorm.DB) {
 	}
 
 	redisClient := config.InitRedisDB(redisAddr, redisPass, 0)
+	fileStorage := config.InitFileStorage()
+	paymentGateway := config.InitPaymentGateway()
+	oidcProvider, fakeOIDCIssuer := config.InitOIDC()
 	// JWT secret validation
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -60,6 +65,13 @@ func InitializeAppWithoutWhatsappNotification() (*gin.Engine, *gorm.DB) {
 	if len(jwtSecret) < 32 {
 		log.Fatal("❌ JWT_SECRET must be at least 32 characters for security. Generate one with: openssl rand -base64 32")
 	}
+	jwtRefreshSecret := os.Getenv("JWT_REFRESH_SECRET")
+	if len(jwtRefreshSecret) < 32 {
+		log.Fatal("❌ JWT_REFRESH_SECRET must be set and at least 32 characters. Generate one with: openssl rand -base64 32")
+	}
+	if jwtRefreshSecret == jwtSecret {
+		log.Fatal("❌ JWT_REFRESH_SECRET must be different from JWT_SECRET")
+	}
 
 	// Init repositories
 	authRepo := repository.NewAuthRepository(db)
@@ -69,14 +81,59 @@ func InitializeAppWithoutWhatsappNotification() (*gin.Engine, *gorm.DB) {
 	adminRepo := repository.NewAdminRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
 	otpRepo := repository.NewOTPRedisRepository(redisClient)
+	refreshTokenRepo := repository.NewRefreshTokenRedisRepository(redisClient)
+	tokenVersionRepo := repository.NewTokenVersionRepository(db, redisClient)
+	twoFactorRepo := repository.NewTwoFactorRepository(db, redisClient)
+	loginSecurityRepo := repository.NewLoginSecurityRepository(db)
+	ratingRepo := repository.NewRatingRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	fileRepo := repository.NewFileRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	voucherRepo := repository.NewVoucherRepository(db)
+	receiptRepo := repository.NewReceiptRepository(db)
+	settingRepo := repository.NewSettingRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	oidcRepo := repository.NewOIDCRepository(db, redisClient)
 
 	// Init services
+	auditService := service.NewAuditService(auditRepo)
 	studentService := service.NewStudentUseCase(studentRepo, nil)
-	managementService := service.NewManagerService(managerRepo, nil)
-	adminService := service.NewAdminService(adminRepo, paymentRepo, nil)
+	ratingService := service.NewRatingService(ratingRepo, nil)
+	payrollService := service.NewPayrollService(payrollRepo, auditService)
+	fileService := service.NewFileService(fileRepo, fileStorage, jwtSecret)
+	managementService := service.NewManagerService(managerRepo, auditService, nil)
+	adminService := service.NewAdminService(adminRepo, paymentRepo, tokenVersionRepo, auditService, nil)
 	teacherService := service.NewTeacherService(teacherRepo, nil)
-	paymentService := service.NewPaymentService(paymentRepo, studentRepo, db, nil)
-	authService := service.NewAuthService(authRepo, otpRepo, jwtSecret)
+	receiptService := service.NewReceiptService(receiptRepo)
+	settingService := service.NewSettingService(settingRepo, auditService)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, authRepo, settingService, auditService)
+	analyticsService := service.NewAnalyticsService(analyticsRepo)
+	exportService := service.NewExportService(exportRepo, fileStorage)
+	paymentService := service.NewPaymentService(paymentRepo, studentRepo, paymentGateway, receiptService, settingService, auditService, db, nil)
+	webhookService := service.NewWebhookService(webhookRepo, paymentService)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, paymentRepo, paymentService, paymentGateway)
+	voucherService := service.NewVoucherService(voucherRepo, auditService, db)
+	refundService := service.NewRefundService(refundRepo, paymentGateway, auditService, db, nil)
+	roleService := service.NewRoleService(roleRepo, tokenVersionRepo, auditService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, auditService)
+	loginSecurityService := service.NewLoginSecurityService(loginSecurityRepo, authRepo, auditService, nil)
+	authService := service.NewAuthService(authRepo, otpRepo, refreshTokenRepo, tokenVersionRepo, twoFactorService, loginSecurityService, auditService, oidcProvider, oidcRepo, jwtSecret, jwtRefreshSecret)
+
+	// Reject access tokens revoked by a token version bump or a revoked session
+	config.InitTokenVersionCheck(tokenVersionRepo)
+	config.InitSessionCheck(refreshTokenRepo)
+
+	// Resolve route permissions from the caller's role
+	middleware.InitPermissions(roleService)
+
+	// Accept X-API-Key on routes that allow integrations
+	config.InitAPIKeyAuth(apiKeyService)
 
 	// RATE LIMITER
 	middleware.InitRateLimiter(redisClient)
@@ -89,13 +146,43 @@ func InitializeAppWithoutWhatsappNotification() (*gin.Engine, *gorm.DB) {
 	// INIT HANDLERS
 	// ========================================================================
 	delivery.NewAuthHandler(app, authService, db)
+	delivery.NewSessionHandler(app, authService, authService.GetAccessTokenManager(), db)
+	delivery.NewTwoFactorHandler(app, authService, twoFactorService, authService.GetAccessTokenManager(), db)
+	delivery.NewLoginSecurityHandler(app, loginSecurityService, authService.GetAccessTokenManager(), db)
+	delivery.NewRoleHandler(app, roleService, authService.GetAccessTokenManager(), db)
+	delivery.NewAuditHandler(app, auditService, authService.GetAccessTokenManager())
+	delivery.NewAPIKeyHandler(app, apiKeyService, authService.GetAccessTokenManager())
+	delivery.NewOIDCHandler(app, authService)
 	delivery.NewManagerHandler(app, managementService, authService.GetAccessTokenManager(), db)
 	delivery.NewStudentHandler(app, studentService, authService.GetAccessTokenManager())
 	delivery.NewAdminHandler(app, adminService, authService.GetAccessTokenManager())
 	delivery.NewTeacherHandler(app, teacherService, authService.GetAccessTokenManager(), db)
+	delivery.NewRatingHandler(app, ratingService, authService.GetAccessTokenManager(), db)
+	delivery.NewPayrollHandler(app, payrollService, authService.GetAccessTokenManager(), db)
+	delivery.NewFileHandler(app, fileService, authService.GetAccessTokenManager(), db)
 
 	// Inject AuthMiddleware from config for PaymentHandler
 	delivery.NewPaymentHandler(app, paymentService, config.AuthMiddleware(authService.GetAccessTokenManager()))
+	delivery.NewWebhookHandler(app, webhookService, authService.GetAccessTokenManager())
+	delivery.NewReconciliationHandler(app, reconciliationService, authService.GetAccessTokenManager())
+	delivery.NewRefundHandler(app, refundService, authService.GetAccessTokenManager())
+	delivery.NewVoucherHandler(app, voucherService, authService.GetAccessTokenManager(), db)
+	delivery.NewReceiptHandler(app, receiptService, authService.GetAccessTokenManager(), db)
+	delivery.NewStudentPaymentHandler(app, paymentService, authService.GetAccessTokenManager(), db)
+	delivery.NewSettingHandler(app, settingService, authService.GetAccessTokenManager())
+	delivery.NewOfflinePaymentHandler(app, paymentService, fileService, authService.GetAccessTokenManager())
+	delivery.NewAnalyticsHandler(app, analyticsService, authService.GetAccessTokenManager())
+	delivery.NewExportHandler(app, exportService, authService.GetAccessTokenManager())
+	if fakeGateway, ok := paymentGateway.(*gateway.FakeGateway); ok {
+		delivery.NewFakeGatewayHandler(app, fakeGateway)
+	}
+	if fakeOIDCIssuer != nil {
+		delivery.NewFakeOIDCHandler(app, fakeOIDCIssuer)
+	}
+
+	// Background jobs
+	service.StartPaymentReconciler(context.Background(), reconciliationService)
+	service.StartExportMaintenance(context.Background(), exportService)
 
 	return app, db
 }
@@ -135,6 +222,9 @@ func InitializeAppWithoutRateLimiter() (*gin.Engine, *gorm.DB) {
 	}
 
 	redisClient := config.InitRedisDB(redisAddr, redisPass, 0)
+	fileStorage := config.InitFileStorage()
+	paymentGateway := config.InitPaymentGateway()
+	oidcProvider, fakeOIDCIssuer := config.InitOIDC()
 	// JWT secret validation
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -143,6 +233,13 @@ func InitializeAppWithoutRateLimiter() (*gin.Engine, *gorm.DB) {
 	if len(jwtSecret) < 32 {
 		log.Fatal("❌ JWT_SECRET must be at least 32 characters for security. Generate one with: openssl rand -base64 32")
 	}
+	jwtRefreshSecret := os.Getenv("JWT_REFRESH_SECRET")
+	if len(jwtRefreshSecret) < 32 {
+		log.Fatal("❌ JWT_REFRESH_SECRET must be set and at least 32 characters. Generate one with: openssl rand -base64 32")
+	}
+	if jwtRefreshSecret == jwtSecret {
+		log.Fatal("❌ JWT_REFRESH_SECRET must be different from JWT_SECRET")
+	}
 
 	// Init repositories
 	authRepo := repository.NewAuthRepository(db)
@@ -152,14 +249,59 @@ func InitializeAppWithoutRateLimiter() (*gin.Engine, *gorm.DB) {
 	adminRepo := repository.NewAdminRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
 	otpRepo := repository.NewOTPRedisRepository(redisClient)
+	refreshTokenRepo := repository.NewRefreshTokenRedisRepository(redisClient)
+	tokenVersionRepo := repository.NewTokenVersionRepository(db, redisClient)
+	twoFactorRepo := repository.NewTwoFactorRepository(db, redisClient)
+	loginSecurityRepo := repository.NewLoginSecurityRepository(db)
+	ratingRepo := repository.NewRatingRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	fileRepo := repository.NewFileRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	voucherRepo := repository.NewVoucherRepository(db)
+	receiptRepo := repository.NewReceiptRepository(db)
+	settingRepo := repository.NewSettingRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	oidcRepo := repository.NewOIDCRepository(db, redisClient)
 
 	// Init services
+	auditService := service.NewAuditService(auditRepo)
 	studentService := service.NewStudentUseCase(studentRepo, WhatsappClient)
-	managementService := service.NewManagerService(managerRepo, WhatsappClient)
-	adminService := service.NewAdminService(adminRepo, paymentRepo, WhatsappClient)
+	ratingService := service.NewRatingService(ratingRepo, WhatsappClient)
+	payrollService := service.NewPayrollService(payrollRepo, auditService)
+	fileService := service.NewFileService(fileRepo, fileStorage, jwtSecret)
+	managementService := service.NewManagerService(managerRepo, auditService, WhatsappClient)
+	adminService := service.NewAdminService(adminRepo, paymentRepo, tokenVersionRepo, auditService, WhatsappClient)
 	teacherService := service.NewTeacherService(teacherRepo, WhatsappClient)
-	paymentService := service.NewPaymentService(paymentRepo, studentRepo, db, WhatsappClient)
-	authService := service.NewAuthService(authRepo, otpRepo, jwtSecret)
+	receiptService := service.NewReceiptService(receiptRepo)
+	settingService := service.NewSettingService(settingRepo, auditService)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, authRepo, settingService, auditService)
+	analyticsService := service.NewAnalyticsService(analyticsRepo)
+	exportService := service.NewExportService(exportRepo, fileStorage)
+	paymentService := service.NewPaymentService(paymentRepo, studentRepo, paymentGateway, receiptService, settingService, auditService, db, WhatsappClient)
+	webhookService := service.NewWebhookService(webhookRepo, paymentService)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, paymentRepo, paymentService, paymentGateway)
+	voucherService := service.NewVoucherService(voucherRepo, auditService, db)
+	refundService := service.NewRefundService(refundRepo, paymentGateway, auditService, db, WhatsappClient)
+	roleService := service.NewRoleService(roleRepo, tokenVersionRepo, auditService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, auditService)
+	loginSecurityService := service.NewLoginSecurityService(loginSecurityRepo, authRepo, auditService, WhatsappClient)
+	authService := service.NewAuthService(authRepo, otpRepo, refreshTokenRepo, tokenVersionRepo, twoFactorService, loginSecurityService, auditService, oidcProvider, oidcRepo, jwtSecret, jwtRefreshSecret)
+
+	// Reject access tokens revoked by a token version bump or a revoked session
+	config.InitTokenVersionCheck(tokenVersionRepo)
+	config.InitSessionCheck(refreshTokenRepo)
+
+	// Resolve route permissions from the caller's role
+	middleware.InitPermissions(roleService)
+
+	// Accept X-API-Key on routes that allow integrations
+	config.InitAPIKeyAuth(apiKeyService)
 
 	// RATE LIMITER
 	// middleware.InitRateLimiter(redisClient)
@@ -172,13 +314,43 @@ func InitializeAppWithoutRateLimiter() (*gin.Engine, *gorm.DB) {
 	// INIT HANDLERS
 	// ========================================================================
 	delivery.NewAuthHandler(app, authService, db)
+	delivery.NewSessionHandler(app, authService, authService.GetAccessTokenManager(), db)
+	delivery.NewTwoFactorHandler(app, authService, twoFactorService, authService.GetAccessTokenManager(), db)
+	delivery.NewLoginSecurityHandler(app, loginSecurityService, authService.GetAccessTokenManager(), db)
+	delivery.NewRoleHandler(app, roleService, authService.GetAccessTokenManager(), db)
+	delivery.NewAuditHandler(app, auditService, authService.GetAccessTokenManager())
+	delivery.NewAPIKeyHandler(app, apiKeyService, authService.GetAccessTokenManager())
+	delivery.NewOIDCHandler(app, authService)
 	delivery.NewManagerHandler(app, managementService, authService.GetAccessTokenManager(), db)
 	delivery.NewStudentHandler(app, studentService, authService.GetAccessTokenManager())
 	delivery.NewAdminHandler(app, adminService, authService.GetAccessTokenManager())
 	delivery.NewTeacherHandler(app, teacherService, authService.GetAccessTokenManager(), db)
+	delivery.NewRatingHandler(app, ratingService, authService.GetAccessTokenManager(), db)
+	delivery.NewPayrollHandler(app, payrollService, authService.GetAccessTokenManager(), db)
+	delivery.NewFileHandler(app, fileService, authService.GetAccessTokenManager(), db)
 
 	// Inject AuthMiddleware from config for PaymentHandler
 	delivery.NewPaymentHandler(app, paymentService, config.AuthMiddleware(authService.GetAccessTokenManager()))
+	delivery.NewWebhookHandler(app, webhookService, authService.GetAccessTokenManager())
+	delivery.NewReconciliationHandler(app, reconciliationService, authService.GetAccessTokenManager())
+	delivery.NewRefundHandler(app, refundService, authService.GetAccessTokenManager())
+	delivery.NewVoucherHandler(app, voucherService, authService.GetAccessTokenManager(), db)
+	delivery.NewReceiptHandler(app, receiptService, authService.GetAccessTokenManager(), db)
+	delivery.NewStudentPaymentHandler(app, paymentService, authService.GetAccessTokenManager(), db)
+	delivery.NewSettingHandler(app, settingService, authService.GetAccessTokenManager())
+	delivery.NewOfflinePaymentHandler(app, paymentService, fileService, authService.GetAccessTokenManager())
+	delivery.NewAnalyticsHandler(app, analyticsService, authService.GetAccessTokenManager())
+	delivery.NewExportHandler(app, exportService, authService.GetAccessTokenManager())
+	if fakeGateway, ok := paymentGateway.(*gateway.FakeGateway); ok {
+		delivery.NewFakeGatewayHandler(app, fakeGateway)
+	}
+	if fakeOIDCIssuer != nil {
+		delivery.NewFakeOIDCHandler(app, fakeOIDCIssuer)
+	}
+
+	// Background jobs
+	service.StartPaymentReconciler(context.Background(), reconciliationService)
+	service.StartExportMaintenance(context.Background(), exportService)
 
 	return app, db
 }
@@ -218,6 +390,9 @@ func InitializeFullApp() (*gin.Engine, *gorm.DB) {
 	}
 
 	redisClient := config.InitRedisDB(redisAddr, redisPass, 0)
+	fileStorage := config.InitFileStorage()
+	paymentGateway := config.InitPaymentGateway()
+	oidcProvider, fakeOIDCIssuer := config.InitOIDC()
 	// JWT secret validation
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -226,6 +401,13 @@ func InitializeFullApp() (*gin.Engine, *gorm.DB) {
 	if len(jwtSecret) < 32 {
 		log.Fatal("❌ JWT_SECRET must be at least 32 characters for security. Generate one with: openssl rand -base64 32")
 	}
+	jwtRefreshSecret := os.Getenv("JWT_REFRESH_SECRET")
+	if len(jwtRefreshSecret) < 32 {
+		log.Fatal("❌ JWT_REFRESH_SECRET must be set and at least 32 characters. Generate one with: openssl rand -base64 32")
+	}
+	if jwtRefreshSecret == jwtSecret {
+		log.Fatal("❌ JWT_REFRESH_SECRET must be different from JWT_SECRET")
+	}
 
 	// Init repositories
 	authRepo := repository.NewAuthRepository(db)
@@ -235,14 +417,59 @@ func InitializeFullApp() (*gin.Engine, *gorm.DB) {
 	adminRepo := repository.NewAdminRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
 	otpRepo := repository.NewOTPRedisRepository(redisClient)
+	refreshTokenRepo := repository.NewRefreshTokenRedisRepository(redisClient)
+	tokenVersionRepo := repository.NewTokenVersionRepository(db, redisClient)
+	twoFactorRepo := repository.NewTwoFactorRepository(db, redisClient)
+	loginSecurityRepo := repository.NewLoginSecurityRepository(db)
+	ratingRepo := repository.NewRatingRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	fileRepo := repository.NewFileRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	voucherRepo := repository.NewVoucherRepository(db)
+	receiptRepo := repository.NewReceiptRepository(db)
+	settingRepo := repository.NewSettingRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	oidcRepo := repository.NewOIDCRepository(db, redisClient)
 
 	// Init services
+	auditService := service.NewAuditService(auditRepo)
 	studentService := service.NewStudentUseCase(studentRepo, WhatsappClient)
-	managementService := service.NewManagerService(managerRepo, WhatsappClient)
-	adminService := service.NewAdminService(adminRepo, paymentRepo, WhatsappClient)
+	ratingService := service.NewRatingService(ratingRepo, WhatsappClient)
+	payrollService := service.NewPayrollService(payrollRepo, auditService)
+	fileService := service.NewFileService(fileRepo, fileStorage, jwtSecret)
+	managementService := service.NewManagerService(managerRepo, auditService, WhatsappClient)
+	adminService := service.NewAdminService(adminRepo, paymentRepo, tokenVersionRepo, auditService, WhatsappClient)
 	teacherService := service.NewTeacherService(teacherRepo, WhatsappClient)
-	paymentService := service.NewPaymentService(paymentRepo, studentRepo, db, WhatsappClient)
-	authService := service.NewAuthService(authRepo, otpRepo, jwtSecret)
+	receiptService := service.NewReceiptService(receiptRepo)
+	settingService := service.NewSettingService(settingRepo, auditService)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, authRepo, settingService, auditService)
+	analyticsService := service.NewAnalyticsService(analyticsRepo)
+	exportService := service.NewExportService(exportRepo, fileStorage)
+	paymentService := service.NewPaymentService(paymentRepo, studentRepo, paymentGateway, receiptService, settingService, auditService, db, WhatsappClient)
+	webhookService := service.NewWebhookService(webhookRepo, paymentService)
+	reconciliationService := service.NewReconciliationService(reconciliationRepo, paymentRepo, paymentService, paymentGateway)
+	voucherService := service.NewVoucherService(voucherRepo, auditService, db)
+	refundService := service.NewRefundService(refundRepo, paymentGateway, auditService, db, WhatsappClient)
+	roleService := service.NewRoleService(roleRepo, tokenVersionRepo, auditService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, auditService)
+	loginSecurityService := service.NewLoginSecurityService(loginSecurityRepo, authRepo, auditService, WhatsappClient)
+	authService := service.NewAuthService(authRepo, otpRepo, refreshTokenRepo, tokenVersionRepo, twoFactorService, loginSecurityService, auditService, oidcProvider, oidcRepo, jwtSecret, jwtRefreshSecret)
+
+	// Reject access tokens revoked by a token version bump or a revoked session
+	config.InitTokenVersionCheck(tokenVersionRepo)
+	config.InitSessionCheck(refreshTokenRepo)
+
+	// Resolve route permissions from the caller's role
+	middleware.InitPermissions(roleService)
+
+	// Accept X-API-Key on routes that allow integrations
+	config.InitAPIKeyAuth(apiKeyService)
 
 	// RATE LIMITER
 	middleware.InitRateLimiter(redisClient)
@@ -255,13 +482,43 @@ func InitializeFullApp() (*gin.Engine, *gorm.DB) {
 	// INIT HANDLERS
 	// ========================================================================
 	delivery.NewAuthHandler(app, authService, db)
+	delivery.NewSessionHandler(app, authService, authService.GetAccessTokenManager(), db)
+	delivery.NewTwoFactorHandler(app, authService, twoFactorService, authService.GetAccessTokenManager(), db)
+	delivery.NewLoginSecurityHandler(app, loginSecurityService, authService.GetAccessTokenManager(), db)
+	delivery.NewRoleHandler(app, roleService, authService.GetAccessTokenManager(), db)
+	delivery.NewAuditHandler(app, auditService, authService.GetAccessTokenManager())
+	delivery.NewAPIKeyHandler(app, apiKeyService, authService.GetAccessTokenManager())
+	delivery.NewOIDCHandler(app, authService)
 	delivery.NewManagerHandler(app, managementService, authService.GetAccessTokenManager(), db)
 	delivery.NewStudentHandler(app, studentService, authService.GetAccessTokenManager())
 	delivery.NewAdminHandler(app, adminService, authService.GetAccessTokenManager())
 	delivery.NewTeacherHandler(app, teacherService, authService.GetAccessTokenManager(), db)
+	delivery.NewRatingHandler(app, ratingService, authService.GetAccessTokenManager(), db)
+	delivery.NewPayrollHandler(app, payrollService, authService.GetAccessTokenManager(), db)
+	delivery.NewFileHandler(app, fileService, authService.GetAccessTokenManager(), db)
 
 	// Inject AuthMiddleware from config for PaymentHandler
 	delivery.NewPaymentHandler(app, paymentService, config.AuthMiddleware(authService.GetAccessTokenManager()))
+	delivery.NewWebhookHandler(app, webhookService, authService.GetAccessTokenManager())
+	delivery.NewReconciliationHandler(app, reconciliationService, authService.GetAccessTokenManager())
+	delivery.NewRefundHandler(app, refundService, authService.GetAccessTokenManager())
+	delivery.NewVoucherHandler(app, voucherService, authService.GetAccessTokenManager(), db)
+	delivery.NewReceiptHandler(app, receiptService, authService.GetAccessTokenManager(), db)
+	delivery.NewStudentPaymentHandler(app, paymentService, authService.GetAccessTokenManager(), db)
+	delivery.NewSettingHandler(app, settingService, authService.GetAccessTokenManager())
+	delivery.NewOfflinePaymentHandler(app, paymentService, fileService, authService.GetAccessTokenManager())
+	delivery.NewAnalyticsHandler(app, analyticsService, authService.GetAccessTokenManager())
+	delivery.NewExportHandler(app, exportService, authService.GetAccessTokenManager())
+	if fakeGateway, ok := paymentGateway.(*gateway.FakeGateway); ok {
+		delivery.NewFakeGatewayHandler(app, fakeGateway)
+	}
+	if fakeOIDCIssuer != nil {
+		delivery.NewFakeOIDCHandler(app, fakeOIDCIssuer)
+	}
+
+	// Background jobs
+	service.StartPaymentReconciler(context.Background(), reconciliationService)
+	service.StartExportMaintenance(context.Background(), exportService)
 
 	return app, db
 }
@@ -14,6 +14,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -113,12 +114,38 @@ func runMigrations(db *gorm.DB) error {
 		&domain.TeacherProfile{},
 		&domain.StudentProfile{},
 		&domain.Package{},
+		&domain.PackageVersion{},
 		&domain.StudentPackage{},
 		&domain.TeacherSchedule{},
 		&domain.Booking{},
 		&domain.Payment{},
 		&domain.ClassHistory{},
 		&domain.ClassDocumentation{},
+		&domain.ClassRating{},
+		&domain.TeacherPayRate{},
+		&domain.PayrollRun{},
+		&domain.Payslip{},
+		&domain.PayslipItem{},
+		&domain.StoredFile{},
+		&domain.WebhookEvent{},
+		&domain.ReconciliationRun{},
+		&domain.PaymentDiscrepancy{},
+		&domain.PaymentRefund{},
+		&domain.Voucher{},
+		&domain.VoucherRedemption{},
+		&domain.Receipt{},
+		&domain.AppSetting{},
+		&domain.ExportJob{},
+		&domain.UserTwoFactor{},
+		&domain.TwoFactorRecoveryCode{},
+		&domain.LoginHistory{},
+		&domain.AccountLockout{},
+		&domain.Role{},
+		&domain.RolePermission{},
+		&domain.AuditLog{},
+		&domain.APIKey{},
+		&domain.APIKeyScope{},
+		&domain.UserIdentity{},
 	}
 
 	for _, m := range models {
@@ -141,6 +168,131 @@ func runMigrations(db *gorm.DB) error {
 		log.Printf("✅ Migrated %s", modelName)
 	}
 
+	// Payments without a gateway invoice used to store an empty id, which collides under the
+	// unique index; NULL marks them now.
+	if err := db.Model(&domain.Payment{}).Where("xendit_invoice_id = ''").
+		Update("xendit_invoice_id", nil).Error; err != nil {
+		return fmt.Errorf("failed to clear empty invoice ids: %w", err)
+	}
+
+	if err := backfillPackageVersions(db); err != nil {
+		return err
+	}
+
+	if err := seedSystemRoles(db); err != nil {
+		return err
+	}
+
+	if err := protectAuditLog(db); err != nil {
+		return err
+	}
+
+	if err := indexOpenDiscrepancies(db); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// protectAuditLog makes audit_logs append-only at the database level too, so a stray
+// query can't edit or remove entries.
+func protectAuditLog(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`,
+		`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+		FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change()`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+		FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change()`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to protect audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// indexOpenDiscrepancies allows one open discrepancy per payment and type, so a payment
+// that stays PENDING isn't reported again on every reconciliation run. Duplicates recorded
+// before the index existed are dropped first, keeping the oldest report.
+func indexOpenDiscrepancies(db *gorm.DB) error {
+	statements := []string{
+		`DELETE FROM payment_discrepancies d WHERE d.resolved = false AND EXISTS (
+			SELECT 1 FROM payment_discrepancies o
+			WHERE o.payment_id = d.payment_id AND o.type = d.type AND o.resolved = false AND o.id < d.id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_discrepancies_open
+			ON payment_discrepancies (payment_id, type) WHERE resolved = false`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to index open discrepancies: %w", err)
+		}
+	}
+	return nil
+}
+
+// seedSystemRoles creates the built-in roles and grants them any default permission they
+// don't have yet, so permissions added in a release reach existing installs. Role details
+// and permissions granted by admins are left alone.
+func seedSystemRoles(db *gorm.DB) error {
+	systemRoles := []domain.Role{
+		{Name: domain.RoleAdmin, DisplayName: "Admin", Description: "Akses penuh ke semua fitur back-office", IsSystem: true},
+		{Name: domain.RoleManagement, DisplayName: "Manajemen", IsSystem: true},
+		{Name: domain.RoleTeacher, DisplayName: "Guru", IsSystem: true},
+		{Name: domain.RoleStudent, DisplayName: "Murid", IsSystem: true},
+	}
+
+	for _, role := range systemRoles {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+				return err
+			}
+			for _, permission := range domain.DefaultRolePermissions[role.Name] {
+				grant := &domain.RolePermission{RoleName: role.Name, Permission: permission}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(grant).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed role %s: %w", role.Name, err)
+		}
+	}
+	return nil
+}
+
+// backfillPackageVersions gives packages created before versioning their first version.
+// Purchases made before then keep no snapshot and show the package's current terms.
+func backfillPackageVersions(db *gorm.DB) error {
+	var packages []domain.Package
+	if err := db.Where("current_version_id IS NULL").Find(&packages).Error; err != nil {
+		return fmt.Errorf("failed to load unversioned packages: %w", err)
+	}
+
+	for i := range packages {
+		pkg := &packages[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			version := domain.NewPackageVersion(pkg, 1)
+			if err := tx.Create(version).Error; err != nil {
+				return err
+			}
+			return tx.Model(&domain.Package{}).Where("id = ?", pkg.ID).
+				Updates(map[string]interface{}{"version": 1, "current_version_id": version.ID}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create first version of package %d: %w", pkg.ID, err)
+		}
+	}
+	if len(packages) > 0 {
+		log.Printf("✅ Created first version for %d package(s)", len(packages))
+	}
 	return nil
 }
 
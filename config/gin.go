@@ -1,9 +1,12 @@
 package config
 
 import (
+	"chronosphere/domain"
 	"chronosphere/middleware"
 	"chronosphere/utils"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -32,7 +35,7 @@ func InitMiddleware(app *gin.Engine) {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     strings.Split(corsOrigins, ","),
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -125,6 +128,73 @@ func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	}
 }
 
+var tokenVersions domain.TokenVersionRepository
+
+// InitTokenVersionCheck makes AuthMiddleware reject access tokens issued before the user's
+// current token version. Without it tokens are only checked for signature and expiry.
+func InitTokenVersionCheck(repo domain.TokenVersionRepository) {
+	tokenVersions = repo
+}
+
+var sessions domain.RefreshTokenRepository
+
+// InitSessionCheck makes AuthMiddleware reject access tokens whose session (refresh token
+// family) was logged out or revoked, instead of honouring them until they expire.
+func InitSessionCheck(repo domain.RefreshTokenRepository) {
+	sessions = repo
+}
+
+// sessionRevoked reports whether the token's session no longer exists. Tokens without a
+// session ID predate sessions and are left to the token version check. An outage is
+// handled like in tokenRevoked.
+func sessionRevoked(ctx context.Context, claims *utils.AccessClaims) (bool, error) {
+	if sessions == nil || claims.SessionID == "" {
+		return false, nil
+	}
+	exists, err := sessions.FamilyExists(ctx, claims.SessionID)
+	if err != nil {
+		return false, revocationCheckFailed(claims, "Session", err)
+	}
+	return !exists, nil
+}
+
+// tokenRevoked reports whether the token predates the user's current token version.
+func tokenRevoked(ctx context.Context, claims *utils.AccessClaims) (bool, error) {
+	if tokenVersions == nil {
+		return false, nil
+	}
+	current, err := tokenVersions.GetTokenVersion(ctx, claims.UserUUID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return true, nil
+		}
+		return false, revocationCheckFailed(claims, "Token version", err)
+	}
+	return claims.TokenVersion < current, nil
+}
+
+// revocationCheckFailed decides what a Redis or database outage during a revocation check
+// means. Students and teachers are let through, since rejecting every request would take
+// the whole API down and the token still expires on its own. Staff tokens reach the
+// back-office, so they fail closed: a revoked admin token must not keep working.
+func revocationCheckFailed(claims *utils.AccessClaims, check string, err error) error {
+	if domain.IsStaffRole(claims.Role) {
+		log.Printf("❌ %s check failed for user %s, request rejected: %v", check, claims.UserUUID, err)
+		return err
+	}
+	log.Printf("⚠️ %s check skipped for user %s: %v", check, claims.UserUUID, err)
+	return nil
+}
+
+// accessRevoked runs both revocation checks; err is set when they couldn't be completed
+// for a token that has to fail closed.
+func accessRevoked(ctx context.Context, claims *utils.AccessClaims) (bool, error) {
+	if revoked, err := tokenRevoked(ctx, claims); revoked || err != nil {
+		return revoked, err
+	}
+	return sessionRevoked(ctx, claims)
+}
+
 func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// ✅ Protect against panic
@@ -162,13 +232,14 @@ func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// ✅ Safe token verification
-		userUUID, role, name, err := func() (string, string, string, error) {
+		claims, err := func() (claims *utils.AccessClaims, err error) {
 			defer func() {
 				if r := recover(); r != nil {
 					log.Printf("🔥 Token verification panic: %v", r)
+					err = fmt.Errorf("token verification panic: %v", r)
 				}
 			}()
-			return jwtManager.VerifyToken(tokenStr)
+			return jwtManager.VerifyAccessToken(tokenStr)
 		}()
 
 		if err != nil {
@@ -181,10 +252,92 @@ func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := accessRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "Unable to verify session, please try again",
+				"error":   "auth_unavailable",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Token has been revoked, please log in again",
+				"error":   "token_revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// Save to context
-		c.Set("userUUID", userUUID)
-		c.Set("role", role)
-		c.Set("name", name)
+		c.Set("userUUID", claims.UserUUID)
+		c.Set("role", claims.Role)
+		c.Set("name", claims.Name)
+		c.Set("sessionID", claims.SessionID)
+		c.Request = c.Request.WithContext(domain.WithAuditActor(c.Request.Context(), domain.AuditActor{
+			UUID:   claims.UserUUID,
+			Role:   claims.Role,
+			Client: domain.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()},
+		}))
+
+		c.Next()
+	}
+}
+
+var apiKeyAuth domain.APIKeyAuthenticator
+
+// InitAPIKeyAuth enables the X-API-Key header on routes guarded by AuthOrAPIKeyMiddleware.
+func InitAPIKeyAuth(auth domain.APIKeyAuthenticator) {
+	apiKeyAuth = auth
+}
+
+// AuthOrAPIKeyMiddleware accepts either an API key in X-API-Key or a user access token.
+// API key requests carry no user: handlers and RequirePermission see the key via
+// c.Get("apiKey") and its scopes stand in for role permissions.
+func AuthOrAPIKeyMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
+	userAuth := AuthMiddleware(jwtManager)
+	return func(c *gin.Context) {
+		rawKey := strings.TrimSpace(c.GetHeader("X-API-Key"))
+		if rawKey == "" || apiKeyAuth == nil {
+			userAuth(c)
+			return
+		}
+
+		key, err := apiKeyAuth.Authenticate(c.Request.Context(), rawKey, c.ClientIP())
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Invalid, revoked or expired API key",
+				"error":   "invalid_api_key",
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("⚠️ API key authentication failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Authentication error",
+				"error":   "auth_error",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("apiKey", key)
+		if !middleware.APIKeyRateLimit(c) {
+			return
+		}
+
+		c.Set("name", "api-key:"+key.Name)
+		c.Request = c.Request.WithContext(domain.WithAuditActor(c.Request.Context(), domain.AuditActor{
+			Role:         domain.AuditActorAPIKey,
+			APIKeyPrefix: key.Prefix,
+			Client:       domain.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()},
+		}))
 
 		c.Next()
 	}
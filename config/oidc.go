@@ -0,0 +1,75 @@
+package config
+
+import (
+	"chronosphere/domain"
+	"chronosphere/gateway"
+	"chronosphere/utils"
+	"log"
+	"os"
+	"strings"
+)
+
+// InitOIDC sets up OIDC login from OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET and
+// OIDC_REDIRECT_URL (the frontend page the provider sends the user back to). OIDC_SCOPES
+// defaults to "openid email profile". Without OIDC_ISSUER the login is off and both
+// results are nil.
+//
+// OIDC_ISSUER=fake serves a local mock issuer under /fake-oidc and logs in against it; the
+// returned issuer must then be mounted with delivery.NewFakeOIDCHandler.
+func InitOIDC() (domain.OIDCProvider, *gateway.FakeOIDCIssuer) {
+	issuer := strings.TrimRight(strings.TrimSpace(os.Getenv("OIDC_ISSUER")), "/")
+	if issuer == "" {
+		log.Println("⚠️  OIDC_ISSUER not set, OIDC login disabled")
+		return nil, nil
+	}
+
+	clientID := os.Getenv("OIDC_CLIENT_ID")
+	clientSecret := os.Getenv("OIDC_CLIENT_SECRET")
+	redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	scopes := strings.Fields(os.Getenv("OIDC_SCOPES"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	if issuer == "fake" {
+		if os.Getenv("APP_ENV") == "production" {
+			log.Fatal("❌ OIDC_ISSUER=fake is not allowed in production")
+		}
+
+		baseURL := strings.TrimRight(os.Getenv("OIDC_FAKE_BASE_URL"), "/")
+		if baseURL == "" {
+			port := os.Getenv("APP_PORT")
+			if port == "" {
+				port = "8080"
+			}
+			baseURL = "http://localhost:" + port
+		}
+		if clientID == "" {
+			clientID = "chronosphere-dev"
+		}
+		if clientSecret == "" {
+			clientSecret = "chronosphere-dev-secret"
+		}
+		if redirectURL == "" {
+			redirectURL = "http://localhost:3000/auth/oidc/callback"
+			log.Println("⚠️  OIDC_REDIRECT_URL not set, using default:", redirectURL)
+		}
+
+		fakeIssuer, err := gateway.NewFakeOIDCIssuer(baseURL+domain.OIDCFakeIssuerPath, clientID, clientSecret, redirectURL)
+		if err != nil {
+			log.Fatalf("❌ Failed to start fake OIDC issuer: %v", err)
+		}
+		log.Print("✅ OIDC issuer ", utils.ColorText("fake", utils.Yellow), " ready at ", fakeIssuer.Issuer())
+		return gateway.NewOIDCClient(fakeIssuer.Issuer(), clientID, clientSecret, redirectURL, scopes), fakeIssuer
+	}
+
+	if clientID == "" || clientSecret == "" || redirectURL == "" {
+		log.Fatal("❌ OIDC_CLIENT_ID, OIDC_CLIENT_SECRET and OIDC_REDIRECT_URL are required with OIDC_ISSUER")
+	}
+	if os.Getenv("APP_ENV") == "production" && !strings.HasPrefix(issuer, "https://") {
+		log.Fatal("❌ OIDC_ISSUER must use https in production")
+	}
+
+	log.Print("✅ OIDC issuer ", utils.ColorText(issuer, utils.Green), " ready")
+	return gateway.NewOIDCClient(issuer, clientID, clientSecret, redirectURL, scopes), nil
+}
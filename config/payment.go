@@ -0,0 +1,50 @@
+package config
+
+import (
+	"chronosphere/domain"
+	"chronosphere/gateway"
+	"chronosphere/utils"
+	"log"
+	"os"
+	"strings"
+)
+
+// InitPaymentGateway picks the payment provider from PAYMENT_GATEWAY ("xendit" by default, or
+// "fake" for a local payment page that never touches the network).
+func InitPaymentGateway() domain.PaymentGateway {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("PAYMENT_GATEWAY")))
+	if driver == "" {
+		driver = domain.PaymentGatewayXendit
+	}
+
+	switch driver {
+	case domain.PaymentGatewayXendit:
+		xenditKey := os.Getenv("XENDIT_API_KEY")
+		if xenditKey == "" {
+			log.Println("⚠️  XENDIT_API_KEY is not set")
+		}
+		log.Print("✅ Payment gateway ", utils.ColorText("xendit", utils.Green), " ready")
+		return gateway.NewXenditGateway(xenditKey)
+
+	case domain.PaymentGatewayFake:
+		if os.Getenv("APP_ENV") == "production" {
+			log.Fatal("❌ PAYMENT_GATEWAY=fake is not allowed in production")
+		}
+
+		baseURL := strings.TrimRight(os.Getenv("PAYMENT_FAKE_BASE_URL"), "/")
+		if baseURL == "" {
+			port := os.Getenv("APP_PORT")
+			if port == "" {
+				port = "8080"
+			}
+			baseURL = "http://localhost:" + port
+		}
+
+		log.Print("✅ Payment gateway ", utils.ColorText("fake", utils.Yellow), " ready at ", baseURL, "/fake-gateway/invoices")
+		return gateway.NewFakeGateway(baseURL, baseURL+"/api/v1/payment/callback", os.Getenv("XENDIT_WEBHOOK_TOKEN"))
+
+	default:
+		log.Fatalf("❌ Unsupported PAYMENT_GATEWAY: %s", driver)
+	}
+	return nil
+}
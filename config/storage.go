@@ -0,0 +1,36 @@
+package config
+
+import (
+	"chronosphere/domain"
+	"chronosphere/storage"
+	"chronosphere/utils"
+	"log"
+	"os"
+	"strings"
+)
+
+// InitFileStorage picks the storage backend from STORAGE_DRIVER. Only "local" is available for now;
+// an S3-compatible driver only has to implement domain.FileStorage.
+func InitFileStorage() domain.FileStorage {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_DRIVER")))
+	if driver == "" {
+		driver = domain.StorageDriverLocal
+	}
+
+	switch driver {
+	case domain.StorageDriverLocal:
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		fs, err := storage.NewLocalStorage(dir)
+		if err != nil {
+			log.Fatalf("❌ Failed to init local storage: %v", err)
+		}
+		log.Print("✅ File storage ", utils.ColorText("local", utils.Green), " ready at ", dir)
+		return fs
+	default:
+		log.Fatalf("❌ Unsupported STORAGE_DRIVER: %s", driver)
+	}
+	return nil
+}
@@ -23,55 +23,59 @@ func NewAdminHandler(app *gin.Engine, uc domain.AdminUseCase, jwtManager *utils.
 	h := &AdminHandler{uc: uc}
 
 	admin := app.Group("/admin")
-	admin.Use(config.AuthMiddleware(jwtManager), middleware.AdminOnly())
+	// Routes whose permission is API key capable (domain.PermissionInfo.APIKey) also accept
+	// integrations authenticating with X-API-Key.
+	admin.Use(config.AuthOrAPIKeyMiddleware(jwtManager))
 	{
 		// Admin
-		admin.PUT("/modify", h.UpdateAdmin)
+		admin.PUT("/modify", middleware.RequirePermission(domain.PermissionAdminProfileWrite), h.UpdateAdmin)
 
 		// Teacher
-		admin.POST("/teachers", h.CreateTeacher)
-		admin.PUT("/teachers/modify/:uuid", h.UpdateTeacher)
-		admin.GET("/teachers", h.GetAllTeachers)
-		admin.GET("/teachers/:uuid", h.GetTeacherByUUID)
+		admin.POST("/teachers", middleware.RequirePermission(domain.PermissionTeachersWrite), h.CreateTeacher)
+		admin.PUT("/teachers/modify/:uuid", middleware.RequirePermission(domain.PermissionTeachersWrite), h.UpdateTeacher)
+		admin.GET("/teachers", middleware.RequirePermission(domain.PermissionTeachersRead), h.GetAllTeachers)
+		admin.GET("/teachers/:uuid", middleware.RequirePermission(domain.PermissionTeachersRead), h.GetTeacherByUUID)
 
 		// Manager
-		admin.POST("/managers", h.CreateManager)
+		admin.POST("/managers", middleware.RequirePermission(domain.PermissionManagersWrite), h.CreateManager)
 		// admin.PUT("/managers/modify/:uuid", h.UpdateManager)
-		admin.GET("/managers", h.GetAllManagers)
-		admin.GET("/managers/:uuid", h.GetManagerByUUID)
+		admin.GET("/managers", middleware.RequirePermission(domain.PermissionManagersRead), h.GetAllManagers)
+		admin.GET("/managers/:uuid", middleware.RequirePermission(domain.PermissionManagersRead), h.GetManagerByUUID)
 
 		// Student
-		admin.GET("/students", h.GetAllStudents)
-		admin.GET("/students/:uuid", h.GetStudentByUUID)
+		admin.POST("/students", middleware.RequirePermission(domain.PermissionStudentsWrite), h.CreateStudent)
+		admin.GET("/students", middleware.RequirePermission(domain.PermissionStudentsRead), h.GetAllStudents)
+		admin.GET("/students/:uuid", middleware.RequirePermission(domain.PermissionStudentsRead), h.GetStudentByUUID)
 
 		// Users
-		admin.GET("/users", h.GetAllUsers)
-		admin.DELETE("/users/:uuid", h.DeleteUser)
-		admin.PUT("/users/:uuid", h.ClearUserDeletedAt)
+		admin.GET("/users", middleware.RequirePermission(domain.PermissionUsersRead), h.GetAllUsers)
+		admin.DELETE("/users/:uuid", middleware.RequirePermission(domain.PermissionUsersWrite), h.DeleteUser)
+		admin.PUT("/users/:uuid", middleware.RequirePermission(domain.PermissionUsersWrite), h.ClearUserDeletedAt)
 
 		// Packages
-		admin.POST("/packages", h.CreatePackage)
-		admin.PUT("/packages/modify/:id", h.UpdatePackage)
-		admin.GET("/packages/:id", h.GetPackagesByID) // NOTE: get all packages, not by id
-		admin.DELETE("/packages/:id", h.DeletePackage)
-		admin.GET("/packages", h.GetAllPackages)
+		admin.POST("/packages", middleware.RequirePermission(domain.PermissionPackagesWrite), h.CreatePackage)
+		admin.PUT("/packages/modify/:id", middleware.RequirePermission(domain.PermissionPackagesWrite), h.UpdatePackage)
+		admin.GET("/packages/:id", middleware.RequirePermission(domain.PermissionPackagesRead), h.GetPackagesByID) // NOTE: get all packages, not by id
+		admin.DELETE("/packages/:id", middleware.RequirePermission(domain.PermissionPackagesWrite), h.DeletePackage)
+		admin.GET("/packages/:id/versions", middleware.RequirePermission(domain.PermissionPackagesRead), h.GetPackageVersions)
+		admin.GET("/packages", middleware.RequirePermission(domain.PermissionPackagesRead), h.GetAllPackages)
 
 		// Instruments
-		admin.POST("/instruments", h.CreateInstrument)
-		admin.PUT("/instruments/modify/:id", h.UpdateInstrument)
-		admin.DELETE("/instruments/:id", h.DeleteInstrument)
-		admin.GET("/instruments", h.GetAllInstruments)
+		admin.POST("/instruments", middleware.RequirePermission(domain.PermissionInstrumentsWrite), h.CreateInstrument)
+		admin.PUT("/instruments/modify/:id", middleware.RequirePermission(domain.PermissionInstrumentsWrite), h.UpdateInstrument)
+		admin.DELETE("/instruments/:id", middleware.RequirePermission(domain.PermissionInstrumentsWrite), h.DeleteInstrument)
+		admin.GET("/instruments", middleware.RequirePermission(domain.PermissionInstrumentsRead), h.GetAllInstruments)
 
 		// Assign package to student
-		admin.POST("/assign-package", h.AssignPackageToStudent)
+		admin.POST("/assign-package", middleware.RequirePermission(domain.PermissionPackagesAssign), h.AssignPackageToStudent)
 
 		// Class Histories
-		admin.GET("/class-histories", h.GetAllClassHistories)
+		admin.GET("/class-histories", middleware.RequirePermission(domain.PermissionClassHistoriesRead), h.GetAllClassHistories)
 
 		// Dashboard / Analytics
-		admin.GET("/profit", h.GetTotalProfit)
-		admin.GET("/payments/history", h.GetPaymentHistory)
-		admin.GET("/packages/summary", h.GetPackageSummary)
+		admin.GET("/profit", middleware.RequirePermission(domain.PermissionReportsRead), h.GetTotalProfit)
+		admin.GET("/payments/history", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetPaymentHistory)
+		admin.GET("/packages/summary", middleware.RequirePermission(domain.PermissionReportsRead), h.GetPackageSummary)
 	}
 }
 
@@ -272,7 +276,7 @@ func (h *AdminHandler) UpdatePackage(c *gin.Context) {
 	}
 
 	utils.PrintLogInfo(&name, 200, "UpdatePackage", nil)
-	c.JSON(http.StatusOK, gin.H{"message": "Package updated successfully", "success": true})
+	c.JSON(http.StatusOK, gin.H{"message": "Package updated successfully", "success": true, "data": pkg})
 }
 
 func (h *AdminHandler) DeletePackage(c *gin.Context) {
@@ -295,6 +299,30 @@ func (h *AdminHandler) DeletePackage(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Package deleted successfully", "success": true})
 }
 
+func (h *AdminHandler) GetPackageVersions(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetPackageVersions - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid package ID"})
+		return
+	}
+
+	versions, err := h.uc.GetPackageVersions(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			status = http.StatusNotFound
+		}
+		utils.PrintLogInfo(&name, status, "GetPackageVersions - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve package history"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetPackageVersions", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": versions})
+}
+
 func (h *AdminHandler) AssignPackageToStudent(c *gin.Context) {
 	var req AssignPackageRequest
 	name := utils.GetAPIHitter(c)
@@ -634,6 +662,45 @@ func (h *AdminHandler) GetAllUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": users, "message": "Users retrieved successfully"})
 }
 
+func (h *AdminHandler) CreateStudent(c *gin.Context) {
+	var req dto.CreateStudentRequest
+	adminName := utils.GetAPIHitter(c)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&adminName, 400, "CreateStudent - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   utils.TranslateValidationError(err),
+			"message": "Failed to create student",
+		})
+		return
+	}
+
+	user := dto.MapCreateStudentRequestToUser(&req)
+
+	created, err := h.uc.CreateStudent(c.Request.Context(), user)
+	if err != nil {
+		utils.PrintLogInfo(&adminName, 500, "CreateStudent - UseCase", &err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "sudah digunakan") || strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to create student",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(&adminName, 201, "CreateStudent", nil)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Student created successfully",
+	})
+}
+
 func (h *AdminHandler) GetAllStudents(c *gin.Context) {
 	name := utils.GetAPIHitter(c)
 	students, err := h.uc.GetAllStudents(c.Request.Context())
@@ -688,8 +755,15 @@ func (h *AdminHandler) GetTotalProfit(c *gin.Context) {
 		return
 	}
 
+	byMethod, err := h.uc.GetRevenueByMethod(c.Request.Context(), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetTotalProfit - GetRevenueByMethod", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to calculate profit"})
+		return
+	}
+
 	utils.PrintLogInfo(&name, 200, "GetTotalProfit", nil)
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"total_profit": profit}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"total_profit": profit, "by_method": byMethod}})
 }
 
 func (h *AdminHandler) GetPaymentHistory(c *gin.Context) {
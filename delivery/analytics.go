@@ -0,0 +1,50 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	uc domain.AnalyticsUseCase
+}
+
+func NewAnalyticsHandler(app *gin.Engine, uc domain.AnalyticsUseCase, jwtManager *utils.JWTManager) {
+	h := &AnalyticsHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionReportsRead))
+	{
+		admin.GET("/analytics/revenue", h.GetRevenueAnalytics)
+	}
+}
+
+func (h *AnalyticsHandler) GetRevenueAnalytics(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.RevenueAnalyticsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetRevenueAnalytics - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	analytics, err := h.uc.GetRevenueAnalytics(c.Request.Context(), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "tidak valid") || strings.Contains(err.Error(), "terlalu panjang") {
+			status = http.StatusBadRequest
+		}
+		utils.PrintLogInfo(&name, status, "GetRevenueAnalytics - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve revenue analytics"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetRevenueAnalytics", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": analytics})
+}
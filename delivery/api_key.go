@@ -0,0 +1,104 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	uc domain.APIKeyUseCase
+}
+
+func NewAPIKeyHandler(app *gin.Engine, uc domain.APIKeyUseCase, jwtManager *utils.JWTManager) {
+	h := &APIKeyHandler{uc: uc}
+
+	// Managed by users only: an API key can't mint or revoke keys.
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionAPIKeysManage))
+	{
+		admin.GET("/api-keys", h.GetAPIKeys)
+		admin.POST("/api-keys", h.CreateAPIKey)
+		admin.DELETE("/api-keys/:id", h.RevokeAPIKey)
+	}
+}
+
+func apiKeyErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "sudah dicabut"):
+		return http.StatusConflict
+	case strings.Contains(msg, "tidak dikenal"),
+		strings.Contains(msg, "tidak dapat diberikan"),
+		strings.Contains(msg, "maksimal"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	keys, err := h.uc.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetAPIKeys - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve API keys"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetAPIKeys", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": keys})
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "CreateAPIKey - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	created, err := h.uc.CreateAPIKey(c.Request.Context(), c.GetString("userUUID"), req)
+	if err != nil {
+		status := apiKeyErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "CreateAPIKey - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to create API key"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "CreateAPIKey", nil)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "API key created. Store the key now, it won't be shown again",
+	})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "RevokeAPIKey - ParseID", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid API key id", "message": "Invalid request"})
+		return
+	}
+
+	if err := h.uc.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		status := apiKeyErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "RevokeAPIKey - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to revoke API key"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "RevokeAPIKey", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "API key revoked"})
+}
@@ -0,0 +1,64 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	uc domain.AuditUseCase
+}
+
+func NewAuditHandler(app *gin.Engine, uc domain.AuditUseCase, jwtManager *utils.JWTManager) {
+	h := &AuditHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionAuditRead))
+	{
+		admin.GET("/audit", h.GetAuditLogs)
+	}
+}
+
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetAuditLogs - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid query"})
+		return
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	logs, total, err := h.uc.GetAuditLogs(c.Request.Context(), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "format tanggal") {
+			status = http.StatusBadRequest
+		}
+		utils.PrintLogInfo(&name, status, "GetAuditLogs - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve audit logs"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetAuditLogs", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    logs,
+		"meta": gin.H{
+			"page":  filter.Page,
+			"limit": filter.Limit,
+			"total": total,
+		},
+	})
+}
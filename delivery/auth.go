@@ -5,8 +5,11 @@ import (
 	"chronosphere/domain"
 	"chronosphere/middleware"
 	"chronosphere/utils"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -89,7 +92,39 @@ func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 
 }
 
+func clientInfo(c *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// refreshTokenFromRequest reads the refresh token from the cookie (web) or the JSON body
+// (mobile).
+func refreshTokenFromRequest(c *gin.Context) string {
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil && refreshToken != "" {
+		return refreshToken
+	}
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return ""
+	}
+	return req.RefreshToken
+}
+
 func (h *AuthHandler) Logout(c *gin.Context) {
+	// ✅ Revoke the session server-side so the refresh token can't be used again
+	if refreshToken := refreshTokenFromRequest(c); refreshToken != "" {
+		if err := h.authUC.Logout(c.Request.Context(), refreshToken); err != nil {
+			utils.PrintLogInfo(nil, 500, "Logout - RevokeSession", &err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to logout",
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
 	// ✅ Clear cookie (for web)
 	c.SetCookie(
 		"refresh_token",
@@ -108,72 +143,53 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 }
 
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	// Try to read refresh token from cookie (for Web)
-	refreshToken, err := c.Cookie("refresh_token")
-	if err != nil || refreshToken == "" {
-		// If not found in cookie, try from JSON body (for Mobile)
-		var req struct {
-			RefreshToken string `json:"refresh_token"`
-		}
-		if bindErr := c.ShouldBindJSON(&req); bindErr != nil || req.RefreshToken == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "No refresh token provided",
-			})
-			return
-		}
-		refreshToken = req.RefreshToken
-	}
-
-	// ✅ Verify refresh token
-	userUUID, role, name, err := h.authUC.GetRefreshTokenManager().VerifyToken(refreshToken)
-	if err != nil {
+	refreshToken := refreshTokenFromRequest(c)
+	if refreshToken == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "Invalid or expired refresh token",
+			"message": "No refresh token provided",
 		})
 		return
 	}
 
-	// ✅ Check if user still exists in database
-	_, err = h.authUC.Me(c.Request.Context(), userUUID)
+	// ✅ Rotate: the old refresh token is spent, reusing it revokes the session
+	tokens, err := h.authUC.RefreshTokens(c.Request.Context(), refreshToken, clientInfo(c))
 	if err != nil {
-		// User deleted - clear cookie and reject
+		utils.PrintLogInfo(nil, 401, "RefreshToken", &err)
+		// Session is gone (or user deleted) - clear cookie and reject
 		c.SetCookie("refresh_token", "", -1, "/", "", false, true)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "User account not found",
-			"error":   "user_deleted",
-		})
-		return
-	}
-
-	// ✅ Generate new access token
-	newAccessToken, err := h.authUC.GetAccessTokenManager().GenerateToken(userUUID, role, name)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to generate new access token",
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	// ✅ (Optional) Generate new refresh token for long sessions
-	newRefreshToken, err := h.authUC.GetRefreshTokenManager().GenerateToken(userUUID, role, name)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to generate new refresh token",
-			"error":   err.Error(),
-		})
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "User account not found",
+				"error":   "user_deleted",
+			})
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": err.Error(),
+				"error":   "refresh_token_reused",
+			})
+		case errors.Is(err, domain.ErrRefreshTokenRevoked):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Invalid or expired refresh token",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to refresh token",
+				"error":   err.Error(),
+			})
+		}
 		return
 	}
 
 	// ✅ For web clients, update HttpOnly cookie
 	c.SetCookie(
 		"refresh_token",
-		newRefreshToken,
+		tokens.RefreshToken,
 		60*60*24*7, // 7 days
 		"/",
 		"",    // ✅ replace in prod
@@ -185,8 +201,8 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
 		"message":       "Token refreshed successfully",
-		"access_token":  newAccessToken,
-		"refresh_token": newRefreshToken,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 	})
 }
 
@@ -245,6 +261,10 @@ func (h *AuthHandler) ResendOTP(c *gin.Context) {
 	}
 
 	if err := h.authUC.ResendOTP(c.Request.Context(), req.Email); err != nil {
+		if respondOTPCooldown(c, err, "Failed to resend OTP") {
+			utils.PrintLogInfo(&req.Email, 429, "ResendOTP", &err)
+			return
+		}
 		utils.PrintLogInfo(&req.Email, 500, "ResendOTP", &err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -358,8 +378,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	loweredEmail := strings.ToLower(req.Email)
-	tokens, err := h.authUC.Login(c.Request.Context(), loweredEmail, req.Password)
+	result, err := h.authUC.Login(c.Request.Context(), loweredEmail, req.Password, clientInfo(c))
 	if err != nil {
+		if respondAccountLocked(c, err) {
+			utils.PrintLogInfo(&loweredEmail, 423, "Login", &err)
+			return
+		}
 		utils.PrintLogInfo(&loweredEmail, 401, "Login", &err)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "Login failed",
@@ -369,6 +393,53 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// ✅ 2FA accounts continue at /auth/2fa/verify with the challenge token
+	if result.Challenge != nil {
+		utils.PrintLogInfo(&loweredEmail, 200, "Login - 2FA Challenge", nil)
+		c.JSON(http.StatusOK, gin.H{
+			"success":             true,
+			"two_factor_required": true,
+			"data":                result.Challenge,
+			"message":             "Two-factor authentication required",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(&loweredEmail, 200, "Login", nil)
+	respondWithLoginTokens(c, result)
+}
+
+// respondAccountLocked answers a login to a locked account with 423 and Retry-After. It
+// reports whether err was such a lock.
+func respondAccountLocked(c *gin.Context, err error) bool {
+	var locked *domain.AccountLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	retryAfter := int(time.Until(locked.Until).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusLocked, gin.H{
+		"success": false,
+		"message": "Login failed",
+		"error":   err.Error(),
+		"data":    gin.H{"locked_until": locked.Until},
+	})
+	return true
+}
+
+// respondWithLoginTokens sends the tokens of a completed login: web clients get the refresh
+// token as an HttpOnly cookie, mobile clients in the body.
+func respondWithLoginTokens(c *gin.Context, result *domain.LoginResult) {
+	tokens := result.Tokens
+	response := gin.H{
+		"success":      true,
+		"access_token": tokens.AccessToken,
+		"message":      "Login successful",
+	}
+	if len(result.RecoveryCodes) > 0 {
+		response["recovery_codes"] = result.RecoveryCodes
+	}
+
 	// ✅ Detect platform (Web or Mobile)
 	userAgent := c.Request.Header.Get("User-Agent")
 	isMobile := strings.Contains(strings.ToLower(userAgent), "okhttp") || // Android
@@ -386,24 +457,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			false, // ✅ secure (HTTPS only)
 			true,  // ✅ HttpOnly
 		)
-
-		utils.PrintLogInfo(&loweredEmail, 200, "Login", nil)
-		c.JSON(http.StatusOK, gin.H{
-			"success":      true,
-			"access_token": tokens.AccessToken,
-			"message":      "Login successful",
-		})
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
 	// ✅ For MOBILE: return both tokens
-	utils.PrintLogInfo(&loweredEmail, 200, "Login", nil)
-	c.JSON(http.StatusOK, gin.H{
-		"success":       true,
-		"access_token":  tokens.AccessToken,
-		"refresh_token": tokens.RefreshToken,
-		"message":       "Login successful",
-	})
+	response["refresh_token"] = tokens.RefreshToken
+	c.JSON(http.StatusOK, response)
 }
 
 type ForgotPasswordRequest struct {
@@ -428,6 +488,10 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	}
 
 	if err := h.authUC.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		if respondOTPCooldown(c, err, "Failed to process request") {
+			utils.PrintLogInfo(&req.Email, 429, "ForgotPassword", &err)
+			return
+		}
 		utils.PrintLogInfo(&req.Email, 500, "ForgotPassword", &err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -437,7 +501,23 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	}
 
 	utils.PrintLogInfo(&req.Email, 200, "ForgotPassword", nil)
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "OTP sent for reset password"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "If the email is registered, an OTP for resetting the password has been sent"})
+}
+
+// respondOTPCooldown answers an OTP request made during the email's resend cooldown with
+// 429 and Retry-After. It reports whether err was such a cooldown.
+func respondOTPCooldown(c *gin.Context, err error, message string) bool {
+	var cooldown *domain.OTPCooldownError
+	if !errors.As(err, &cooldown) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(cooldown.RetryAfterSeconds()))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"success": false,
+		"message": message,
+		"error":   err.Error(),
+	})
+	return true
 }
 
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
@@ -452,6 +532,14 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	}
 
 	if err := h.authUC.ResetPassword(c.Request.Context(), req.Email, req.OTP, req.NewPassword); err != nil {
+		if errors.Is(err, domain.ErrSessionRevocationFailed) {
+			utils.PrintLogInfo(&req.Email, 500, "ResetPassword - RevokeSessions", &err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Password reset, but other sessions could not be logged out",
+				"error":   err.Error()})
+			return
+		}
 		utils.PrintLogInfo(&req.Email, 401, "ResetPassword", &err)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
@@ -487,6 +575,14 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.authUC.ChangePassword(c.Request.Context(), userUUID.(string), req.OldPassword, req.NewPassword); err != nil {
+		if errors.Is(err, domain.ErrSessionRevocationFailed) {
+			utils.PrintLogInfo(nil, 500, "ChangePassword - RevokeSessions", &err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Password changed, but other sessions could not be logged out",
+				"error":   err.Error()})
+			return
+		}
 		utils.PrintLogInfo(nil, 401, "ChangePassword", &err)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
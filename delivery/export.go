@@ -0,0 +1,152 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	uc domain.ExportUseCase
+}
+
+func NewExportHandler(app *gin.Engine, uc domain.ExportUseCase, jwtManager *utils.JWTManager) {
+	h := &ExportHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionReportsExport))
+	{
+		admin.GET("/payments/history/export", h.export(domain.ExportReportPayments))
+		admin.GET("/class-histories/export", h.export(domain.ExportReportClassHistories))
+		admin.GET("/students/export", h.export(domain.ExportReportStudents))
+		admin.GET("/teachers/export", h.export(domain.ExportReportTeachers))
+
+		admin.GET("/exports", h.GetExportJobs)
+		admin.GET("/exports/:id", h.GetExportJob)
+		admin.GET("/exports/:id/download", h.DownloadExport)
+	}
+}
+
+func exportErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "belum selesai"), strings.Contains(errMsg, "export gagal"):
+		return http.StatusConflict
+	case strings.Contains(errMsg, "kedaluwarsa"):
+		return http.StatusGone
+	}
+	return http.StatusInternalServerError
+}
+
+// export streams the report as CSV or XLSX (?format=), or answers 202 with a background
+// job when the report is too large to finish within the request timeout.
+func (h *ExportHandler) export(report string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := utils.GetAPIHitter(c)
+		var filter domain.ExportFilter
+		if err := c.ShouldBindQuery(&filter); err != nil {
+			utils.PrintLogInfo(&name, 400, "Export - BindQuery", &err)
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+			return
+		}
+
+		file, job, err := h.uc.PrepareExport(c.Request.Context(), c.GetString("userUUID"), report, filter)
+		if err != nil {
+			status := exportErrorStatus(err.Error())
+			utils.PrintLogInfo(&name, status, "Export - PrepareExport", &err)
+			c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to export " + report})
+			return
+		}
+
+		if job != nil {
+			utils.PrintLogInfo(&name, 202, "Export - Queued", nil)
+			c.JSON(http.StatusAccepted, gin.H{
+				"success": true,
+				"data":    job,
+				"message": fmt.Sprintf("Export is being prepared, check /admin/exports/%d for the download link", job.ID),
+			})
+			return
+		}
+
+		c.Header("Content-Type", file.ContentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+		c.Status(http.StatusOK)
+		if _, err := h.uc.WriteExport(c.Request.Context(), report, filter, c.Writer); err != nil {
+			// Headers are already sent, so the client only sees a truncated file.
+			utils.PrintLogInfo(&name, 500, "Export - WriteExport", &err)
+			return
+		}
+		utils.PrintLogInfo(&name, 200, "Export", nil)
+	}
+}
+
+func (h *ExportHandler) GetExportJobs(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	jobs, err := h.uc.GetJobs(c.Request.Context(), c.GetString("userUUID"))
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetExportJobs - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve exports"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetExportJobs", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": jobs})
+}
+
+func (h *ExportHandler) GetExportJob(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetExportJob - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid export ID"})
+		return
+	}
+
+	job, err := h.uc.GetJob(c.Request.Context(), id)
+	if err != nil {
+		status := exportErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "GetExportJob - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve export"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetExportJob", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": job})
+}
+
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "DownloadExport - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid export ID"})
+		return
+	}
+
+	_, file, rc, err := h.uc.OpenJobFile(c.Request.Context(), id)
+	if err != nil {
+		status := exportErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "DownloadExport - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to download export"})
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Type", file.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		utils.PrintLogInfo(&name, 500, "DownloadExport - Copy", &err)
+		return
+	}
+	utils.PrintLogInfo(&name, 200, "DownloadExport", nil)
+}
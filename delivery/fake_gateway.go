@@ -0,0 +1,128 @@
+package delivery
+
+import (
+	"chronosphere/domain"
+	"chronosphere/gateway"
+	"chronosphere/utils"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FakeGatewayHandler serves the local payment page used when PAYMENT_GATEWAY=fake.
+type FakeGatewayHandler struct {
+	gw *gateway.FakeGateway
+}
+
+var fakeInvoiceListTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Fake Gateway</title></head>
+<body>
+<h1>Fake Gateway - Invoices</h1>
+<table border="1" cellpadding="6">
+<tr><th>Invoice</th><th>External ID</th><th>Amount</th><th>Status</th></tr>
+{{range .}}<tr><td><a href="/fake-gateway/invoices/{{.ID}}">{{.ID}}</a></td><td>{{.ExternalID}}</td><td>{{printf "%.2f" .Amount}}</td><td>{{.Status}}</td></tr>
+{{else}}<tr><td colspan="4">No invoices yet</td></tr>{{end}}
+</table>
+</body></html>`))
+
+var fakeInvoiceTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Fake Gateway - {{.Invoice.ID}}</title></head>
+<body>
+<h1>Fake Gateway</h1>
+<p><b>Invoice:</b> {{.Invoice.ID}}<br>
+<b>External ID:</b> {{.Invoice.ExternalID}}<br>
+<b>Description:</b> {{.Invoice.Description}}<br>
+<b>Payer:</b> {{.Invoice.PayerEmail}}<br>
+<b>Amount:</b> {{printf "%.2f" .Invoice.Amount}}<br>
+<b>Status:</b> {{.Invoice.Status}}<br>
+<b>Expires:</b> {{.Invoice.ExpiresAt.Format "2006-01-02 15:04:05"}}</p>
+{{if .Error}}<p><b>Error:</b> {{.Error}}</p>{{end}}
+{{if eq .Invoice.Status "PENDING"}}
+<form method="post" action="/fake-gateway/invoices/{{.Invoice.ID}}/pay">
+<label>Channel <select name="channel">{{range .Channels}}<option value="{{.}}">{{.}}</option>{{end}}</select></label>
+<button type="submit">Pay</button>
+</form>
+<form method="post" action="/fake-gateway/invoices/{{.Invoice.ID}}/expire"><button type="submit">Expire</button></form>
+<form method="post" action="/fake-gateway/invoices/{{.Invoice.ID}}/fail"><button type="submit">Fail</button></form>
+{{end}}
+<p><a href="/fake-gateway/invoices">All invoices</a></p>
+</body></html>`))
+
+func NewFakeGatewayHandler(app *gin.Engine, gw *gateway.FakeGateway) {
+	h := &FakeGatewayHandler{gw: gw}
+
+	fake := app.Group("/fake-gateway")
+	{
+		fake.GET("/invoices", h.ListInvoices)
+		fake.GET("/invoices/:id", h.ShowInvoice)
+		fake.POST("/invoices/:id/:action", h.SimulateInvoice)
+	}
+}
+
+func (h *FakeGatewayHandler) ListInvoices(c *gin.Context) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := fakeInvoiceListTemplate.Execute(c.Writer, h.gw.Invoices()); err != nil {
+		name := utils.GetAPIHitter(c)
+		utils.PrintLogInfo(&name, 500, "FakeGateway - ListInvoices", &err)
+	}
+}
+
+func (h *FakeGatewayHandler) ShowInvoice(c *gin.Context) {
+	h.renderInvoice(c, http.StatusOK, c.Param("id"), "")
+}
+
+func (h *FakeGatewayHandler) SimulateInvoice(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id := c.Param("id")
+
+	var status string
+	switch c.Param("action") {
+	case "pay":
+		status = domain.PaymentStatusPaid
+	case "expire":
+		status = domain.PaymentStatusExpired
+	case "fail":
+		status = domain.PaymentStatusFailed
+	default:
+		h.renderInvoice(c, http.StatusBadRequest, id, "unknown action")
+		return
+	}
+
+	_, redirect, err := h.gw.Simulate(c.Request.Context(), id, status, c.PostForm("channel"))
+	if err != nil {
+		utils.PrintLogInfo(&name, 400, "FakeGateway - Simulate", &err)
+		h.renderInvoice(c, http.StatusBadRequest, id, err.Error())
+		return
+	}
+
+	utils.PrintLogInfo(&name, 303, "FakeGateway - Simulate "+strings.ToUpper(c.Param("action")), nil)
+	if redirect == "" {
+		redirect = "/fake-gateway/invoices/" + id
+	}
+	c.Redirect(http.StatusSeeOther, redirect)
+}
+
+func (h *FakeGatewayHandler) renderInvoice(c *gin.Context, status int, id, errMsg string) {
+	inv, err := h.gw.GetInvoice(c.Request.Context(), id)
+	if err != nil {
+		c.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	channels := make([]string, 0, len(gateway.FakeChannels))
+	for ch := range gateway.FakeChannels {
+		channels = append(channels, ch)
+	}
+	sort.Strings(channels)
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	_ = fakeInvoiceTemplate.Execute(c.Writer, gin.H{
+		"Invoice":  inv,
+		"Channels": channels,
+		"Error":    errMsg,
+	})
+}
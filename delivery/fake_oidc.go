@@ -0,0 +1,137 @@
+package delivery
+
+import (
+	"chronosphere/domain"
+	"chronosphere/gateway"
+	"chronosphere/utils"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FakeOIDCHandler serves the local OpenID Connect issuer used when OIDC_ISSUER=fake.
+type FakeOIDCHandler struct {
+	issuer *gateway.FakeOIDCIssuer
+}
+
+var fakeOIDCLoginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Fake OIDC - Login</title></head>
+<body>
+<h1>Fake OIDC Issuer</h1>
+{{if .Error}}<p><b>Error:</b> {{.Error}}</p>{{end}}
+{{if .Login}}
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="client_id" value="{{.Login.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Login.RedirectURI}}">
+<input type="hidden" name="response_type" value="{{.Login.ResponseType}}">
+<input type="hidden" name="scope" value="{{.Login.Scope}}">
+<input type="hidden" name="state" value="{{.Login.State}}">
+<input type="hidden" name="nonce" value="{{.Login.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Login.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Login.CodeChallengeMethod}}">
+<p><label>Email <input type="email" name="email" value="{{.Login.Email}}" required></label></p>
+<p><label>Name <input type="text" name="name" value="{{.Login.Name}}"></label></p>
+<p><label><input type="checkbox" name="email_verified" value="true" checked> Email verified</label></p>
+<button type="submit">Log in</button>
+</form>
+<p>Client: {{.Login.ClientID}}<br>Redirect: {{.Login.RedirectURI}}</p>
+{{end}}
+</body></html>`))
+
+func NewFakeOIDCHandler(app *gin.Engine, issuer *gateway.FakeOIDCIssuer) {
+	h := &FakeOIDCHandler{issuer: issuer}
+
+	fake := app.Group(domain.OIDCFakeIssuerPath)
+	{
+		fake.GET("/.well-known/openid-configuration", h.Discovery)
+		fake.GET("/jwks", h.JWKS)
+		fake.GET("/authorize", h.ShowLogin)
+		fake.POST("/authorize", h.Login)
+		fake.POST("/token", h.Token)
+	}
+}
+
+func (h *FakeOIDCHandler) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, h.issuer.Discovery())
+}
+
+func (h *FakeOIDCHandler) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.issuer.JWKS())
+}
+
+func fakeOIDCLoginFrom(get func(string) string) gateway.FakeOIDCLogin {
+	return gateway.FakeOIDCLogin{
+		ClientID:            get("client_id"),
+		RedirectURI:         get("redirect_uri"),
+		ResponseType:        get("response_type"),
+		Scope:               get("scope"),
+		State:               get("state"),
+		Nonce:               get("nonce"),
+		CodeChallenge:       get("code_challenge"),
+		CodeChallengeMethod: get("code_challenge_method"),
+		Email:               get("email"),
+		Name:                get("name"),
+		EmailVerified:       get("email_verified") == "true",
+	}
+}
+
+func (h *FakeOIDCHandler) ShowLogin(c *gin.Context) {
+	login := fakeOIDCLoginFrom(c.Query)
+	if err := h.issuer.ValidateAuthorization(login); err != nil {
+		name := utils.GetAPIHitter(c)
+		utils.PrintLogInfo(&name, 400, "FakeOIDC - Authorize", &err)
+		h.renderLogin(c, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	h.renderLogin(c, http.StatusOK, &login, "")
+}
+
+func (h *FakeOIDCHandler) Login(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	login := fakeOIDCLoginFrom(c.PostForm)
+
+	redirect, err := h.issuer.Authorize(login)
+	if err != nil {
+		utils.PrintLogInfo(&name, 400, "FakeOIDC - Login", &err)
+		if h.issuer.ValidateAuthorization(login) != nil {
+			h.renderLogin(c, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		h.renderLogin(c, http.StatusBadRequest, &login, err.Error())
+		return
+	}
+
+	utils.PrintLogInfo(&name, 303, "FakeOIDC - Login", nil)
+	c.Redirect(http.StatusSeeOther, redirect)
+}
+
+func (h *FakeOIDCHandler) Token(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	c.Header("Cache-Control", "no-store")
+
+	token, err := h.issuer.Token(c.Request)
+	if err != nil {
+		var oauthErr *gateway.FakeOIDCError
+		if !errors.As(err, &oauthErr) {
+			oauthErr = &gateway.FakeOIDCError{Status: http.StatusInternalServerError, Code: "server_error", Description: err.Error()}
+		}
+		utils.PrintLogInfo(&name, oauthErr.Status, "FakeOIDC - Token", &err)
+		c.JSON(oauthErr.Status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "FakeOIDC - Token", nil)
+	c.JSON(http.StatusOK, token)
+}
+
+func (h *FakeOIDCHandler) renderLogin(c *gin.Context, status int, login *gateway.FakeOIDCLogin, errMsg string) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	_ = fakeOIDCLoginTemplate.Execute(c.Writer, gin.H{
+		"Action": domain.OIDCFakeIssuerPath + "/authorize",
+		"Login":  login,
+		"Error":  errMsg,
+	})
+}
@@ -0,0 +1,171 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FileHandler struct {
+	uc domain.FileUseCase
+}
+
+type SignFileRequest struct {
+	Key string `json:"key" binding:"required,max=255"`
+}
+
+func NewFileHandler(app *gin.Engine, uc domain.FileUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &FileHandler{uc: uc}
+	auth := config.AuthMiddleware(jwtManager)
+
+	files := app.Group("/files")
+	{
+		files.POST("/profile-image", auth, middleware.ValidateTurnedOffUserMiddleware(db), h.UploadProfileImage)
+		files.POST("/sign", auth, h.SignFileURL)
+		files.GET("/*key", signedOrAuth(auth), h.ServeFile)
+	}
+
+	teacher := app.Group("/teacher")
+	teacher.Use(auth, middleware.RequirePermission(domain.PermissionTeacherPortal), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		teacher.POST("/documentations/upload", h.UploadDocumentation)
+	}
+}
+
+// signedOrAuth lets signed links through without a bearer token; the handler verifies the signature.
+func signedOrAuth(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query(domain.SignedURLSigParam) != "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+func fileErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak memiliki akses"):
+		return http.StatusForbidden
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "kedaluwarsa"):
+		return http.StatusUnauthorized
+	case strings.Contains(errMsg, "melebihi batas"):
+		return http.StatusRequestEntityTooLarge
+	case strings.Contains(errMsg, "tidak diizinkan"):
+		return http.StatusUnsupportedMediaType
+	case strings.Contains(errMsg, "tidak valid"), strings.Contains(errMsg, "tidak boleh kosong"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *FileHandler) UploadProfileImage(c *gin.Context) {
+	h.upload(c, domain.FilePurposeProfile, "UploadProfileImage")
+}
+
+func (h *FileHandler) UploadDocumentation(c *gin.Context) {
+	h.upload(c, domain.FilePurposeDocumentation, "UploadDocumentation")
+}
+
+func (h *FileHandler) upload(c *gin.Context, purpose, funcName string) {
+	name := utils.GetAPIHitter(c)
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		utils.PrintLogInfo(&name, 401, funcName, nil)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: missing user context",
+			"message": "Failed to upload file",
+		})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.PrintLogInfo(&name, 400, funcName+" - FormFile", &err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Field 'file' wajib diisi (multipart/form-data)",
+			"message": "Failed to upload file",
+		})
+		return
+	}
+
+	file, err := h.uc.Upload(c.Request.Context(), userUUID.(string), purpose, header)
+	if err != nil {
+		status := fileErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, funcName+" - UseCase", &err)
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to upload file",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, funcName, nil)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    file,
+		"message": "File uploaded successfully",
+	})
+}
+
+func (h *FileHandler) SignFileURL(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req SignFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "SignFileURL - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	signedURL, expiresAt, err := h.uc.SignURL(c.Request.Context(), req.Key, c.GetString("userUUID"), c.GetString("role"))
+	if err != nil {
+		status := fileErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "SignFileURL - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to sign file URL"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "SignFileURL", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"url": signedURL, "expires_at": expiresAt}})
+}
+
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	var (
+		file *domain.StoredFile
+		rc   io.ReadCloser
+		err  error
+	)
+	if sig := c.Query(domain.SignedURLSigParam); sig != "" {
+		file, rc, err = h.uc.OpenSigned(c.Request.Context(), key, c.Query(domain.SignedURLExpiresParam), sig)
+	} else {
+		file, rc, err = h.uc.Open(c.Request.Context(), key, c.GetString("userUUID"), c.GetString("role"))
+	}
+	if err != nil {
+		status := fileErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "ServeFile - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, rc, map[string]string{
+		"Cache-Control":       "private, max-age=300",
+		"Content-Disposition": fmt.Sprintf("inline; filename=%q", file.OriginalName),
+	})
+}
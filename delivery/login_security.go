@@ -0,0 +1,110 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LoginSecurityHandler struct {
+	uc domain.LoginSecurityUseCase
+}
+
+func NewLoginSecurityHandler(app *gin.Engine, uc domain.LoginSecurityUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &LoginSecurityHandler{uc: uc}
+
+	auth := app.Group("/auth")
+	auth.Use(config.AuthMiddleware(jwtManager), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		auth.GET("/login-history", h.GetMyLoginHistory)
+	}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionUsersSecurity))
+	{
+		admin.GET("/login-history", h.GetLoginHistory)
+		admin.POST("/users/:uuid/unlock", h.UnlockUser)
+	}
+}
+
+func bindLoginHistoryFilter(c *gin.Context) (domain.LoginHistoryFilter, error) {
+	var filter domain.LoginHistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		return filter, err
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	return filter, nil
+}
+
+func (h *LoginSecurityHandler) respondLoginHistory(c *gin.Context, name string, step string, filter domain.LoginHistoryFilter) {
+	history, total, err := h.uc.GetLoginHistory(c.Request.Context(), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, step+" - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve login history"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, step, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+		"meta": gin.H{
+			"page":  filter.Page,
+			"limit": filter.Limit,
+			"total": total,
+		},
+	})
+}
+
+func (h *LoginSecurityHandler) GetMyLoginHistory(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	filter, err := bindLoginHistoryFilter(c)
+	if err != nil {
+		utils.PrintLogInfo(&name, 400, "GetMyLoginHistory - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid query"})
+		return
+	}
+	filter.UserUUID = c.GetString("userUUID")
+	filter.Email = ""
+
+	h.respondLoginHistory(c, name, "GetMyLoginHistory", filter)
+}
+
+func (h *LoginSecurityHandler) GetLoginHistory(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	filter, err := bindLoginHistoryFilter(c)
+	if err != nil {
+		utils.PrintLogInfo(&name, 400, "GetLoginHistory - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid query"})
+		return
+	}
+
+	h.respondLoginHistory(c, name, "GetLoginHistory", filter)
+}
+
+func (h *LoginSecurityHandler) UnlockUser(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	if err := h.uc.Unlock(c.Request.Context(), c.Param("uuid")); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			status = http.StatusNotFound
+		}
+		utils.PrintLogInfo(&name, status, "UnlockUser - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to unlock user"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "UnlockUser", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "User unlocked"})
+}
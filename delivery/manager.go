@@ -22,12 +22,12 @@ func NewManagerHandler(app *gin.Engine, uc domain.ManagerUseCase, jwtManager *ut
 	h := &ManagerHandler{uc: uc}
 
 	manager := app.Group("/manager")
-	manager.Use(config.AuthMiddleware(jwtManager), middleware.ManagerAndAdminOnly(), middleware.ValidateTurnedOffUserMiddleware(db))
+	manager.Use(config.AuthMiddleware(jwtManager), middleware.ValidateTurnedOffUserMiddleware(db))
 	{
-		manager.GET("/students", h.GetAllStudents)
-		manager.GET("/students/:uuid", h.GetStudentByUUID)
-		manager.PUT("/students/:uuid/packages/:package_id/quota", h.ModifyStudentPackageQuota)
-		manager.PUT("/modify", h.UpdateManager)
+		manager.GET("/students", middleware.RequirePermission(domain.PermissionStudentsRead), h.GetAllStudents)
+		manager.GET("/students/:uuid", middleware.RequirePermission(domain.PermissionStudentsRead), h.GetStudentByUUID)
+		manager.PUT("/students/:uuid/packages/:package_id/quota", middleware.RequirePermission(domain.PermissionQuotaModify), h.ModifyStudentPackageQuota)
+		manager.PUT("/modify", middleware.RequirePermission(domain.PermissionManagerProfileWrite), h.UpdateManager)
 	}
 }
 
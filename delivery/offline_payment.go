@@ -0,0 +1,86 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OfflinePaymentHandler struct {
+	uc    domain.PaymentUseCase
+	files domain.FileUseCase
+}
+
+func NewOfflinePaymentHandler(app *gin.Engine, uc domain.PaymentUseCase, files domain.FileUseCase, jwtManager *utils.JWTManager) {
+	h := &OfflinePaymentHandler{uc: uc, files: files}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionPaymentsWrite))
+	{
+		admin.POST("/payments/offline", h.RecordOfflinePayment)
+	}
+}
+
+func offlinePaymentErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "sudah ada"):
+		return http.StatusConflict
+	case strings.Contains(errMsg, "wajib diisi"),
+		strings.Contains(errMsg, "tidak valid"),
+		strings.Contains(errMsg, "tidak diizinkan"),
+		strings.Contains(errMsg, "melebihi batas"),
+		strings.Contains(errMsg, "tidak boleh kosong"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// RecordOfflinePayment records a cash/transfer/EDC payment taken at the studio and activates
+// the package. Send multipart/form-data with a "proof" file to attach a slip or photo.
+func (h *OfflinePaymentHandler) RecordOfflinePayment(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	staffUUID := c.GetString("userUUID")
+
+	var req domain.OfflinePaymentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "RecordOfflinePayment - Bind", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Failed to record payment"})
+		return
+	}
+
+	header, err := c.FormFile("proof")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+		utils.PrintLogInfo(&name, 400, "RecordOfflinePayment - FormFile", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error(), "message": "Failed to read proof file"})
+		return
+	}
+	if header != nil {
+		file, err := h.files.Upload(c.Request.Context(), staffUUID, domain.FilePurposePaymentProof, header)
+		if err != nil {
+			status := offlinePaymentErrorStatus(err.Error())
+			utils.PrintLogInfo(&name, status, "RecordOfflinePayment - Upload", &err)
+			c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to upload proof file"})
+			return
+		}
+		req.ProofFileKey = &file.Key
+	}
+
+	payment, err := h.uc.RecordOfflinePayment(c.Request.Context(), staffUUID, req)
+	if err != nil {
+		status := offlinePaymentErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "RecordOfflinePayment - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to record payment"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "RecordOfflinePayment", nil)
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": payment, "message": "Offline payment recorded and package activated"})
+}
@@ -0,0 +1,129 @@
+package delivery
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	authUC domain.AuthUseCase
+}
+
+// NewOIDCHandler serves the OIDC login: /authorize returns the provider URL, the frontend
+// page at OIDC_REDIRECT_URL posts the code it gets back to /callback, and a first-time
+// user completes their student account at /signup.
+func NewOIDCHandler(app *gin.Engine, authUC domain.AuthUseCase) {
+	h := &OIDCHandler{authUC: authUC}
+
+	oidc := app.Group("/auth/oidc")
+	{
+		oidc.GET("/authorize", h.Authorize)
+		oidc.POST("/callback", h.Callback)
+		oidc.POST("/signup", h.Signup)
+	}
+}
+
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrOIDCDisabled):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrOIDCStateInvalid), errors.Is(err, domain.ErrOIDCSignupInvalid),
+		errors.Is(err, domain.ErrOIDCEmailUnverified), errors.Is(err, domain.ErrUserNotFound),
+		strings.Contains(err.Error(), "login OIDC gagal"), strings.Contains(err.Error(), "dinonaktifkan"):
+		return http.StatusUnauthorized
+	case strings.Contains(err.Error(), "sudah digunakan"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "wajib"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	authorization, err := h.authUC.StartOIDCLogin(c.Request.Context())
+	if err != nil {
+		status := oidcErrorStatus(err)
+		utils.PrintLogInfo(nil, status, "OIDCAuthorize - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to start OIDC login"})
+		return
+	}
+
+	utils.PrintLogInfo(nil, 200, "OIDCAuthorize", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": authorization})
+}
+
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req domain.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(nil, 400, "OIDCCallback - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	result, err := h.authUC.CompleteOIDCLogin(c.Request.Context(), req.State, req.Code, clientInfo(c))
+	if err != nil {
+		if respondAccountLocked(c, err) {
+			utils.PrintLogInfo(nil, 423, "OIDCCallback - UseCase", &err)
+			return
+		}
+		status := oidcErrorStatus(err)
+		utils.PrintLogInfo(nil, status, "OIDCCallback - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Login failed"})
+		return
+	}
+
+	// ✅ No account with this email yet: the client collects phone and gender for /auth/oidc/signup
+	if result.Signup != nil {
+		utils.PrintLogInfo(&result.Signup.Email, 200, "OIDCCallback - Signup Required", nil)
+		c.JSON(http.StatusOK, gin.H{
+			"success":         true,
+			"signup_required": true,
+			"data":            result.Signup,
+			"message":         "Complete your profile to finish signing up",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(nil, 200, "OIDCCallback", nil)
+	respondWithOIDCLogin(c, result)
+}
+
+// respondWithOIDCLogin answers with the tokens, or like /auth/login, with the 2FA
+// challenge to continue at /auth/2fa/verify.
+func respondWithOIDCLogin(c *gin.Context, result *domain.LoginResult) {
+	if result.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success":             true,
+			"two_factor_required": true,
+			"data":                result.Challenge,
+			"message":             "Two-factor authentication required",
+		})
+		return
+	}
+	respondWithLoginTokens(c, result)
+}
+
+func (h *OIDCHandler) Signup(c *gin.Context) {
+	var req domain.OIDCSignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(nil, 400, "OIDCSignup - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	result, err := h.authUC.CompleteOIDCSignup(c.Request.Context(), req, clientInfo(c))
+	if err != nil {
+		status := oidcErrorStatus(err)
+		utils.PrintLogInfo(nil, status, "OIDCSignup - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Signup failed"})
+		return
+	}
+
+	utils.PrintLogInfo(nil, 200, "OIDCSignup", nil)
+	respondWithOIDCLogin(c, result)
+}
@@ -5,10 +5,9 @@ import (
 	"chronosphere/middleware"
 	"chronosphere/utils"
 	"net/http"
-	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/xendit/xendit-go/v6/invoice"
 )
 
 type PaymentHandler struct {
@@ -23,10 +22,9 @@ func NewPaymentHandler(r *gin.Engine, paymentUseCase domain.PaymentUseCase, auth
 	paymentGroup := r.Group("/api/v1/payment")
 	{
 		// Authenticated route for checkout
-		paymentGroup.POST("/checkout", authMiddleware, middleware.StudentOnly(), handler.Checkout)
+		paymentGroup.POST("/checkout", authMiddleware, middleware.RequirePermission(domain.PermissionStudentPortal), handler.Checkout)
 
-		// Public route for webhook (Xendit will call this)
-		paymentGroup.POST("/callback", handler.PaymentCallback)
+		// The public webhook route (/callback) is registered by WebhookHandler.
 	}
 }
 
@@ -37,6 +35,7 @@ func NewPaymentHandler(r *gin.Engine, paymentUseCase domain.PaymentUseCase, auth
 // @Accept json
 // @Produce json
 // @Param request body domain.CheckoutRequest true "Checkout Request"
+// @Param Idempotency-Key header string false "Replays the original response when the same key is sent again"
 // @Success 200 {object} domain.CheckoutResponse
 // @Failure 401 {object} utils.Response
 // @Router /payment/checkout [post]
@@ -58,63 +57,35 @@ func (h *PaymentHandler) Checkout(c *gin.Context) {
 		return
 	}
 
+	req.IdempotencyKey = strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(req.IdempotencyKey) > domain.MaxIdempotencyKeyLength {
+		utils.PrintLogInfo(&studentName, http.StatusBadRequest, "Checkout - Idempotency-Key", nil)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+		return
+	}
+
 	resp, err := h.paymentUseCase.CreateInvoice(c.Request.Context(), studentUUID, req)
 	if err != nil {
-		utils.PrintLogInfo(&studentName, http.StatusInternalServerError, "Checkout - Create Invoice", &err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errMsg := err.Error(); strings.Contains(errMsg, "Idempotency-Key") || strings.Contains(errMsg, "batas invoice") ||
+			strings.Contains(errMsg, "masih diproses") {
+			status = http.StatusConflict
+		} else if strings.Contains(errMsg, "voucher") || strings.Contains(errMsg, "minimum pembayaran") {
+			status = http.StatusBadRequest
+		}
+		utils.PrintLogInfo(&studentName, status, "Checkout - Create Invoice", &err)
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
+	message := "Invoice created successfully"
+	if resp.Reused {
+		message = "Existing unpaid invoice returned"
+	}
+
 	utils.PrintLogInfo(&studentName, http.StatusOK, "Checkout - Create Invoice", nil)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Invoice created successfully",
+		"message": message,
 		"data":    resp,
 	})
 }
-
-// PaymentCallback godoc
-// @Summary Handle Xendit Callback
-// @Description Webhook receiver for payment updates
-// @Tags payment
-// @Accept json
-// @Produce json
-// @Router /payment/callback [post]
-func (h *PaymentHandler) PaymentCallback(c *gin.Context) {
-	// Verify Xendit Callback Token or Header (Optional but recommended)
-	// Xendit sends `x-callback-token` header.
-	token := c.GetHeader("x-callback-token")
-	expectedToken := os.Getenv("XENDIT_WEBHOOK_TOKEN")
-
-	if expectedToken != "" && token != expectedToken {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid callback token"})
-		return
-	}
-
-	// Parse Body
-	var invoiceData invoice.Invoice
-	if err := c.ShouldBindJSON(&invoiceData); err != nil {
-		// Xendit might send test events differently, but assuming standard Invoice Callback
-		// Fallback or log error
-		// Note: Xendit v6 SDK might expect specific structs.
-		// If binding fails, it might be a different event type or malformed.
-		// For now simple binding.
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse webhook body"})
-		return
-	}
-
-	// Process
-	err := h.paymentUseCase.HandleCallback(c.Request.Context(), &invoiceData)
-	if err != nil {
-		utils.PrintPretty(invoiceData)
-		utils.PrintPretty(err)
-		// We return 200 even on error to tell Xendit we received it,
-		// otherwise they keep retrying. But maybe 500 if temporary error?
-		// Usually 5xx triggers retry. 4xx doesn't.
-		// If it's a logic error (e.g. payment not found), maybe 200 is fine but log it.
-		// Let's return 500 for now if it fails so I successfully get alerts if logic is wrong.
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
-}
@@ -0,0 +1,319 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PayrollHandler struct {
+	uc domain.PayrollUseCase
+}
+
+type PayRateRequest struct {
+	TeacherUUID     *string `json:"teacher_uuid" binding:"omitempty,uuid"`
+	InstrumentID    *int    `json:"instrument_id" binding:"omitempty,gt=0"`
+	PackageDuration *int    `json:"package_duration" binding:"omitempty,oneof=30 60"`
+	CompletedRate   float64 `json:"completed_rate" binding:"gte=0"`
+	NoShowRate      float64 `json:"no_show_rate" binding:"gte=0"`
+	LateCancelRate  float64 `json:"late_cancel_rate" binding:"gte=0"`
+}
+
+func (r *PayRateRequest) toDomain(id int) *domain.TeacherPayRate {
+	return &domain.TeacherPayRate{
+		ID:              id,
+		TeacherUUID:     r.TeacherUUID,
+		InstrumentID:    r.InstrumentID,
+		PackageDuration: r.PackageDuration,
+		CompletedRate:   r.CompletedRate,
+		NoShowRate:      r.NoShowRate,
+		LateCancelRate:  r.LateCancelRate,
+	}
+}
+
+func NewPayrollHandler(app *gin.Engine, uc domain.PayrollUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &PayrollHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager))
+	{
+		// Pay rates
+		admin.POST("/payroll/rates", middleware.RequirePermission(domain.PermissionPayrollWrite), h.CreatePayRate)
+		admin.GET("/payroll/rates", middleware.RequirePermission(domain.PermissionPayrollRead), h.GetAllPayRates)
+		admin.PUT("/payroll/rates/modify/:id", middleware.RequirePermission(domain.PermissionPayrollWrite), h.UpdatePayRate)
+		admin.DELETE("/payroll/rates/:id", middleware.RequirePermission(domain.PermissionPayrollWrite), h.DeletePayRate)
+
+		// Runs
+		admin.GET("/payroll/preview", middleware.RequirePermission(domain.PermissionPayrollRead), h.PreviewPayroll)
+		admin.POST("/payroll/runs", middleware.RequirePermission(domain.PermissionPayrollWrite), h.RunPayroll)
+		admin.GET("/payroll/runs", middleware.RequirePermission(domain.PermissionPayrollRead), h.GetAllPayrollRuns)
+		admin.GET("/payroll/runs/:id", middleware.RequirePermission(domain.PermissionPayrollRead), h.GetPayrollRunByID)
+		admin.GET("/payroll/runs/:id/export", middleware.RequirePermission(domain.PermissionPayrollRead), h.ExportPayrollRun)
+	}
+
+	teacher := app.Group("/teacher")
+	teacher.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionTeacherPortal), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		teacher.GET("/payslips", h.GetMyPayslips)
+	}
+}
+
+func payrollErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "sudah ada"), strings.Contains(errMsg, "sudah dibayarkan"):
+		return http.StatusConflict
+	case strings.Contains(errMsg, "format"),
+		strings.Contains(errMsg, "tidak boleh"),
+		strings.Contains(errMsg, "harus"),
+		strings.Contains(errMsg, "belum diatur"),
+		strings.Contains(errMsg, "tidak ada kelas"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *PayrollHandler) CreatePayRate(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req PayRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "CreatePayRate - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create pay rate", "success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	created, err := h.uc.CreatePayRate(c.Request.Context(), req.toDomain(0))
+	if err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "CreatePayRate - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to create pay rate", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "CreatePayRate", nil)
+	c.JSON(http.StatusCreated, gin.H{"message": "Pay rate created successfully", "success": true, "data": created})
+}
+
+func (h *PayrollHandler) GetAllPayRates(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	rates, err := h.uc.GetAllPayRates(c.Request.Context())
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetAllPayRates - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetAllPayRates", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": rates})
+}
+
+func (h *PayrollHandler) UpdatePayRate(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "UpdatePayRate - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update pay rate", "success": false, "error": "Invalid pay rate ID"})
+		return
+	}
+
+	var req PayRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "UpdatePayRate - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update pay rate", "success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	if err := h.uc.UpdatePayRate(c.Request.Context(), req.toDomain(id)); err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "UpdatePayRate - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to update pay rate", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "UpdatePayRate", nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Pay rate updated successfully", "success": true})
+}
+
+func (h *PayrollHandler) DeletePayRate(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "DeletePayRate - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to delete pay rate", "success": false, "error": "Invalid pay rate ID"})
+		return
+	}
+
+	if err := h.uc.DeletePayRate(c.Request.Context(), id); err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "DeletePayRate - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to delete pay rate", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "DeletePayRate", nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Pay rate deleted successfully", "success": true})
+}
+
+func (h *PayrollHandler) PreviewPayroll(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.PayrollPeriodRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "PreviewPayroll - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	preview, err := h.uc.PreviewPayroll(c.Request.Context(), req)
+	if err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "PreviewPayroll - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to calculate payroll"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "PreviewPayroll", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": preview})
+}
+
+func (h *PayrollHandler) RunPayroll(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		utils.PrintLogInfo(&name, 401, "RunPayroll", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: missing user context",
+			"message": "Failed to run payroll",
+		})
+		return
+	}
+
+	var req domain.PayrollPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "RunPayroll - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to run payroll", "success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	run, err := h.uc.RunPayroll(c.Request.Context(), userUUID.(string), req)
+	if err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "RunPayroll - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to run payroll", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "RunPayroll", nil)
+	c.JSON(http.StatusCreated, gin.H{"message": "Payroll run created and locked", "success": true, "data": run})
+}
+
+func (h *PayrollHandler) GetAllPayrollRuns(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	runs, err := h.uc.GetAllPayrollRuns(c.Request.Context())
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetAllPayrollRuns - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetAllPayrollRuns", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": runs})
+}
+
+func (h *PayrollHandler) GetPayrollRunByID(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetPayrollRunByID - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payroll run ID"})
+		return
+	}
+
+	run, err := h.uc.GetPayrollRunByID(c.Request.Context(), id)
+	if err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "GetPayrollRunByID - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetPayrollRunByID", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": run})
+}
+
+func (h *PayrollHandler) ExportPayrollRun(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "ExportPayrollRun - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payroll run ID"})
+		return
+	}
+
+	run, err := h.uc.GetPayrollRunByID(c.Request.Context(), id)
+	if err != nil {
+		status := payrollErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "ExportPayrollRun - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("payroll_%s_%s.csv", run.PeriodStart.Format("20060102"), run.PeriodEnd.Format("20060102"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"payslip_id", "teacher", "class_date", "student", "instrument", "duration", "payout_type", "amount"})
+	for _, slip := range run.Payslips {
+		for _, item := range slip.Items {
+			_ = w.Write([]string{
+				strconv.Itoa(slip.ID),
+				slip.Teacher.Name,
+				item.ClassDate.Format("2006-01-02 15:04"),
+				item.StudentName,
+				item.InstrumentName,
+				strconv.Itoa(item.Duration),
+				item.PayoutType,
+				strconv.FormatFloat(item.Amount, 'f', 2, 64),
+			})
+		}
+		_ = w.Write([]string{strconv.Itoa(slip.ID), slip.Teacher.Name, "", "", "", "", "TOTAL", strconv.FormatFloat(slip.TotalAmount, 'f', 2, 64)})
+	}
+	w.Flush()
+
+	utils.PrintLogInfo(&name, 200, "ExportPayrollRun", nil)
+}
+
+func (h *PayrollHandler) GetMyPayslips(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		utils.PrintLogInfo(&name, 401, "GetMyPayslips", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: missing user context",
+			"message": "Failed to get payslips",
+		})
+		return
+	}
+
+	payslips, err := h.uc.GetMyPayslips(c.Request.Context(), userUUID.(string))
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetMyPayslips - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to get payslips"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetMyPayslips", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": payslips})
+}
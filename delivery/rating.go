@@ -0,0 +1,208 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/dto"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RatingHandler struct {
+	uc domain.RatingUseCase
+}
+
+func NewRatingHandler(app *gin.Engine, uc domain.RatingUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &RatingHandler{uc: uc}
+
+	student := app.Group("/student")
+	student.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionStudentPortal))
+	{
+		student.POST("/class-history/:id/rating", h.SubmitRating)
+		student.GET("/ratings", h.GetMyRatings)
+	}
+
+	teacher := app.Group("/teacher")
+	teacher.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionTeacherPortal), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		teacher.GET("/ratings", h.GetMyRatingSummary)
+	}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionRatingsRead))
+	{
+		admin.GET("/ratings/teachers", h.GetTeacherRatingAverages)
+		admin.GET("/ratings/teachers/:uuid/trend", h.GetTeacherRatingTrend)
+	}
+}
+
+func (h *RatingHandler) SubmitRating(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		utils.PrintLogInfo(&name, 401, "SubmitRating", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: missing user context",
+			"message": "Failed to submit rating",
+		})
+		return
+	}
+
+	historyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || historyID <= 0 {
+		utils.PrintLogInfo(&name, 400, "SubmitRating - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid class history ID",
+			"message": "Failed to submit rating",
+		})
+		return
+	}
+
+	var req dto.SubmitRatingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "SubmitRating - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   utils.TranslateValidationError(err),
+			"message": "Failed to submit rating",
+		})
+		return
+	}
+
+	rating, err := h.uc.SubmitRating(c.Request.Context(), userUUID.(string), historyID, req.Rating, req.Comment)
+	if err != nil {
+		status := http.StatusInternalServerError
+		errorMsg := err.Error()
+		if strings.Contains(errorMsg, "tidak memiliki akses") {
+			status = http.StatusForbidden
+		} else if strings.Contains(errorMsg, "tidak ditemukan") {
+			status = http.StatusNotFound
+		} else if strings.Contains(errorMsg, "sudah dinilai") ||
+			strings.Contains(errorMsg, "sudah lewat") ||
+			strings.Contains(errorMsg, "sudah selesai") ||
+			strings.Contains(errorMsg, "nilai harus") {
+			status = http.StatusBadRequest
+		}
+
+		utils.PrintLogInfo(&name, status, "SubmitRating - UseCase", &err)
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   errorMsg,
+			"message": "Failed to submit rating",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "SubmitRating", nil)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    rating,
+		"message": "Rating submitted successfully",
+	})
+}
+
+func (h *RatingHandler) GetMyRatings(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		utils.PrintLogInfo(&name, 401, "GetMyRatings", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: missing user context",
+			"message": "Failed to get ratings",
+		})
+		return
+	}
+
+	ratings, err := h.uc.GetMyRatings(c.Request.Context(), userUUID.(string))
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetMyRatings - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get ratings",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetMyRatings", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": ratings})
+}
+
+func (h *RatingHandler) GetMyRatingSummary(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	userUUID, exists := c.Get("userUUID")
+	if !exists {
+		utils.PrintLogInfo(&name, 401, "GetMyRatingSummary", nil)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: missing user context",
+			"message": "Failed to get rating summary",
+		})
+		return
+	}
+
+	summary, err := h.uc.GetMyRatingSummary(c.Request.Context(), userUUID.(string))
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetMyRatingSummary - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get rating summary",
+		})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetMyRatingSummary", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": summary})
+}
+
+func (h *RatingHandler) GetTeacherRatingAverages(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.RatingFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetTeacherRatingAverages - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	averages, err := h.uc.GetTeacherRatingAverages(c.Request.Context(), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetTeacherRatingAverages - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve teacher ratings"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetTeacherRatingAverages", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": averages})
+}
+
+func (h *RatingHandler) GetTeacherRatingTrend(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	teacherUUID := c.Param("uuid")
+
+	var filter domain.RatingFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetTeacherRatingTrend - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	trend, err := h.uc.GetTeacherRatingTrend(c.Request.Context(), teacherUUID, filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetTeacherRatingTrend - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve rating trend"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetTeacherRatingTrend", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": trend})
+}
@@ -0,0 +1,94 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReceiptHandler struct {
+	uc domain.ReceiptUseCase
+}
+
+func NewReceiptHandler(app *gin.Engine, uc domain.ReceiptUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &ReceiptHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionPaymentsRead))
+	{
+		admin.GET("/payments/:id/receipt", h.DownloadReceipt)
+	}
+
+	student := app.Group("/student")
+	student.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionStudentPortal), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		student.GET("/payments/:id/receipt", h.DownloadMyReceipt)
+	}
+}
+
+func receiptErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "tidak memiliki akses"):
+		return http.StatusForbidden
+	case strings.Contains(errMsg, "hanya tersedia"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func writeReceipt(c *gin.Context, doc *domain.ReceiptDocument) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.FileName))
+	c.Data(http.StatusOK, domain.ReceiptContentType, doc.Content)
+}
+
+func (h *ReceiptHandler) DownloadReceipt(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "DownloadReceipt - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	doc, err := h.uc.GetReceiptPDF(c.Request.Context(), id)
+	if err != nil {
+		status := receiptErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "DownloadReceipt - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "DownloadReceipt", nil)
+	writeReceipt(c, doc)
+}
+
+func (h *ReceiptHandler) DownloadMyReceipt(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "DownloadMyReceipt - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	doc, err := h.uc.GetStudentReceiptPDF(c.Request.Context(), c.GetString("userUUID"), id)
+	if err != nil {
+		status := receiptErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "DownloadMyReceipt - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "DownloadMyReceipt", nil)
+	writeReceipt(c, doc)
+}
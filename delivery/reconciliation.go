@@ -0,0 +1,137 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	uc domain.ReconciliationUseCase
+}
+
+func NewReconciliationHandler(app *gin.Engine, uc domain.ReconciliationUseCase, jwtManager *utils.JWTManager) {
+	h := &ReconciliationHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager))
+	{
+		admin.POST("/payments/reconcile", middleware.RequirePermission(domain.PermissionPaymentsWrite), h.ReconcileNow)
+		admin.GET("/payments/reconciliations", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetReconciliationRuns)
+		admin.GET("/payments/reconciliations/:id", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetReconciliationRunByID)
+		admin.GET("/payments/discrepancies", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetDiscrepancies)
+	}
+}
+
+func (h *ReconciliationHandler) ReconcileNow(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var triggeredBy *string
+	if userUUID := c.GetString("userUUID"); userUUID != "" {
+		triggeredBy = &userUUID
+	}
+
+	run, err := h.uc.ReconcilePendingPayments(c.Request.Context(), domain.ReconcileTriggerManual, triggeredBy)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "ReconcileNow - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to reconcile payments"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "ReconcileNow", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": run, "message": "Reconciliation finished"})
+}
+
+func (h *ReconciliationHandler) GetReconciliationRuns(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	runs, total, err := h.uc.GetReconciliationRuns(c.Request.Context(), page, limit)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetReconciliationRuns - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve reconciliation runs"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetReconciliationRuns", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+		"meta": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (h *ReconciliationHandler) GetReconciliationRunByID(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetReconciliationRunByID - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid reconciliation ID"})
+		return
+	}
+
+	run, err := h.uc.GetReconciliationRunByID(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			status = http.StatusNotFound
+		}
+		utils.PrintLogInfo(&name, status, "GetReconciliationRunByID - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetReconciliationRunByID", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": run})
+}
+
+func (h *ReconciliationHandler) GetDiscrepancies(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.DiscrepancyFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetDiscrepancies - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	items, total, err := h.uc.GetDiscrepancies(c.Request.Context(), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetDiscrepancies - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve discrepancies"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetDiscrepancies", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+		"meta": gin.H{
+			"page":  filter.Page,
+			"limit": filter.Limit,
+			"total": total,
+		},
+	})
+}
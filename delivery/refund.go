@@ -0,0 +1,132 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	uc domain.RefundUseCase
+}
+
+func NewRefundHandler(app *gin.Engine, uc domain.RefundUseCase, jwtManager *utils.JWTManager) {
+	h := &RefundHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager))
+	{
+		admin.GET("/payments/:id/refund-quote", middleware.RequirePermission(domain.PermissionPaymentsRefund), h.QuoteRefund)
+		admin.POST("/payments/:id/refund", middleware.RequirePermission(domain.PermissionPaymentsRefund), h.RefundPayment)
+		admin.GET("/payments/:id/refunds", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetRefundsByPayment)
+	}
+}
+
+func refundErrorStatus(errMsg string) int {
+	switch {
+	case strings.HasPrefix(errMsg, "refund "):
+		// The gateway rejected the refund; nothing was changed locally.
+		return http.StatusBadGateway
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "tidak dapat direfund"),
+		strings.Contains(errMsg, "sudah direfund"),
+		strings.Contains(errMsg, "tidak valid"),
+		strings.Contains(errMsg, "melebihi"),
+		strings.Contains(errMsg, "tidak ada sisa"),
+		strings.Contains(errMsg, "gunakan refund"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// QuoteRefund shows what a refund would pay back and take away, without doing it.
+func (h *RefundHandler) QuoteRefund(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "QuoteRefund - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	req := domain.RefundRequest{Mode: c.DefaultQuery("mode", domain.RefundModeFull)}
+	if raw := c.Query("lessons"); raw != "" {
+		lessons, err := strconv.Atoi(raw)
+		if err != nil || lessons <= 0 {
+			utils.PrintLogInfo(&name, 400, "QuoteRefund - Lessons", &err)
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid lessons value"})
+			return
+		}
+		req.Lessons = &lessons
+	}
+
+	quote, err := h.uc.QuoteRefund(c.Request.Context(), id, req)
+	if err != nil {
+		status := refundErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "QuoteRefund - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "QuoteRefund", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": quote})
+}
+
+func (h *RefundHandler) RefundPayment(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "RefundPayment - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	var req domain.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "RefundPayment - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+	if req.Mode == domain.RefundModeFull && req.Lessons != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "lessons can only be set for a prorated refund"})
+		return
+	}
+
+	result, err := h.uc.RefundPayment(c.Request.Context(), id, c.GetString("userUUID"), req)
+	if err != nil {
+		status := refundErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "RefundPayment - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to refund payment"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "RefundPayment", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result, "message": "Payment refunded successfully"})
+}
+
+func (h *RefundHandler) GetRefundsByPayment(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetRefundsByPayment - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	refunds, err := h.uc.GetRefundsByPayment(c.Request.Context(), id)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetRefundsByPayment - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve refunds"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetRefundsByPayment", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": refunds})
+}
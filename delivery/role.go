@@ -0,0 +1,171 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RoleHandler struct {
+	uc domain.RoleUseCase
+}
+
+func NewRoleHandler(app *gin.Engine, uc domain.RoleUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &RoleHandler{uc: uc}
+
+	auth := app.Group("/auth")
+	auth.Use(config.AuthMiddleware(jwtManager), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		auth.GET("/permissions", h.GetMyPermissions)
+	}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionRolesManage))
+	{
+		admin.GET("/permissions", h.GetPermissionCatalog)
+		admin.GET("/roles", h.GetRoles)
+		admin.POST("/roles", h.CreateRole)
+		admin.PUT("/roles/modify/:name", h.UpdateRole)
+		admin.DELETE("/roles/:name", h.DeleteRole)
+		admin.PUT("/users/:uuid/role", h.AssignRole)
+	}
+}
+
+func roleErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "sudah ada"),
+		strings.Contains(msg, "sudah memiliki"),
+		strings.Contains(msg, "masih digunakan"),
+		strings.Contains(msg, "admin terakhir"):
+		return http.StatusConflict
+	case strings.Contains(msg, "hanya admin"),
+		strings.Contains(msg, "yang anda miliki"),
+		strings.Contains(msg, "tidak anda miliki"):
+		return http.StatusForbidden
+	case strings.Contains(msg, "tidak dapat"),
+		strings.Contains(msg, "tidak dikenal"),
+		strings.Contains(msg, "hanya untuk"),
+		strings.Contains(msg, "nama role"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *RoleHandler) GetMyPermissions(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	role := c.GetString("role")
+	permissions, err := h.uc.GetPermissions(c.Request.Context(), role)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetMyPermissions - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve permissions"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetMyPermissions", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"role": role, "permissions": permissions}})
+}
+
+func (h *RoleHandler) GetPermissionCatalog(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	utils.PrintLogInfo(&name, 200, "GetPermissionCatalog", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": domain.PermissionCatalog})
+}
+
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	roles, err := h.uc.GetRoles(c.Request.Context())
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetRoles - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve roles"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetRoles", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": roles})
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "CreateRole - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Failed to create role"})
+		return
+	}
+
+	role, err := h.uc.CreateRole(c.Request.Context(), c.GetString("role"), req)
+	if err != nil {
+		status := roleErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "CreateRole - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to create role"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "CreateRole", nil)
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": role, "message": "Role created successfully"})
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "UpdateRole - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Failed to update role"})
+		return
+	}
+
+	role, err := h.uc.UpdateRole(c.Request.Context(), c.GetString("role"), c.Param("name"), req)
+	if err != nil {
+		status := roleErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "UpdateRole - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to update role"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "UpdateRole", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": role, "message": "Role updated successfully"})
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	if err := h.uc.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		status := roleErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "DeleteRole - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to delete role"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "DeleteRole", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Role deleted successfully"})
+}
+
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "AssignRole - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Failed to assign role"})
+		return
+	}
+
+	err := h.uc.AssignRole(c.Request.Context(), c.GetString("userUUID"), c.GetString("role"), c.Param("uuid"), req.Role)
+	if err != nil {
+		status := roleErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "AssignRole - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to assign role"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "AssignRole", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Role assigned, the user's current sessions must sign in again"})
+}
@@ -0,0 +1,100 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SessionHandler struct {
+	authUC domain.AuthUseCase
+}
+
+func NewSessionHandler(app *gin.Engine, authUC domain.AuthUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &SessionHandler{authUC: authUC}
+
+	sessions := app.Group("/auth/sessions")
+	sessions.Use(config.AuthMiddleware(jwtManager), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		sessions.GET("", h.GetSessions)
+		sessions.DELETE("", h.RevokeOtherSessions)
+		sessions.DELETE("/:id", h.RevokeSession)
+	}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionUsersSecurity))
+	{
+		admin.POST("/users/:uuid/force-logout", h.ForceLogout)
+	}
+}
+
+func sessionErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "tidak dikenali"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	sessions, err := h.authUC.GetSessions(c.Request.Context(), c.GetString("userUUID"), c.GetString("sessionID"))
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetSessions - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve sessions"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetSessions", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sessions})
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	if err := h.authUC.RevokeSession(c.Request.Context(), c.GetString("userUUID"), c.Param("id")); err != nil {
+		status := sessionErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "RevokeSession - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to revoke session"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "RevokeSession", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Session revoked"})
+}
+
+// RevokeOtherSessions logs out every device except the one making the request.
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	revoked, err := h.authUC.RevokeOtherSessions(c.Request.Context(), c.GetString("userUUID"), c.GetString("sessionID"))
+	if err != nil {
+		status := sessionErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "RevokeOtherSessions - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to revoke sessions"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "RevokeOtherSessions", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"revoked": revoked}, "message": "Other sessions revoked"})
+}
+
+func (h *SessionHandler) ForceLogout(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	revoked, err := h.authUC.ForceLogout(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		status := sessionErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "ForceLogout - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to force logout"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "ForceLogout", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"revoked": revoked}, "message": "User logged out from all sessions"})
+}
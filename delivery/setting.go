@@ -0,0 +1,66 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingHandler struct {
+	uc domain.SettingUseCase
+}
+
+func NewSettingHandler(app *gin.Engine, uc domain.SettingUseCase, jwtManager *utils.JWTManager) {
+	h := &SettingHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionSettingsManage))
+	{
+		admin.GET("/settings", h.GetSettings)
+		admin.PUT("/settings/:key", h.UpdateSetting)
+	}
+}
+
+func (h *SettingHandler) GetSettings(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	settings, err := h.uc.GetSettings(c.Request.Context())
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetSettings - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve settings"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetSettings", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": settings})
+}
+
+func (h *SettingHandler) UpdateSetting(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.UpdateSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "UpdateSetting - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Failed to update setting"})
+		return
+	}
+
+	setting, err := h.uc.UpdateSetting(c.Request.Context(), c.Param("key"), req.Value, c.GetString("userUUID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "nilai harus") {
+			status = http.StatusBadRequest
+		}
+		utils.PrintLogInfo(&name, status, "UpdateSetting - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to update setting"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "UpdateSetting", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setting, "message": "Setting updated successfully"})
+}
@@ -0,0 +1,123 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type StudentPaymentHandler struct {
+	uc domain.PaymentUseCase
+}
+
+// NewStudentPaymentHandler registers the student's own payment history. Receipts of paid
+// payments are served by the receipt handler at /student/payments/:id/receipt.
+func NewStudentPaymentHandler(app *gin.Engine, uc domain.PaymentUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &StudentPaymentHandler{uc: uc}
+
+	student := app.Group("/student")
+	student.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionStudentPortal), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		student.GET("/payments", h.GetMyPayments)
+		student.GET("/payments/:id", h.GetMyPayment)
+		student.POST("/payments/:id/cancel", h.CancelMyPayment)
+	}
+}
+
+func studentPaymentErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "tidak memiliki akses"):
+		return http.StatusForbidden
+	case strings.Contains(errMsg, "dapat dibatalkan"):
+		return http.StatusConflict
+	case strings.Contains(errMsg, "gagal membatalkan invoice"):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *StudentPaymentHandler) GetMyPayments(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.StudentPaymentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetMyPayments - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	payments, total, err := h.uc.GetMyPayments(c.Request.Context(), c.GetString("userUUID"), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetMyPayments - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve payments"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetMyPayments", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    payments,
+		"meta": gin.H{
+			"page":  filter.Page,
+			"limit": filter.Limit,
+			"total": total,
+		},
+	})
+}
+
+func (h *StudentPaymentHandler) GetMyPayment(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetMyPayment - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	payment, err := h.uc.GetMyPayment(c.Request.Context(), c.GetString("userUUID"), id)
+	if err != nil {
+		status := studentPaymentErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "GetMyPayment - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve payment"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetMyPayment", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": payment})
+}
+
+func (h *StudentPaymentHandler) CancelMyPayment(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "CancelMyPayment - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid payment ID"})
+		return
+	}
+
+	payment, err := h.uc.CancelMyPayment(c.Request.Context(), c.GetString("userUUID"), id)
+	if err != nil {
+		status := studentPaymentErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "CancelMyPayment - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to cancel payment"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "CancelMyPayment", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": payment, "message": "Invoice cancelled"})
+}
@@ -0,0 +1,208 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TwoFactorHandler struct {
+	authUC      domain.AuthUseCase
+	twoFactorUC domain.TwoFactorUseCase
+}
+
+func NewTwoFactorHandler(app *gin.Engine, authUC domain.AuthUseCase, twoFactorUC domain.TwoFactorUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &TwoFactorHandler{authUC: authUC, twoFactorUC: twoFactorUC}
+
+	// Second login step, authenticated by the challenge token from /auth/login
+	login := app.Group("/auth/2fa")
+	{
+		login.POST("/verify", h.VerifyLogin)
+		login.POST("/setup/challenge", h.BeginChallengeSetup)
+	}
+
+	manage := app.Group("/auth/2fa")
+	manage.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionTwoFactorUse), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		manage.GET("", h.GetStatus)
+		manage.POST("/setup", h.BeginSetup)
+		manage.POST("/enable", h.ConfirmSetup)
+		manage.POST("/disable", h.Disable)
+		manage.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionUsersSecurity))
+	{
+		admin.DELETE("/users/:uuid/two-factor", h.ResetForUser)
+	}
+}
+
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrTwoFactorChallengeInvalid),
+		errors.Is(err, domain.ErrTwoFactorTooManyAttempts), errors.Is(err, domain.ErrUserNotFound),
+		strings.Contains(err.Error(), "password salah"):
+		return http.StatusUnauthorized
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "hanya tersedia"), strings.Contains(err.Error(), "wajib"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "sudah aktif"), strings.Contains(err.Error(), "belum"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *TwoFactorHandler) VerifyLogin(c *gin.Context) {
+	var req domain.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(nil, 400, "VerifyTwoFactorLogin - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	result, err := h.authUC.CompleteTwoFactorLogin(c.Request.Context(), req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		if respondAccountLocked(c, err) {
+			utils.PrintLogInfo(nil, 423, "VerifyTwoFactorLogin - UseCase", &err)
+			return
+		}
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(nil, status, "VerifyTwoFactorLogin - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Login failed"})
+		return
+	}
+
+	utils.PrintLogInfo(nil, 200, "VerifyTwoFactorLogin", nil)
+	respondWithLoginTokens(c, result)
+}
+
+func (h *TwoFactorHandler) BeginChallengeSetup(c *gin.Context) {
+	var req domain.TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(nil, 400, "BeginChallengeSetup - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	setup, err := h.twoFactorUC.BeginChallengeSetup(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(nil, status, "BeginChallengeSetup - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to start 2FA setup"})
+		return
+	}
+
+	utils.PrintLogInfo(nil, 200, "BeginChallengeSetup", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setup, "message": "Scan the QR code, then verify with a code from the app"})
+}
+
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	status, err := h.twoFactorUC.GetStatus(c.Request.Context(), c.GetString("userUUID"), c.GetString("role"))
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetTwoFactorStatus - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve 2FA status"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetTwoFactorStatus", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+func (h *TwoFactorHandler) BeginSetup(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	setup, err := h.twoFactorUC.BeginSetup(c.Request.Context(), c.GetString("userUUID"))
+	if err != nil {
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "BeginTwoFactorSetup - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to start 2FA setup"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "BeginTwoFactorSetup", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setup, "message": "Scan the QR code, then confirm with a code from the app"})
+}
+
+func (h *TwoFactorHandler) ConfirmSetup(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "ConfirmTwoFactorSetup - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	codes, err := h.twoFactorUC.ConfirmSetup(c.Request.Context(), c.GetString("userUUID"), req.Code)
+	if err != nil {
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "ConfirmTwoFactorSetup - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to enable 2FA"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "ConfirmTwoFactorSetup", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"recovery_codes": codes}, "message": "2FA enabled. Store the recovery codes somewhere safe, they are shown only once"})
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "DisableTwoFactor - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	if err := h.twoFactorUC.Disable(c.Request.Context(), c.GetString("userUUID"), c.GetString("role"), req.Password, req.Code); err != nil {
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "DisableTwoFactor - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to disable 2FA"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "DisableTwoFactor", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "2FA disabled"})
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "RegenerateRecoveryCodes - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err), "message": "Invalid request"})
+		return
+	}
+
+	codes, err := h.twoFactorUC.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("userUUID"), req.Code)
+	if err != nil {
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "RegenerateRecoveryCodes - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "RegenerateRecoveryCodes", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"recovery_codes": codes}, "message": "Recovery codes regenerated, the old ones no longer work"})
+}
+
+func (h *TwoFactorHandler) ResetForUser(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	if err := h.twoFactorUC.ResetForUser(c.Request.Context(), c.Param("uuid")); err != nil {
+		status := twoFactorErrorStatus(err)
+		utils.PrintLogInfo(&name, status, "ResetTwoFactor - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to reset 2FA"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "ResetTwoFactor", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "2FA reset for user"})
+}
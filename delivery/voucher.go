@@ -0,0 +1,276 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type VoucherHandler struct {
+	uc domain.VoucherUseCase
+}
+
+type VoucherRequest struct {
+	Code              string     `json:"code" binding:"required,max=50"`
+	Description       string     `json:"description"`
+	Type              string     `json:"type" binding:"required,oneof=percent fixed"`
+	Value             float64    `json:"value" binding:"required,gt=0"`
+	MaxDiscount       *float64   `json:"max_discount" binding:"omitempty,gt=0"`
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	MaxRedemptions    *int       `json:"max_redemptions" binding:"omitempty,gt=0"`
+	MaxPerUser        *int       `json:"max_per_user" binding:"omitempty,gt=0"`
+	FirstPurchaseOnly bool       `json:"first_purchase_only"`
+	IsActive          *bool      `json:"is_active"`
+	PackageIDs        []int      `json:"package_ids" binding:"omitempty,dive,gt=0"`
+}
+
+func (r *VoucherRequest) toDomain(id int) *domain.Voucher {
+	isActive := true
+	if r.IsActive != nil {
+		isActive = *r.IsActive
+	}
+	return &domain.Voucher{
+		ID:                id,
+		Code:              r.Code,
+		Description:       r.Description,
+		Type:              r.Type,
+		Value:             r.Value,
+		MaxDiscount:       r.MaxDiscount,
+		StartsAt:          r.StartsAt,
+		EndsAt:            r.EndsAt,
+		MaxRedemptions:    r.MaxRedemptions,
+		MaxPerUser:        r.MaxPerUser,
+		FirstPurchaseOnly: r.FirstPurchaseOnly,
+		IsActive:          isActive,
+	}
+}
+
+func NewVoucherHandler(app *gin.Engine, uc domain.VoucherUseCase, jwtManager *utils.JWTManager, db *gorm.DB) {
+	h := &VoucherHandler{uc: uc}
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager))
+	{
+		admin.POST("/vouchers", middleware.RequirePermission(domain.PermissionVouchersWrite), h.CreateVoucher)
+		admin.GET("/vouchers", middleware.RequirePermission(domain.PermissionVouchersRead), h.GetVouchers)
+		admin.GET("/vouchers/:id", middleware.RequirePermission(domain.PermissionVouchersRead), h.GetVoucherByID)
+		admin.PUT("/vouchers/modify/:id", middleware.RequirePermission(domain.PermissionVouchersWrite), h.UpdateVoucher)
+		admin.DELETE("/vouchers/:id", middleware.RequirePermission(domain.PermissionVouchersWrite), h.DeleteVoucher)
+		admin.GET("/vouchers/:id/redemptions", middleware.RequirePermission(domain.PermissionVouchersRead), h.GetVoucherRedemptions)
+	}
+
+	payment := app.Group("/api/v1/payment")
+	payment.Use(config.AuthMiddleware(jwtManager), middleware.RequirePermission(domain.PermissionStudentPortal), middleware.ValidateTurnedOffUserMiddleware(db))
+	{
+		payment.POST("/voucher/check", h.CheckVoucher)
+	}
+}
+
+func voucherErrorStatus(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "tidak ditemukan"):
+		return http.StatusNotFound
+	case strings.Contains(errMsg, "sudah ada"):
+		return http.StatusConflict
+	case strings.Contains(errMsg, "voucher"),
+		strings.Contains(errMsg, "harus"),
+		strings.Contains(errMsg, "tidak boleh"),
+		strings.Contains(errMsg, "minimum pembayaran"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *VoucherHandler) CreateVoucher(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req VoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "CreateVoucher - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to create voucher", "success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	created, err := h.uc.CreateVoucher(c.Request.Context(), req.toDomain(0), req.PackageIDs)
+	if err != nil {
+		status := voucherErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "CreateVoucher - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to create voucher", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 201, "CreateVoucher", nil)
+	c.JSON(http.StatusCreated, gin.H{"message": "Voucher created successfully", "success": true, "data": created})
+}
+
+func (h *VoucherHandler) GetVouchers(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.VoucherFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetVouchers - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	vouchers, total, err := h.uc.GetVouchers(c.Request.Context(), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetVouchers - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve vouchers"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetVouchers", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    vouchers,
+		"meta": gin.H{
+			"page":  filter.Page,
+			"limit": filter.Limit,
+			"total": total,
+		},
+	})
+}
+
+func (h *VoucherHandler) GetVoucherByID(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetVoucherByID - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid voucher ID"})
+		return
+	}
+
+	voucher, err := h.uc.GetVoucherByID(c.Request.Context(), id)
+	if err != nil {
+		status := voucherErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "GetVoucherByID - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetVoucherByID", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": voucher})
+}
+
+func (h *VoucherHandler) UpdateVoucher(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "UpdateVoucher - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update voucher", "success": false, "error": "Invalid voucher ID"})
+		return
+	}
+
+	var req VoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "UpdateVoucher - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to update voucher", "success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	updated, err := h.uc.UpdateVoucher(c.Request.Context(), req.toDomain(id), req.PackageIDs)
+	if err != nil {
+		status := voucherErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "UpdateVoucher - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to update voucher", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "UpdateVoucher", nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Voucher updated successfully", "success": true, "data": updated})
+}
+
+func (h *VoucherHandler) DeleteVoucher(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "DeleteVoucher - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to delete voucher", "success": false, "error": "Invalid voucher ID"})
+		return
+	}
+
+	if err := h.uc.DeleteVoucher(c.Request.Context(), id); err != nil {
+		status := voucherErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "DeleteVoucher - UseCase", &err)
+		c.JSON(status, gin.H{"message": "Failed to delete voucher", "success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "DeleteVoucher", nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Voucher deleted successfully", "success": true})
+}
+
+func (h *VoucherHandler) GetVoucherRedemptions(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetVoucherRedemptions - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid voucher ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	redemptions, total, err := h.uc.GetVoucherRedemptions(c.Request.Context(), id, page, limit)
+	if err != nil {
+		status := voucherErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "GetVoucherRedemptions - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve voucher redemptions"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetVoucherRedemptions", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    redemptions,
+		"meta": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// CheckVoucher lets a student see the discounted price before checking out.
+func (h *VoucherHandler) CheckVoucher(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var req domain.VoucherCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.PrintLogInfo(&name, 400, "CheckVoucher - BindJSON", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	breakdown, err := h.uc.CheckVoucher(c.Request.Context(), c.GetString("userUUID"), req)
+	if err != nil {
+		status := voucherErrorStatus(err.Error())
+		utils.PrintLogInfo(&name, status, "CheckVoucher - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "CheckVoucher", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": breakdown})
+}
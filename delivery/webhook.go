@@ -0,0 +1,158 @@
+package delivery
+
+import (
+	"chronosphere/config"
+	"chronosphere/domain"
+	"chronosphere/middleware"
+	"chronosphere/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	uc domain.WebhookUseCase
+}
+
+func NewWebhookHandler(app *gin.Engine, uc domain.WebhookUseCase, jwtManager *utils.JWTManager) {
+	h := &WebhookHandler{uc: uc}
+
+	// Public route for webhook (the payment gateway will call this)
+	app.POST("/api/v1/payment/callback", h.PaymentCallback)
+
+	admin := app.Group("/admin")
+	admin.Use(config.AuthMiddleware(jwtManager))
+	{
+		admin.GET("/webhooks", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetWebhookEvents)
+		admin.GET("/webhooks/:id", middleware.RequirePermission(domain.PermissionPaymentsRead), h.GetWebhookEventByID)
+		admin.POST("/webhooks/:id/reprocess", middleware.RequirePermission(domain.PermissionPaymentsWrite), h.ReprocessWebhookEvent)
+	}
+}
+
+// PaymentCallback godoc
+// @Summary Handle payment gateway callback
+// @Description Webhook receiver for payment updates. Every call is logged before processing.
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Router /payment/callback [post]
+func (h *WebhookHandler) PaymentCallback(c *gin.Context) {
+	name := "payment-webhook"
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, domain.MaxWebhookBodyBytes))
+	if err != nil {
+		utils.PrintLogInfo(&name, 400, "PaymentCallback - ReadBody", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read webhook body"})
+		return
+	}
+
+	event, err := h.uc.ReceivePaymentWebhook(c.Request.Context(), c.Request.Header, body, c.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "ditolak") {
+			status = http.StatusForbidden
+		} else if strings.Contains(errMsg, "tidak valid") {
+			status = http.StatusBadRequest
+		}
+
+		// 5xx makes the gateway retry; 4xx does not.
+		utils.PrintLogInfo(&name, status, "PaymentCallback - UseCase", &err)
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "PaymentCallback - "+event.Status, nil)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *WebhookHandler) GetWebhookEvents(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	var filter domain.WebhookFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.PrintLogInfo(&name, 400, "GetWebhookEvents - BindQuery", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": utils.TranslateValidationError(err)})
+		return
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	events, total, err := h.uc.GetWebhookEvents(c.Request.Context(), filter)
+	if err != nil {
+		utils.PrintLogInfo(&name, 500, "GetWebhookEvents - UseCase", &err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "message": "Failed to retrieve webhook events"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetWebhookEvents", nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    events,
+		"meta": gin.H{
+			"page":  filter.Page,
+			"limit": filter.Limit,
+			"total": total,
+		},
+	})
+}
+
+func (h *WebhookHandler) GetWebhookEventByID(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "GetWebhookEventByID - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid webhook event ID"})
+		return
+	}
+
+	event, err := h.uc.GetWebhookEventByID(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			status = http.StatusNotFound
+		}
+		utils.PrintLogInfo(&name, status, "GetWebhookEventByID - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "GetWebhookEventByID", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": event})
+}
+
+func (h *WebhookHandler) ReprocessWebhookEvent(c *gin.Context) {
+	name := utils.GetAPIHitter(c)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.PrintLogInfo(&name, 400, "ReprocessWebhookEvent - Atoi", &err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid webhook event ID"})
+		return
+	}
+
+	event, err := h.uc.ReprocessWebhookEvent(c.Request.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "tidak ditemukan") {
+			status = http.StatusNotFound
+		} else if strings.Contains(errMsg, "hanya webhook") || strings.Contains(errMsg, "tidak valid") {
+			status = http.StatusBadRequest
+		} else if strings.Contains(errMsg, "sudah diproses") {
+			status = http.StatusConflict
+		}
+		utils.PrintLogInfo(&name, status, "ReprocessWebhookEvent - UseCase", &err)
+		c.JSON(status, gin.H{"success": false, "error": errMsg, "data": event, "message": "Failed to reprocess webhook event"})
+		return
+	}
+
+	utils.PrintLogInfo(&name, 200, "ReprocessWebhookEvent", nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": event, "message": "Webhook event reprocessed successfully"})
+}
@@ -16,6 +16,7 @@ type AdminUseCase interface {
 	GetStudentByUUID(ctx context.Context, uuid string) (*User, error)
 	AssignPackageToStudent(ctx context.Context, studentUUID string, packageID int) error
 	GetAllStudents(ctx context.Context) ([]User, error)
+	CreateStudent(ctx context.Context, user *User) (*User, error)
 
 	// Manager Management
 	CreateManager(ctx context.Context, user *User) (*User, error)
@@ -29,6 +30,7 @@ type AdminUseCase interface {
 	CreatePackage(ctx context.Context, pkg *Package) (*Package, error)
 	UpdatePackage(ctx context.Context, pkg *Package) error
 	DeletePackage(ctx context.Context, id int) error
+	GetPackageVersions(ctx context.Context, packageID int) ([]PackageVersion, error)
 	// Instrument Management
 	GetAllInstruments(ctx context.Context) ([]Instrument, error)
 	CreateInstrument(ctx context.Context, instrument *Instrument) (*Instrument, error)
@@ -47,6 +49,7 @@ type AdminUseCase interface {
 	GetTotalProfit(ctx context.Context, filter ProfitFilter) (float64, error)
 	GetPaymentHistory(ctx context.Context, filter HistoryFilter) ([]Payment, int64, error)
 	GetPackageSummary(ctx context.Context) ([]PackageSummary, error)
+	GetRevenueByMethod(ctx context.Context, filter ProfitFilter) ([]RevenueByMethod, error)
 }
 
 type AdminRepository interface {
@@ -63,6 +66,7 @@ type AdminRepository interface {
 	GetStudentByUUID(ctx context.Context, uuid string) (*User, error)
 	AssignPackageToStudent(ctx context.Context, studentUUID string, packageID int) (*User, *Package, error)
 	GetAllStudents(ctx context.Context) ([]User, error)
+	CreateStudent(ctx context.Context, user *User) (*User, error)
 
 	// Manager Management
 	CreateManager(ctx context.Context, user *User) (*User, error)
@@ -76,6 +80,7 @@ type AdminRepository interface {
 	CreatePackage(ctx context.Context, pkg *Package) (*Package, error)
 	UpdatePackage(ctx context.Context, pkg *Package) error
 	DeletePackage(ctx context.Context, id int) error
+	GetPackageVersions(ctx context.Context, packageID int) ([]PackageVersion, error)
 	// Instrument Management
 	GetAllInstruments(ctx context.Context) ([]Instrument, error)
 	CreateInstrument(ctx context.Context, instrument *Instrument) (*Instrument, error)
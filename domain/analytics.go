@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AnalyticsIntervalDay   = "day"
+	AnalyticsIntervalWeek  = "week" // weeks start on Monday
+	AnalyticsIntervalMonth = "month"
+
+	// Buckets and date filters follow the studio's local calendar.
+	AnalyticsTimezone = "Asia/Makassar"
+
+	DefaultAnalyticsRangeDays = 30
+	MaxAnalyticsBuckets       = 400
+)
+
+type RevenueAnalyticsFilter struct {
+	StartDate string `form:"start_date"`                                        // YYYY-MM-DD, default 30 days before end_date
+	EndDate   string `form:"end_date"`                                          // YYYY-MM-DD inclusive, default today
+	Interval  string `form:"interval" binding:"omitempty,oneof=day week month"` // default day
+}
+
+// RevenueTotals are the raw aggregates of one period, as read from the database.
+type RevenueTotals struct {
+	Revenue   float64 `json:"revenue"`
+	Orders    int64   `json:"orders"`
+	Buyers    int64   `json:"buyers"`
+	NewBuyers int64   `json:"new_buyers"` // buyers whose first ever purchase is in the period
+}
+
+type RevenuePeriodSummary struct {
+	StartDate         string  `json:"start_date"`
+	EndDate           string  `json:"end_date"`
+	Revenue           float64 `json:"revenue"`
+	Orders            int64   `json:"orders"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	Buyers            int64   `json:"buyers"`
+	NewBuyers         int64   `json:"new_buyers"`
+	ReturningBuyers   int64   `json:"returning_buyers"`
+}
+
+// RevenueComparison holds percentage changes against the previous period; nil when the
+// previous value was zero.
+type RevenueComparison struct {
+	RevenueChangePct           *float64 `json:"revenue_change_pct"`
+	OrdersChangePct            *float64 `json:"orders_change_pct"`
+	AverageOrderValueChangePct *float64 `json:"average_order_value_change_pct"`
+	BuyersChangePct            *float64 `json:"buyers_change_pct"`
+}
+
+type RevenueBucket struct {
+	Period            time.Time `json:"period"`
+	Revenue           float64   `json:"revenue"`
+	Orders            int64     `json:"orders"`
+	AverageOrderValue float64   `json:"average_order_value"`
+	NewBuyers         int64     `json:"new_buyers"`
+	ReturningBuyers   int64     `json:"returning_buyers"`
+}
+
+type RevenueByPackage struct {
+	PackageID   int     `json:"package_id"`
+	PackageName string  `json:"package_name"`
+	Orders      int64   `json:"orders"`
+	Revenue     float64 `json:"revenue"`
+	SharePct    float64 `json:"share_pct"`
+}
+
+type RevenueByInstrument struct {
+	InstrumentID   int     `json:"instrument_id"`
+	InstrumentName string  `json:"instrument_name"`
+	Orders         int64   `json:"orders"`
+	Revenue        float64 `json:"revenue"`
+	SharePct       float64 `json:"share_pct"`
+}
+
+type RevenueByChannel struct {
+	Source         string  `json:"source"`
+	PaymentMethod  string  `json:"payment_method"`
+	PaymentChannel string  `json:"payment_channel"`
+	Orders         int64   `json:"orders"`
+	Revenue        float64 `json:"revenue"`
+	SharePct       float64 `json:"share_pct"`
+}
+
+type RevenueAnalytics struct {
+	Interval     string                `json:"interval"`
+	Current      RevenuePeriodSummary  `json:"current"`
+	Previous     RevenuePeriodSummary  `json:"previous"`
+	Comparison   RevenueComparison     `json:"comparison"`
+	Series       []RevenueBucket       `json:"series"`
+	ByPackage    []RevenueByPackage    `json:"by_package"`
+	ByInstrument []RevenueByInstrument `json:"by_instrument"`
+	ByChannel    []RevenueByChannel    `json:"by_channel"`
+}
+
+type AnalyticsUseCase interface {
+	GetRevenueAnalytics(ctx context.Context, filter RevenueAnalyticsFilter) (*RevenueAnalytics, error)
+}
+
+// AnalyticsRepository ranges are half-open: from <= paid_at < to.
+type AnalyticsRepository interface {
+	GetRevenueTotals(ctx context.Context, from, to time.Time) (*RevenueTotals, error)
+	GetRevenueSeries(ctx context.Context, from, to time.Time, interval string) ([]RevenueBucket, error)
+	GetRevenueByPackage(ctx context.Context, from, to time.Time) ([]RevenueByPackage, error)
+	GetRevenueByInstrument(ctx context.Context, from, to time.Time) ([]RevenueByInstrument, error)
+	GetRevenueByChannel(ctx context.Context, from, to time.Time) ([]RevenueByChannel, error)
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Audited actions, named <target>.<verb>.
+const (
+	AuditActionUserDeactivate     = "user.deactivate"
+	AuditActionUserReactivate     = "user.reactivate"
+	AuditActionUserForceLogout    = "user.force_logout"
+	AuditActionUserUnlock         = "user.unlock"
+	AuditActionUserTwoFactorReset = "user.two_factor_reset"
+	AuditActionUserRoleAssign     = "user.role_assign"
+	AuditActionTeacherCreate      = "teacher.create"
+	AuditActionTeacherUpdate      = "teacher.update"
+	AuditActionManagerCreate      = "manager.create"
+	AuditActionPackageCreate      = "package.create"
+	AuditActionPackageUpdate      = "package.update"
+	AuditActionPackageDelete      = "package.delete"
+	AuditActionPackageAssign      = "student_package.assign"
+	AuditActionQuotaModify        = "student_package.quota_modify"
+	AuditActionInstrumentCreate   = "instrument.create"
+	AuditActionInstrumentUpdate   = "instrument.update"
+	AuditActionInstrumentDelete   = "instrument.delete"
+	AuditActionRoleCreate         = "role.create"
+	AuditActionRoleUpdate         = "role.update"
+	AuditActionRoleDelete         = "role.delete"
+	AuditActionSettingUpdate      = "setting.update"
+	AuditActionPaymentOffline     = "payment.record_offline"
+	AuditActionPaymentRefund      = "payment.refund"
+	AuditActionVoucherCreate      = "voucher.create"
+	AuditActionVoucherUpdate      = "voucher.update"
+	AuditActionVoucherDelete      = "voucher.delete"
+	AuditActionPayRateCreate      = "pay_rate.create"
+	AuditActionPayRateUpdate      = "pay_rate.update"
+	AuditActionPayRateDelete      = "pay_rate.delete"
+	AuditActionPayrollRun         = "payroll_run.create"
+	AuditActionStudentCreate      = "student.create"
+	AuditActionAPIKeyCreate       = "api_key.create"
+	AuditActionAPIKeyRevoke       = "api_key.revoke"
+)
+
+const (
+	AuditTargetUser       = "user"
+	AuditTargetPackage    = "package"
+	AuditTargetInstrument = "instrument"
+	AuditTargetRole       = "role"
+	AuditTargetSetting    = "setting"
+	AuditTargetPayment    = "payment"
+	AuditTargetVoucher    = "voucher"
+	AuditTargetPayRate    = "pay_rate"
+	AuditTargetPayrollRun = "payroll_run"
+	AuditTargetAPIKey     = "api_key"
+)
+
+// AuditLog is one privileged action. Rows are append-only: the repository has no update or
+// delete, and a database trigger rejects them too.
+type AuditLog struct {
+	ID          int64           `gorm:"primaryKey" json:"id"`
+	ActorUUID   *string         `gorm:"type:uuid;index" json:"actor_uuid"`
+	ActorRole   string          `gorm:"size:50" json:"actor_role"`
+	ActorAPIKey *string         `gorm:"size:20;index" json:"actor_api_key,omitempty"`
+	Action      string          `gorm:"size:60;not null;index" json:"action"`
+	TargetType  string          `gorm:"size:40;not null;index:idx_audit_logs_target" json:"target_type"`
+	TargetID    string          `gorm:"size:64;index:idx_audit_logs_target" json:"target_id"`
+	Before      json.RawMessage `gorm:"type:jsonb" json:"before"`
+	After       json.RawMessage `gorm:"type:jsonb" json:"after"`
+	IPAddress   string          `gorm:"size:45" json:"ip_address"`
+	UserAgent   string          `gorm:"size:255" json:"user_agent"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+type AuditFilter struct {
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+	ActorUUID  string `form:"actor_uuid"`
+	APIKey     string `form:"api_key"` // key prefix
+	Action     string `form:"action"`
+	TargetType string `form:"target_type"`
+	TargetID   string `form:"target_id"`
+	StartDate  string `form:"start_date"` // YYYY-MM-DD
+	EndDate    string `form:"end_date"`   // YYYY-MM-DD
+}
+
+// AuditActorAPIKey is the actor role recorded for requests made with an API key.
+const AuditActorAPIKey = "api_key"
+
+// AuditActor is who performed a request. config.AuthMiddleware puts it in the request
+// context so services can record it without it being threaded through every call.
+type AuditActor struct {
+	UUID         string
+	Role         string
+	APIKeyPrefix string
+	Client       ClientInfo
+}
+
+type auditActorKey struct{}
+
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func AuditActorFrom(ctx context.Context) (AuditActor, bool) {
+	actor, ok := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor, ok
+}
+
+// AuditRecorder is what services use to log an action after it succeeded. before and after
+// are marshalled to JSON; nil leaves the column empty. Recording never fails the action.
+type AuditRecorder interface {
+	Record(ctx context.Context, action, targetType, targetID string, before, after interface{})
+}
+
+type AuditUseCase interface {
+	AuditRecorder
+	GetAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, int64, error)
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *AuditLog) error
+	GetAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, int64, error)
+}
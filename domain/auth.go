@@ -4,16 +4,30 @@ package domain
 import (
 	"chronosphere/utils"
 	"context"
+	"errors"
 )
 
+// ErrUserNotFound is returned when a token belongs to an account that no longer exists or
+// was deactivated.
+var ErrUserNotFound = errors.New("akun tidak ditemukan atau telah dinonaktifkan")
+
 type AuthUseCase interface {
 	ChangeEmail(ctx context.Context, userUUID, newEmail, password string) error
 	Me(ctx context.Context, userUUID string) (*User, error)
 	GetAccessTokenManager() *utils.JWTManager
-	GetRefreshTokenManager() *utils.JWTManager // 🔥 add this line
 	Register(ctx context.Context, email string, name string, telephone string, password string, gender string) error
 	VerifyOTP(ctx context.Context, email, otp string) error
-	Login(ctx context.Context, email, password string) (*AuthTokens, error)
+	Login(ctx context.Context, email, password string, client ClientInfo) (*LoginResult, error)
+	CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client ClientInfo) (*LoginResult, error)
+	StartOIDCLogin(ctx context.Context) (*OIDCAuthorization, error)
+	CompleteOIDCLogin(ctx context.Context, state, code string, client ClientInfo) (*LoginResult, error)
+	CompleteOIDCSignup(ctx context.Context, req OIDCSignupRequest, client ClientInfo) (*LoginResult, error)
+	RefreshTokens(ctx context.Context, refreshToken string, client ClientInfo) (*AuthTokens, error)
+	Logout(ctx context.Context, refreshToken string) error
+	GetSessions(ctx context.Context, userUUID, currentSessionID string) ([]RefreshTokenFamily, error)
+	RevokeSession(ctx context.Context, userUUID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userUUID, currentSessionID string) (int, error)
+	ForceLogout(ctx context.Context, userUUID string) (int, error)
 	ForgotPassword(ctx context.Context, email string) error
 	ResetPassword(ctx context.Context, email, otp, newPassword string) error
 	ChangePassword(ctx context.Context, userUUID, oldPassword, newPassword string) error
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+const (
+	ExportReportPayments       = "payments"
+	ExportReportClassHistories = "class-histories"
+	ExportReportStudents       = "students"
+	ExportReportTeachers       = "teachers"
+
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+
+	ExportJobQueued    = "queued"
+	ExportJobRunning   = "running"
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+
+	// Exports with more rows than this run as a background job instead of streaming,
+	// so they are not cut off by the request timeout.
+	DefaultExportSyncMaxRows = 5000
+	DefaultExportRetention   = 24 * time.Hour
+	ExportBatchSize          = 500
+	ExportJobTimeout         = 30 * time.Minute
+	ExportPurgeInterval      = time.Hour
+	MaxConcurrentExportJobs  = 2
+)
+
+// ExportFilter carries the filters of the matching JSON endpoint. Dates are YYYY-MM-DD:
+// payments filter on created_at, class histories on the class date, users on sign-up.
+type ExportFilter struct {
+	Format    string `form:"format,default=csv" json:"format" binding:"omitempty,oneof=csv xlsx"`
+	StartDate string `form:"start_date" json:"start_date,omitempty"`
+	EndDate   string `form:"end_date" json:"end_date,omitempty"`
+	Status    string `form:"status" json:"status,omitempty"` // payment status, class status, or active/deleted for users
+	Source    string `form:"source" json:"source,omitempty"` // payments only: gateway, offline
+	// Async forces a background job even for small exports.
+	Async bool `form:"async" json:"-"`
+}
+
+// ExportJob is a background export whose file is kept in file storage until ExpiresAt.
+type ExportJob struct {
+	ID          int        `gorm:"primaryKey" json:"id"`
+	RequestedBy string     `gorm:"type:uuid;not null;index" json:"requested_by"`
+	Report      string     `gorm:"size:30;not null" json:"report"`
+	Format      string     `gorm:"size:10;not null" json:"format"`
+	Filter      string     `gorm:"type:text;not null" json:"filter"` // ExportFilter as JSON
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	RowCount    int64      `gorm:"not null;default:0" json:"row_count"`
+	FileKey     *string    `gorm:"size:255" json:"-"`
+	FileName    string     `gorm:"size:255" json:"file_name"`
+	Error       *string    `gorm:"type:text" json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"`
+}
+
+// ExportFile describes a download: what the client should save it as.
+type ExportFile struct {
+	FileName    string
+	ContentType string
+}
+
+type ExportUseCase interface {
+	// PrepareExport decides how a report is delivered. Small reports return the file to
+	// stream with WriteExport; large ones (or filter.Async) are queued and return the job.
+	PrepareExport(ctx context.Context, adminUUID, report string, filter ExportFilter) (*ExportFile, *ExportJob, error)
+	WriteExport(ctx context.Context, report string, filter ExportFilter, w io.Writer) (int64, error)
+	GetJob(ctx context.Context, id int) (*ExportJob, error)
+	GetJobs(ctx context.Context, adminUUID string) ([]ExportJob, error)
+	OpenJobFile(ctx context.Context, id int) (*ExportJob, *ExportFile, io.ReadCloser, error)
+	FailInterruptedJobs(ctx context.Context) error
+	PurgeExpiredFiles(ctx context.Context) error
+}
+
+type ExportRepository interface {
+	CountRows(ctx context.Context, report string, filter ExportFilter) (int64, error)
+	StreamPayments(ctx context.Context, filter ExportFilter, fn func(batch []Payment) error) error
+	StreamClassHistories(ctx context.Context, filter ExportFilter, fn func(batch []ClassHistory) error) error
+	StreamUsers(ctx context.Context, role string, filter ExportFilter, fn func(batch []User) error) error
+
+	CreateJob(ctx context.Context, job *ExportJob) error
+	UpdateJob(ctx context.Context, job *ExportJob) error
+	GetJob(ctx context.Context, id int) (*ExportJob, error)
+	GetJobsByRequester(ctx context.Context, requestedBy string, limit int) ([]ExportJob, error)
+	FailInterruptedJobs(ctx context.Context) (int64, error)
+	GetExpiredJobFiles(ctx context.Context, now time.Time) ([]ExportJob, error)
+}
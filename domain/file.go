@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"time"
+)
+
+const (
+	FilePurposeProfile       = "profile"
+	FilePurposeDocumentation = "documentation"
+	FilePurposePaymentProof  = "payment_proof"
+
+	DefaultMaxUploadMB    = 5
+	DefaultSignedURLTTL   = 15 * time.Minute
+	StorageDriverLocal    = "local"
+	FileRoutePrefix       = "/files/"
+	SignedURLExpiresParam = "expires"
+	SignedURLSigParam     = "sig"
+)
+
+// StoredFile is the metadata of an uploaded object; the bytes live in a FileStorage backend.
+type StoredFile struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	Key          string    `gorm:"size:255;not null;uniqueIndex" json:"key"`
+	OwnerUUID    string    `gorm:"type:uuid;not null;index" json:"owner_uuid"`
+	Purpose      string    `gorm:"size:20;not null" json:"purpose"`
+	ContentType  string    `gorm:"size:100;not null" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	OriginalName string    `gorm:"size:255" json:"original_name"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	URL string `gorm:"-" json:"url"`
+}
+
+// FileStorage is a pluggable object store. Keys are slash separated and never start with a slash.
+type FileStorage interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type FileUseCase interface {
+	Upload(ctx context.Context, ownerUUID, purpose string, header *multipart.FileHeader) (*StoredFile, error)
+	Open(ctx context.Context, key, viewerUUID, viewerRole string) (*StoredFile, io.ReadCloser, error)
+	OpenSigned(ctx context.Context, key, expires, sig string) (*StoredFile, io.ReadCloser, error)
+	SignURL(ctx context.Context, key, viewerUUID, viewerRole string) (string, time.Time, error)
+}
+
+type FileRepository interface {
+	Create(ctx context.Context, file *StoredFile) error
+	GetByKey(ctx context.Context, key string) (*StoredFile, error)
+	Delete(ctx context.Context, id int) error
+	UpdateUserImage(ctx context.Context, userUUID, url string) error
+	// IsDocumentationParticipant reports whether the user is the student or teacher of a
+	// class whose documentation links to the stored file with the given key.
+	IsDocumentationParticipant(ctx context.Context, key, userUUID string) (bool, error)
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Login history results.
+const (
+	LoginResultSuccess           = "SUCCESS"
+	LoginResultTwoFactorRequired = "TWO_FACTOR_REQUIRED"
+	LoginResultInvalidPassword   = "INVALID_PASSWORD"
+	LoginResultTwoFactorFailed   = "TWO_FACTOR_FAILED"
+	LoginResultUnknownEmail      = "UNKNOWN_EMAIL"
+	LoginResultLocked            = "LOCKED"
+)
+
+// LoginLockThreshold consecutive failed logins lock the account. Each lock lasts longer
+// than the previous one, following LoginLockDurations (the last entry repeats), until a
+// successful login or an admin unlock starts over.
+const LoginLockThreshold = 5
+
+var LoginLockDurations = []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour, 24 * time.Hour}
+
+// LoginHistory records every login attempt. UserUUID is nil when the email didn't match an
+// account.
+type LoginHistory struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	UserUUID  *string   `gorm:"type:uuid;index" json:"user_uuid,omitempty"`
+	Email     string    `gorm:"size:255;not null;index" json:"email"`
+	IPAddress string    `gorm:"size:45" json:"ip_address"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	Result    string    `gorm:"size:30;not null;index" json:"result"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// AccountLockout tracks consecutive failed logins. The row is deleted on a successful
+// login or an admin unlock.
+type AccountLockout struct {
+	UserUUID       string     `gorm:"primaryKey;type:uuid" json:"user_uuid"`
+	FailedAttempts int        `gorm:"not null;default:0" json:"failed_attempts"`
+	LockCount      int        `gorm:"not null;default:0" json:"lock_count"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	LastFailedAt   *time.Time `json:"last_failed_at,omitempty"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsLocked reports whether the lock is still running at now.
+func (l *AccountLockout) IsLocked(now time.Time) bool {
+	return l != nil && l.LockedUntil != nil && l.LockedUntil.After(now)
+}
+
+// AccountLockedError is returned by Login while the account is locked.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("akun dikunci sementara karena terlalu banyak percobaan login gagal, coba lagi dalam %d menit", int(math.Ceil(time.Until(e.Until).Minutes())))
+}
+
+type LoginHistoryFilter struct {
+	Page     int    `form:"page"`
+	Limit    int    `form:"limit"`
+	UserUUID string `form:"user_uuid"`
+	Email    string `form:"email"`
+	Result   string `form:"result"`
+}
+
+// KnownLogins summarises a user's earlier successful logins, to spot a new device or IP.
+type KnownLogins struct {
+	Total      int64
+	SameIP     int64
+	SameDevice int64
+}
+
+type LoginSecurityUseCase interface {
+	// Called by Login. CheckLock and RecordFailedLogin return *AccountLockedError while the
+	// account is (or just became) locked.
+	CheckLock(ctx context.Context, user *User, client ClientInfo) error
+	RecordFailedLogin(ctx context.Context, email string, user *User, result string, client ClientInfo) error
+	RecordSuccessfulLogin(ctx context.Context, user *User, result string, client ClientInfo)
+
+	Unlock(ctx context.Context, userUUID string) error
+	GetLoginHistory(ctx context.Context, filter LoginHistoryFilter) ([]LoginHistory, int64, error)
+}
+
+type LoginSecurityRepository interface {
+	CreateHistory(ctx context.Context, entry *LoginHistory) error
+	GetLoginHistory(ctx context.Context, filter LoginHistoryFilter) ([]LoginHistory, int64, error)
+	GetKnownLogins(ctx context.Context, userUUID, ipAddress, userAgent string) (*KnownLogins, error)
+
+	GetLockout(ctx context.Context, userUUID string) (*AccountLockout, error) // nil when none
+	// UpdateLockout applies update to the user's lockout row under a row lock, creating it
+	// first if needed, and returns the saved row.
+	UpdateLockout(ctx context.Context, userUUID string, update func(*AccountLockout)) (*AccountLockout, error)
+	DeleteLockout(ctx context.Context, userUUID string) error
+}
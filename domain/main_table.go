@@ -40,6 +40,9 @@ type User struct {
 	Password string  `gorm:"not null" json:"-"`
 	Role     string  `gorm:"not null" json:"role"`             // student | teacher | admin
 	Image    *string `gorm:"type:text" json:"image,omitempty"` // nullable, default NULL
+	// TokenVersion is bumped to revoke the user's access tokens. It is only written through
+	// TokenVersionRepository, never by Save, so a stale user record can't roll it back.
+	TokenVersion int `gorm:"not null;default:0;<-:create" json:"-"`
 
 	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
@@ -56,18 +59,20 @@ type StudentProfile struct {
 }
 
 type Package struct {
-	ID              int        `gorm:"primaryKey" json:"id"`
-	Name            string     `gorm:"not null" json:"name"`
-	Price           float64    `gorm:"not null" json:"price"`
-	Quota           int        `gorm:"not null" json:"quota"`
-	Duration        int        `gorm:"not null;default:30" json:"duration"` // Minutes: 30 or 60
-	ExpiredDuration int        `json:"expired_duration"`
-	Description     string     `json:"description"`
-	InstrumentID    int        `gorm:"not null" json:"instrument_id"`
-	Instrument      Instrument `gorm:"foreignKey:InstrumentID" json:"instrument"`
-	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt       *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+	ID               int        `gorm:"primaryKey" json:"id"`
+	Name             string     `gorm:"not null" json:"name"`
+	Price            float64    `gorm:"not null" json:"price"`
+	Quota            int        `gorm:"not null" json:"quota"`
+	Duration         int        `gorm:"not null;default:30" json:"duration"` // Minutes: 30 or 60
+	ExpiredDuration  int        `json:"expired_duration"`
+	Description      string     `json:"description"`
+	InstrumentID     int        `gorm:"not null" json:"instrument_id"`
+	Instrument       Instrument `gorm:"foreignKey:InstrumentID" json:"instrument"`
+	Version          int        `gorm:"not null;default:1" json:"version"`
+	CurrentVersionID *int       `json:"current_version_id,omitempty"` // PackageVersion sold right now
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        *time.Time `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 type StudentPackage struct {
@@ -77,8 +82,13 @@ type StudentPackage struct {
 	RemainingQuota int       `gorm:"not null" json:"remaining_quota"`
 	StartDate      time.Time `json:"start_date"`
 	EndDate        time.Time `json:"end_date"`
+	PaymentID      *int      `gorm:"index" json:"payment_id,omitempty"` // nil for packages granted by an admin or bought before refunds existed
+	// PackageVersionID is the terms the package was sold with; nil for packages bought
+	// before versioning existed.
+	PackageVersionID *int `gorm:"index" json:"package_version_id,omitempty"`
 
-	Package *Package `gorm:"foreignKey:PackageID" json:"package,omitempty"`
+	Package        *Package        `gorm:"foreignKey:PackageID" json:"package,omitempty"`
+	PackageVersion *PackageVersion `gorm:"foreignKey:PackageVersionID" json:"package_version,omitempty"`
 }
 
 type TeacherProfile struct {
@@ -12,6 +12,7 @@ type ManagerUseCase interface {
 type ManagerRepository interface {
 	GetAllStudents(ctx context.Context) ([]User, error)
 	GetStudentByUUID(ctx context.Context, uuid string) (*User, error)
-	ModifyStudentPackageQuota(ctx context.Context, studentUUID string, packageID int, incomingQuota int) (*User, error)
+	// ModifyStudentPackageQuota returns the student and the quota the package had before.
+	ModifyStudentPackageQuota(ctx context.Context, studentUUID string, packageID int, incomingQuota int) (*User, int, error)
 	UpdateManager(ctx context.Context, manager *User) error
 }
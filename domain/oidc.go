@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// OIDCLoginStateTTL is how long the user has to finish logging in at the provider.
+	OIDCLoginStateTTL = 10 * time.Minute
+	// OIDCSignupTTL is how long a first-time OIDC user has to complete their profile.
+	OIDCSignupTTL = 15 * time.Minute
+	// OIDCFakeIssuerPath is where the local mock issuer is served when OIDC_ISSUER=fake.
+	OIDCFakeIssuerPath = "/fake-oidc"
+)
+
+var (
+	ErrOIDCDisabled        = errors.New("login OIDC tidak diaktifkan")
+	ErrOIDCStateInvalid    = errors.New("sesi login OIDC tidak valid atau sudah kedaluwarsa, silakan login kembali")
+	ErrOIDCEmailUnverified = errors.New("email akun OIDC belum diverifikasi oleh penyedia login")
+	ErrOIDCSignupInvalid   = errors.New("pendaftaran OIDC tidak valid atau sudah kedaluwarsa, silakan login kembali")
+)
+
+// UserIdentity links a user to their account at an OIDC issuer. Once linked, logins match
+// on the issuer's subject, so a later email change at the provider doesn't matter.
+type UserIdentity struct {
+	Issuer      string     `gorm:"primaryKey;size:255" json:"issuer"`
+	Subject     string     `gorm:"primaryKey;size:255" json:"subject"`
+	UserUUID    string     `gorm:"type:uuid;not null;index" json:"user_uuid"`
+	Email       string     `gorm:"size:255" json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OIDCIdentity is the verified content of a provider's ID token.
+type OIDCIdentity struct {
+	Issuer        string `json:"issuer"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+}
+
+// OIDCAuthorization is where the client sends the user to log in. The client keeps State
+// and must check that the provider redirects back with the same value before calling the
+// callback, which ties the login to the browser that started it.
+type OIDCAuthorization struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// OIDCLoginState is kept server-side between the redirect and the callback; the PKCE
+// verifier never leaves the server.
+type OIDCLoginState struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// OIDCSignup is returned by the callback instead of tokens when no account matches the
+// provider's verified email. The student account is created once the client sends the
+// profile fields the provider doesn't have (phone and gender) with the token.
+type OIDCSignup struct {
+	Token     string       `json:"signup_token"`
+	Email     string       `json:"email"`
+	Name      string       `json:"name"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Identity  OIDCIdentity `json:"-"`
+}
+
+type OIDCCallbackRequest struct {
+	State string `json:"state" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+type OIDCSignupRequest struct {
+	SignupToken string `json:"signup_token" binding:"required"`
+	Name        string `json:"name" binding:"omitempty,min=3,max=50"` // defaults to the provider's name
+	Phone       string `json:"phone" binding:"required,numeric,min=9,max=14"`
+	Gender      string `json:"gender" binding:"required,oneof=male female"`
+}
+
+// OIDCProvider is an OpenID Connect issuer using the authorization code flow with PKCE.
+type OIDCProvider interface {
+	Issuer() string
+	AuthorizationURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the code and returns the ID token's claims once its signature,
+	// issuer, audience and expiry are verified. Checking the nonce is up to the caller.
+	Exchange(ctx context.Context, code, codeVerifier string) (*OIDCIdentity, error)
+}
+
+type OIDCRepository interface {
+	SaveLoginState(ctx context.Context, state string, data OIDCLoginState, ttl time.Duration) error
+	ConsumeLoginState(ctx context.Context, state string) (*OIDCLoginState, error) // nil when missing
+	SaveSignup(ctx context.Context, signup *OIDCSignup, ttl time.Duration) error
+	GetSignup(ctx context.Context, token string) (*OIDCSignup, error) // nil when missing
+	DeleteSignup(ctx context.Context, token string) error
+
+	GetIdentity(ctx context.Context, issuer, subject string) (*UserIdentity, error) // nil when missing
+	CreateIdentity(ctx context.Context, identity *UserIdentity) error
+	TouchIdentity(ctx context.Context, issuer, subject string, at time.Time) error
+	// FindUserByEmail also returns deactivated accounts, so their email isn't offered a
+	// fresh signup. nil when missing.
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	CreateStudentWithIdentity(ctx context.Context, user *User, identity *UserIdentity) error
+}
@@ -3,9 +3,35 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"time"
 )
 
+const (
+	// OTPMaxAttempts wrong codes invalidate an OTP; a new one has to be requested.
+	OTPMaxAttempts = 5
+	// OTPResendCooldown is the minimum time between two OTP emails to the same address.
+	OTPResendCooldown = time.Minute
+)
+
+var ErrOTPTooManyAttempts = errors.New("terlalu banyak percobaan OTP yang salah, silakan minta kode OTP baru")
+
+// OTPCooldownError is returned when a new OTP is requested for an email too soon after
+// the previous one.
+type OTPCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OTPCooldownError) Error() string {
+	return fmt.Sprintf("silakan tunggu %d detik sebelum meminta kode OTP baru", e.RetryAfterSeconds())
+}
+
+func (e *OTPCooldownError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
 type OTP struct {
 	ID        int       `gorm:"primaryKey"`
 	Email     string    `gorm:"uniqueIndex;not null"`
@@ -16,7 +42,12 @@ type OTP struct {
 
 type OTPRepository interface {
 	SaveOTP(ctx context.Context, email, otp, password, name, phone string, gender string, ttl time.Duration) error
+	// VerifyOTP returns the stored data when the code matches. Every wrong code counts as an
+	// attempt; after OTPMaxAttempts the OTP is deleted and ErrOTPTooManyAttempts returned.
 	VerifyOTP(ctx context.Context, email, otp string) (map[string]string, bool, error) // return password kalau valid
 	DeleteOTP(ctx context.Context, email string) error
 	GetOTP(ctx context.Context, email string) (map[string]string, error)
+	// AcquireResendSlot starts the email's resend cooldown, or returns an *OTPCooldownError
+	// if it is still running.
+	AcquireResendSlot(ctx context.Context, email string, cooldown time.Duration) error
 }
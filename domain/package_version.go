@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+// PackageVersion is an immutable snapshot of a package's price and terms. Every edit that
+// changes them adds a new version, and purchases point at the version that was sold, so
+// old payments and student packages keep showing what the student actually bought.
+type PackageVersion struct {
+	ID              int       `gorm:"primaryKey" json:"id"`
+	PackageID       int       `gorm:"not null;uniqueIndex:idx_package_versions_package_version,priority:1" json:"package_id"`
+	Version         int       `gorm:"not null;uniqueIndex:idx_package_versions_package_version,priority:2" json:"version"`
+	Name            string    `gorm:"not null" json:"name"`
+	Price           float64   `gorm:"not null" json:"price"`
+	Quota           int       `gorm:"not null" json:"quota"`
+	Duration        int       `gorm:"not null" json:"duration"` // Minutes: 30 or 60
+	ExpiredDuration int       `gorm:"not null" json:"expired_duration"`
+	Description     string    `json:"description"`
+	InstrumentID    int       `gorm:"not null" json:"instrument_id"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// NewPackageVersion snapshots the package's current terms as the given version.
+func NewPackageVersion(pkg *Package, version int) *PackageVersion {
+	return &PackageVersion{
+		PackageID:       pkg.ID,
+		Version:         version,
+		Name:            pkg.Name,
+		Price:           pkg.Price,
+		Quota:           pkg.Quota,
+		Duration:        pkg.Duration,
+		ExpiredDuration: pkg.ExpiredDuration,
+		Description:     pkg.Description,
+		InstrumentID:    pkg.InstrumentID,
+	}
+}
+
+// SameTerms reports whether the package still matches this version, i.e. an edit
+// changed nothing a buyer would see.
+func (v *PackageVersion) SameTerms(pkg *Package) bool {
+	return v.Name == pkg.Name &&
+		v.Price == pkg.Price &&
+		v.Quota == pkg.Quota &&
+		v.Duration == pkg.Duration &&
+		v.ExpiredDuration == pkg.ExpiredDuration &&
+		v.Description == pkg.Description &&
+		v.InstrumentID == pkg.InstrumentID
+}
+
+// applyTo overlays the snapshot on a package record.
+func (v *PackageVersion) applyTo(pkg *Package) {
+	pkg.Name = v.Name
+	pkg.Price = v.Price
+	pkg.Quota = v.Quota
+	pkg.Duration = v.Duration
+	pkg.ExpiredDuration = v.ExpiredDuration
+	pkg.Description = v.Description
+	pkg.InstrumentID = v.InstrumentID
+	pkg.Version = v.Version
+}
+
+// PurchasedPackage returns the package as this payment bought it: the package record with
+// its name, price and terms taken from the snapshot when PackageVersion is loaded. Payments
+// made before versioning existed fall back to the current terms.
+func (p *Payment) PurchasedPackage() Package {
+	pkg := p.Package
+	if p.PackageVersion != nil {
+		p.PackageVersion.applyTo(&pkg)
+	}
+	return pkg
+}
+
+// PurchasedPackage is the student package counterpart of Payment.PurchasedPackage. It
+// returns nil when neither the package nor its snapshot is loaded.
+func (sp *StudentPackage) PurchasedPackage() *Package {
+	if sp.Package == nil && sp.PackageVersion == nil {
+		return nil
+	}
+	var pkg Package
+	if sp.Package != nil {
+		pkg = *sp.Package
+	} else {
+		pkg.ID = sp.PackageID
+	}
+	if sp.PackageVersion != nil {
+		sp.PackageVersion.applyTo(&pkg)
+	}
+	return &pkg
+}
@@ -2,41 +2,110 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
-
-	"github.com/xendit/xendit-go/v6/invoice"
 )
 
+// ErrPaymentNotPending means the payment left PENDING (paid, cancelled, expired...) before
+// a status update got to it, so the update was not applied.
+var ErrPaymentNotPending = errors.New("pembayaran sudah tidak berstatus PENDING")
+
+// ErrCheckoutInProgress means the same checkout is still waiting on the payment gateway.
+var ErrCheckoutInProgress = errors.New("checkout sebelumnya masih diproses, silakan coba lagi sebentar")
+
 const (
 	PaymentStatusPending = "PENDING"
 	PaymentStatusPaid    = "PAID"
 	PaymentStatusExpired = "EXPIRED"
 	PaymentStatusFailed  = "FAILED"
+	// PaymentStatusCancelled is a pending invoice the student withdrew before paying.
+	PaymentStatusCancelled = "CANCELLED"
+
+	PaymentStatusRefunded          = "REFUNDED"
+	PaymentStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+
+	PaymentSourceGateway = "gateway"
+	PaymentSourceOffline = "offline"
+
+	// An open invoice is only reused if it stays payable for at least this long.
+	InvoiceReuseMinRemaining = 10 * time.Minute
+	// A PENDING payment without an invoice URL younger than this is a checkout still waiting
+	// on the gateway; older ones were abandoned half way.
+	CheckoutInFlightWindow  = 2 * time.Minute
+	MaxIdempotencyKeyLength = 100
 )
 
 type Payment struct {
-	ID              int        `gorm:"primaryKey" json:"id"`
-	ExternalID      string     `gorm:"unique;not null" json:"external_id"` // Xendit External ID
-	StudentUUID     string     `gorm:"type:uuid;not null" json:"student_uuid"`
-	Student         User       `gorm:"foreignKey:StudentUUID;references:UUID" json:"student"`
-	PackageID       int        `gorm:"not null" json:"package_id"`
-	Package         Package    `gorm:"foreignKey:PackageID" json:"package"`
-	Amount          float64    `gorm:"not null" json:"amount"`
-	Status          string     `gorm:"size:20;default:'PENDING'" json:"status"`
-	InvoiceURL      string     `gorm:"type:text" json:"invoice_url"`
-	XenditInvoiceID string     `gorm:"unique" json:"xendit_invoice_id,omitempty"`
-	PaidAt          *time.Time `json:"paid_at,omitempty"`
-	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	ID               int             `gorm:"primaryKey" json:"id"`
+	ExternalID       string          `gorm:"unique;not null" json:"external_id"` // gateway external ID
+	StudentUUID      string          `gorm:"type:uuid;not null;uniqueIndex:idx_payments_student_idempotency,priority:1" json:"student_uuid"`
+	Student          User            `gorm:"foreignKey:StudentUUID;references:UUID" json:"student"`
+	PackageID        int             `gorm:"not null" json:"package_id"`
+	Package          Package         `gorm:"foreignKey:PackageID" json:"package"`
+	PackageVersionID *int            `gorm:"index" json:"package_version_id,omitempty"` // terms bought; nil before versioning existed
+	PackageVersion   *PackageVersion `gorm:"foreignKey:PackageVersionID" json:"package_version,omitempty"`
+	OriginalAmount   float64         `gorm:"not null;default:0" json:"original_amount"` // package price before any voucher
+	DiscountAmount   float64         `gorm:"not null;default:0" json:"discount_amount"`
+	Amount           float64         `gorm:"not null" json:"amount"` // what the student is charged
+	VoucherID        *int            `gorm:"index" json:"voucher_id,omitempty"`
+	VoucherCode      *string         `gorm:"size:50" json:"voucher_code,omitempty"`
+	RefundedAmount   float64         `gorm:"not null;default:0" json:"refunded_amount"`
+	Status           string          `gorm:"size:20;default:'PENDING'" json:"status"`
+	InvoiceURL       string          `gorm:"type:text" json:"invoice_url"`
+	XenditInvoiceID  *string         `gorm:"unique" json:"xendit_invoice_id,omitempty"` // nil until the gateway issues one, and for offline payments
+	PaymentMethod    string          `gorm:"size:50" json:"payment_method,omitempty"`   // e.g. BANK_TRANSFER, EWALLET
+	PaymentChannel   string          `gorm:"size:50" json:"payment_channel,omitempty"`  // e.g. BCA, OVO
+	Source           string          `gorm:"size:20;not null;default:'gateway';index" json:"source"`
+	ReferenceNumber  *string         `gorm:"size:100" json:"reference_number,omitempty"` // transfer/EDC reference for offline payments
+	ProofFileKey     *string         `gorm:"size:255" json:"proof_file_key,omitempty"`
+	RecordedBy       *string         `gorm:"type:uuid" json:"recorded_by,omitempty"` // staff who recorded an offline payment
+	Notes            *string         `gorm:"type:text" json:"notes,omitempty"`
+	PaidAt           *time.Time      `json:"paid_at,omitempty"`
+	ExpiresAt        *time.Time      `json:"expires_at,omitempty"` // when the gateway invoice expires
+	IdempotencyKey   *string         `gorm:"size:100;uniqueIndex:idx_payments_student_idempotency,priority:2" json:"-"`
+	CreatedAt        time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 type CheckoutRequest struct {
-	PackageID int `json:"package_id" binding:"required"`
+	PackageID   int    `json:"package_id" binding:"required"`
+	VoucherCode string `json:"voucher_code"`
+	// IdempotencyKey comes from the Idempotency-Key header, not the body.
+	IdempotencyKey string `json:"-"`
 }
 
 type CheckoutResponse struct {
-	InvoiceURL string `json:"invoice_url"`
-	ExternalID string `json:"external_id"`
+	InvoiceURL     string     `json:"invoice_url"`
+	ExternalID     string     `json:"external_id"`
+	OriginalAmount float64    `json:"original_amount"`
+	DiscountAmount float64    `json:"discount_amount"`
+	Amount         float64    `json:"amount"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	// Reused is true when an existing unpaid invoice was returned instead of a new one.
+	Reused bool `json:"reused"`
+}
+
+// Methods staff can record for a payment taken outside the gateway.
+const (
+	OfflineMethodCash         = "CASH"
+	OfflineMethodBankTransfer = "BANK_TRANSFER"
+	OfflineMethodQRIS         = "QRIS"
+	OfflineMethodEDC          = "EDC"
+	OfflineMethodOther        = "OTHER"
+)
+
+// OfflinePaymentRequest records money a student paid at the studio. It binds from JSON or
+// from multipart form data when a proof image is attached.
+type OfflinePaymentRequest struct {
+	StudentUUID     string     `form:"student_uuid" json:"student_uuid" binding:"required,uuid"`
+	PackageID       int        `form:"package_id" json:"package_id" binding:"required"`
+	Method          string     `form:"method" json:"method" binding:"required,oneof=CASH BANK_TRANSFER QRIS EDC OTHER"`
+	ReferenceNumber string     `form:"reference_number" json:"reference_number" binding:"max=100"` // required for every method but CASH
+	Amount          float64    `form:"amount" json:"amount" binding:"required,gt=0"`
+	PaidAt          *time.Time `form:"paid_at" json:"paid_at" time_format:"2006-01-02T15:04:05Z07:00"` // defaults to now
+	Notes           string     `form:"notes" json:"notes" binding:"max=500"`
+	// ProofFileKey is set by the handler after the proof upload, never by the client.
+	ProofFileKey *string `form:"-" json:"-"`
 }
 
 type PaymentCallback struct {
@@ -63,27 +132,67 @@ type HistoryFilter struct {
 	Limit     int    `form:"limit,default=10"`
 	StartDate string `form:"start_date"`
 	EndDate   string `form:"end_date"`
-	Status    string `form:"status"` // PENDING, PAID, EXPIRED, FAILED
+	Status    string `form:"status"` // PENDING, PAID, EXPIRED, FAILED, CANCELLED, REFUNDED, PARTIALLY_REFUNDED
+	Source    string `form:"source"` // gateway, offline
+}
+
+// StudentPaymentFilter pages through a student's own payments, newest first.
+type StudentPaymentFilter struct {
+	Page   int    `form:"page,default=1"`
+	Limit  int    `form:"limit,default=10"`
+	Status string `form:"status"`
 }
 
 type PackageSummary struct {
-	PackageName  string  `json:"package_name"`
-	TotalSold    int     `json:"total_sold"`
-	TotalRevenue float64 `json:"total_revenue"`
+	PackageName    string  `json:"package_name"`
+	TotalSold      int     `json:"total_sold"`
+	TotalRevenue   float64 `json:"total_revenue"`
+	OfflineSold    int     `json:"offline_sold"`    // part of TotalSold recorded by staff
+	OfflineRevenue float64 `json:"offline_revenue"` // part of TotalRevenue recorded by staff
+}
+
+// RevenueByMethod is net revenue grouped by where and how the money came in.
+type RevenueByMethod struct {
+	Source        string  `json:"source"`
+	PaymentMethod string  `json:"payment_method"`
+	TotalCount    int     `json:"total_count"`
+	TotalRevenue  float64 `json:"total_revenue"`
 }
 
 type PaymentRepository interface {
 	Create(ctx context.Context, payment *Payment) error
+	// UpdateStatus moves a PENDING payment to another status; any other payment is left
+	// as is and ErrPaymentNotPending returned.
 	UpdateStatus(ctx context.Context, externalID string, status string, paidAt *time.Time) (*Payment, error)
 	FindByExternalID(ctx context.Context, externalID string) (*Payment, error)
 	GetTotalProfit(ctx context.Context, filter ProfitFilter) (float64, error)
 	GetPaymentHistory(ctx context.Context, filter HistoryFilter) ([]Payment, int64, error)
 	GetPackageSummary(ctx context.Context) ([]PackageSummary, error)
+	GetRevenueByMethod(ctx context.Context, filter ProfitFilter) ([]RevenueByMethod, error)
 	GetStudentBuyerDetailsAndPackage(ctx context.Context, studentUUID string, packageID int) (*User, *Package, error)
 	CheckStudentProfileExist(ctx context.Context, studentUUID string) (bool, error)
+	// GetStalePendingPayments skips payments waiting on an admin over an open discrepancy.
+	GetStalePendingPayments(ctx context.Context, createdBefore time.Time, limit int) ([]Payment, error)
+	FindByIdempotencyKey(ctx context.Context, studentUUID, key string) (*Payment, error)
+	// FindReusablePending also returns a matching checkout created after inFlightSince that
+	// has no invoice URL yet.
+	FindReusablePending(ctx context.Context, studentUUID string, packageID int, packageVersionID *int, voucherCode *string, validUntil, inFlightSince time.Time) (*Payment, error)
+	CountOpenInvoices(ctx context.Context, studentUUID string, now time.Time) (int64, error)
+	FindByID(ctx context.Context, id int) (*Payment, error)
+	GetPaymentsByStudent(ctx context.Context, studentUUID string, filter StudentPaymentFilter) ([]Payment, int64, error)
+	// CancelPending moves a PENDING payment to CANCELLED and releases its voucher use.
+	// It fails if the payment was settled or closed in the meantime.
+	CancelPending(ctx context.Context, id int) (*Payment, error)
 }
 
 type PaymentUseCase interface {
 	CreateInvoice(ctx context.Context, studentUUID string, req CheckoutRequest) (*CheckoutResponse, error)
-	HandleCallback(ctx context.Context, payload *invoice.Invoice) error
+	HandleCallback(ctx context.Context, payload *PaymentCallback) error
+	RecordOfflinePayment(ctx context.Context, staffUUID string, req OfflinePaymentRequest) (*Payment, error)
+
+	GetMyPayments(ctx context.Context, studentUUID string, filter StudentPaymentFilter) ([]Payment, int64, error)
+	// GetMyPayment returns one of the student's payments. A pending invoice is refreshed
+	// from the gateway first, so the InvoiceURL and status are current.
+	GetMyPayment(ctx context.Context, studentUUID string, id int) (*Payment, error)
+	CancelMyPayment(ctx context.Context, studentUUID string, id int) (*Payment, error)
 }
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	PaymentGatewayXendit = "xendit"
+	PaymentGatewayFake   = "fake"
+
+	// DefaultInvoiceDuration matches the Xendit default invoice lifetime.
+	DefaultInvoiceDuration = 24 * time.Hour
+)
+
+type GatewayInvoiceRequest struct {
+	ExternalID         string
+	Amount             float64
+	PayerEmail         string
+	Description        string
+	SuccessRedirectURL string
+	FailureRedirectURL string
+	Duration           time.Duration // zero means DefaultInvoiceDuration
+}
+
+// GatewayInvoice is a provider invoice with its status normalised to the PaymentStatus* values.
+// A status the provider reports that has no PaymentStatus* equivalent is kept unchanged.
+type GatewayInvoice struct {
+	ID             string     `json:"id"`
+	ExternalID     string     `json:"external_id"`
+	Status         string     `json:"status"`
+	Amount         float64    `json:"amount"`
+	InvoiceURL     string     `json:"invoice_url"`
+	PayerEmail     string     `json:"payer_email"`
+	Description    string     `json:"description"`
+	PaymentMethod  string     `json:"payment_method,omitempty"`
+	PaymentChannel string     `json:"payment_channel,omitempty"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+}
+
+type GatewayRefundRequest struct {
+	InvoiceID   string
+	ExternalID  string
+	ReferenceID string // our own refund reference, used as the provider idempotency key
+	Amount      float64
+	Reason      string
+}
+
+type GatewayRefund struct {
+	ID     string  `json:"id"`
+	Status string  `json:"status"`
+	Amount float64 `json:"amount"`
+}
+
+// PaymentGateway hides the payment provider from the payment service.
+type PaymentGateway interface {
+	Name() string
+	CreateInvoice(ctx context.Context, req GatewayInvoiceRequest) (*GatewayInvoice, error)
+	GetInvoice(ctx context.Context, invoiceID string) (*GatewayInvoice, error)
+	ExpireInvoice(ctx context.Context, invoiceID string) (*GatewayInvoice, error)
+	Refund(ctx context.Context, req GatewayRefundRequest) (*GatewayRefund, error)
+}
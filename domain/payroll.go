@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// StatusNoShow marks a class history where the student did not attend.
+	StatusNoShow = "no_show"
+
+	PayoutCompleted  = "completed"
+	PayoutNoShow     = "no_show"
+	PayoutLateCancel = "late_cancel"
+
+	PayrollStatusLocked = "locked"
+
+	// A student cancellation inside this window before the class counts as a late cancel.
+	LateCancelWindow = 24 * time.Hour
+)
+
+// TeacherPayRate is a pay rule. Nil scope fields act as wildcards; when several
+// rules match a lesson, the one with the most specific scope wins.
+type TeacherPayRate struct {
+	ID              int         `gorm:"primaryKey" json:"id"`
+	TeacherUUID     *string     `gorm:"type:uuid;index" json:"teacher_uuid,omitempty"`
+	Teacher         *User       `gorm:"foreignKey:TeacherUUID;references:UUID" json:"teacher,omitempty"`
+	InstrumentID    *int        `gorm:"index" json:"instrument_id,omitempty"`
+	Instrument      *Instrument `gorm:"foreignKey:InstrumentID" json:"instrument,omitempty"`
+	PackageDuration *int        `json:"package_duration,omitempty"` // 30 | 60
+	CompletedRate   float64     `gorm:"not null" json:"completed_rate"`
+	NoShowRate      float64     `gorm:"not null;default:0" json:"no_show_rate"`
+	LateCancelRate  float64     `gorm:"not null;default:0" json:"late_cancel_rate"`
+	CreatedAt       time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt       *time.Time  `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// Specificity returns how many scope fields the rule pins down.
+func (r *TeacherPayRate) Specificity() int {
+	score := 0
+	if r.TeacherUUID != nil {
+		score += 4 // a teacher-specific rule always beats a generic one
+	}
+	if r.InstrumentID != nil {
+		score += 2
+	}
+	if r.PackageDuration != nil {
+		score++
+	}
+	return score
+}
+
+// Matches reports whether the rule applies to a lesson with the given scope.
+func (r *TeacherPayRate) Matches(teacherUUID string, instrumentID int, duration int) bool {
+	if r.TeacherUUID != nil && *r.TeacherUUID != teacherUUID {
+		return false
+	}
+	if r.InstrumentID != nil && *r.InstrumentID != instrumentID {
+		return false
+	}
+	if r.PackageDuration != nil && *r.PackageDuration != duration {
+		return false
+	}
+	return true
+}
+
+// RateFor returns the amount paid for the given payout type.
+func (r *TeacherPayRate) RateFor(payoutType string) float64 {
+	switch payoutType {
+	case PayoutNoShow:
+		return r.NoShowRate
+	case PayoutLateCancel:
+		return r.LateCancelRate
+	default:
+		return r.CompletedRate
+	}
+}
+
+type PayrollRun struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	PeriodStart time.Time `gorm:"type:date;not null" json:"period_start"`
+	PeriodEnd   time.Time `gorm:"type:date;not null" json:"period_end"`
+	Status      string    `gorm:"size:20;not null;default:'locked'" json:"status"`
+	TotalAmount float64   `gorm:"not null" json:"total_amount"`
+	CreatedBy   string    `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	LockedAt    time.Time `gorm:"not null" json:"locked_at"`
+
+	Payslips []Payslip `gorm:"foreignKey:PayrollRunID;constraint:OnDelete:CASCADE;" json:"payslips,omitempty"`
+}
+
+type Payslip struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	PayrollRunID int       `gorm:"not null;index" json:"payroll_run_id"`
+	TeacherUUID  string    `gorm:"type:uuid;not null;index" json:"teacher_uuid"`
+	Teacher      User      `gorm:"foreignKey:TeacherUUID;references:UUID" json:"teacher"`
+	TotalLessons int       `gorm:"not null" json:"total_lessons"`
+	TotalAmount  float64   `gorm:"not null" json:"total_amount"`
+	Status       string    `gorm:"size:20;not null;default:'locked'" json:"status"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Items []PayslipItem `gorm:"foreignKey:PayslipID;constraint:OnDelete:CASCADE;" json:"items,omitempty"`
+}
+
+type PayslipItem struct {
+	ID             int       `gorm:"primaryKey" json:"id"`
+	PayslipID      int       `gorm:"not null;index" json:"payslip_id"`
+	ClassHistoryID int       `gorm:"not null;uniqueIndex" json:"class_history_id"` // a lesson can only ever be paid once
+	BookingID      int       `gorm:"not null" json:"booking_id"`
+	ClassDate      time.Time `gorm:"not null" json:"class_date"`
+	StudentName    string    `gorm:"not null" json:"student_name"`
+	InstrumentName string    `gorm:"not null" json:"instrument_name"`
+	Duration       int       `gorm:"not null" json:"duration"`
+	PayoutType     string    `gorm:"size:20;not null" json:"payout_type"`
+	PayRateID      int       `gorm:"not null" json:"pay_rate_id"`
+	Amount         float64   `gorm:"not null" json:"amount"`
+}
+
+// PayableLesson is a finished or cancelled class that has not been paid out yet.
+type PayableLesson struct {
+	ClassHistoryID int
+	BookingID      int
+	HistoryStatus  string
+	TeacherUUID    string
+	TeacherName    string
+	StudentUUID    string
+	StudentName    string
+	InstrumentID   int
+	InstrumentName string
+	Duration       int
+	ClassDate      time.Time
+	CanceledBy     *string
+	CancelledAt    *time.Time
+}
+
+type PayrollPeriodRequest struct {
+	PeriodStart string `json:"period_start" form:"period_start" binding:"required"` // YYYY-MM-DD
+	PeriodEnd   string `json:"period_end" form:"period_end" binding:"required"`     // YYYY-MM-DD
+}
+
+// PayrollPreview is the unsaved result of a payroll calculation.
+type PayrollPreview struct {
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	TotalAmount    float64   `json:"total_amount"`
+	Payslips       []Payslip `json:"payslips"`
+	MissingRateFor []string  `json:"missing_rate_for"` // teacher names with lessons but no matching rate
+}
+
+type PayrollUseCase interface {
+	// Pay rates
+	CreatePayRate(ctx context.Context, rate *TeacherPayRate) (*TeacherPayRate, error)
+	GetAllPayRates(ctx context.Context) ([]TeacherPayRate, error)
+	UpdatePayRate(ctx context.Context, rate *TeacherPayRate) error
+	DeletePayRate(ctx context.Context, id int) error
+
+	// Runs
+	PreviewPayroll(ctx context.Context, req PayrollPeriodRequest) (*PayrollPreview, error)
+	RunPayroll(ctx context.Context, adminUUID string, req PayrollPeriodRequest) (*PayrollRun, error)
+	GetAllPayrollRuns(ctx context.Context) ([]PayrollRun, error)
+	GetPayrollRunByID(ctx context.Context, id int) (*PayrollRun, error)
+
+	// Teacher
+	GetMyPayslips(ctx context.Context, teacherUUID string) ([]Payslip, error)
+}
+
+type PayrollRepository interface {
+	CreatePayRate(ctx context.Context, rate *TeacherPayRate) (*TeacherPayRate, error)
+	GetAllPayRates(ctx context.Context) ([]TeacherPayRate, error)
+	UpdatePayRate(ctx context.Context, rate *TeacherPayRate) error
+	DeletePayRate(ctx context.Context, id int) error
+
+	GetUnpaidLessons(ctx context.Context, start, end time.Time) ([]PayableLesson, error)
+	CreatePayrollRun(ctx context.Context, run *PayrollRun) error
+	GetAllPayrollRuns(ctx context.Context) ([]PayrollRun, error)
+	GetPayrollRunByID(ctx context.Context, id int) (*PayrollRun, error)
+	GetPayslipsByTeacher(ctx context.Context, teacherUUID string) ([]Payslip, error)
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	RatingMin = 1
+	RatingMax = 5
+
+	// Students may rate a completed class within this many days after it finished.
+	DefaultRatingWindowDays = 7
+	// Ratings at or below this value trigger an alert to managers.
+	DefaultLowRatingThreshold = 2
+)
+
+type ClassRating struct {
+	ID             int          `gorm:"primaryKey" json:"id"`
+	ClassHistoryID int          `gorm:"not null;uniqueIndex" json:"class_history_id"`
+	ClassHistory   ClassHistory `gorm:"foreignKey:ClassHistoryID;constraint:OnDelete:CASCADE;" json:"-"`
+	StudentUUID    string       `gorm:"type:uuid;not null;index" json:"-"` // never exposed to teachers
+	TeacherUUID    string       `gorm:"type:uuid;not null;index" json:"teacher_uuid"`
+	Rating         int          `gorm:"not null" json:"rating"`
+	Comment        *string      `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RatingFilter struct {
+	StartDate string `form:"start_date"`                                  // YYYY-MM-DD
+	EndDate   string `form:"end_date"`                                    // YYYY-MM-DD
+	Period    string `form:"period" binding:"omitempty,oneof=week month"` // trend bucket, default month
+}
+
+// TeacherRatingSummary is the anonymised view a teacher gets of their own ratings.
+type TeacherRatingSummary struct {
+	AverageRating  float64         `json:"average_rating"`
+	TotalRatings   int64           `json:"total_ratings"`
+	Distribution   map[int]int64   `json:"distribution"`
+	RecentComments []RatingComment `json:"recent_comments"`
+}
+
+type RatingComment struct {
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TeacherRatingAverage struct {
+	TeacherUUID   string  `json:"teacher_uuid"`
+	TeacherName   string  `json:"teacher_name"`
+	AverageRating float64 `json:"average_rating"`
+	TotalRatings  int64   `json:"total_ratings"`
+	LowRatings    int64   `json:"low_ratings"`
+}
+
+type RatingTrendPoint struct {
+	Period        time.Time `json:"period"`
+	AverageRating float64   `json:"average_rating"`
+	TotalRatings  int64     `json:"total_ratings"`
+}
+
+type RatingUseCase interface {
+	// Student
+	SubmitRating(ctx context.Context, studentUUID string, classHistoryID int, rating int, comment *string) (*ClassRating, error)
+	GetMyRatings(ctx context.Context, studentUUID string) ([]ClassRating, error)
+
+	// Teacher
+	GetMyRatingSummary(ctx context.Context, teacherUUID string) (*TeacherRatingSummary, error)
+
+	// Admin
+	GetTeacherRatingAverages(ctx context.Context, filter RatingFilter) ([]TeacherRatingAverage, error)
+	GetTeacherRatingTrend(ctx context.Context, teacherUUID string, filter RatingFilter) ([]RatingTrendPoint, error)
+}
+
+type RatingRepository interface {
+	GetClassHistoryForRating(ctx context.Context, classHistoryID int) (*ClassHistory, error)
+	CreateRating(ctx context.Context, rating *ClassRating) error
+	GetRatingsByStudent(ctx context.Context, studentUUID string) ([]ClassRating, error)
+	GetTeacherRatingSummary(ctx context.Context, teacherUUID string, commentLimit int) (*TeacherRatingSummary, error)
+	GetTeacherRatingAverages(ctx context.Context, filter RatingFilter, lowThreshold int) ([]TeacherRatingAverage, error)
+	GetTeacherRatingTrend(ctx context.Context, teacherUUID string, filter RatingFilter) ([]RatingTrendPoint, error)
+	GetActiveManagers(ctx context.Context) ([]User, error)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const ReceiptContentType = "application/pdf"
+
+// Receipt reserves a sequential receipt number for a settled payment. The PDF itself is
+// rendered on demand from the payment, so it is never stored.
+type Receipt struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	PaymentID int       `gorm:"not null;uniqueIndex" json:"payment_id"`
+	Sequence  int       `gorm:"not null;uniqueIndex" json:"sequence"`
+	Number    string    `gorm:"size:30;not null;uniqueIndex" json:"number"`
+	IssuedAt  time.Time `gorm:"not null" json:"issued_at"`
+}
+
+// ReceiptDocument is a rendered receipt ready to download or attach.
+type ReceiptDocument struct {
+	Receipt  Receipt
+	FileName string
+	Content  []byte
+}
+
+type ReceiptUseCase interface {
+	IssueReceipt(ctx context.Context, paymentID int) (*Receipt, error)
+	GetReceiptPDF(ctx context.Context, paymentID int) (*ReceiptDocument, error)
+	GetStudentReceiptPDF(ctx context.Context, studentUUID string, paymentID int) (*ReceiptDocument, error)
+}
+
+type ReceiptRepository interface {
+	// GetOrCreate returns the payment's receipt, assigning the next number if it has none.
+	GetOrCreate(ctx context.Context, paymentID int, issuedAt time.Time) (*Receipt, error)
+	GetPaymentForReceipt(ctx context.Context, paymentID int) (*Payment, error)
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ReconcileTriggerScheduled = "scheduled"
+	ReconcileTriggerManual    = "manual"
+
+	// Paid at the gateway but still PENDING locally (the callback was lost).
+	DiscrepancyPaidNotActivated = "PAID_NOT_ACTIVATED"
+	// The gateway amount differs from Payment.Amount; the payment is left untouched for review.
+	DiscrepancyAmountMismatch = "AMOUNT_MISMATCH"
+	// The payment never got a gateway invoice ID, so it can't be looked up.
+	DiscrepancyMissingInvoice = "MISSING_INVOICE"
+	DiscrepancyGatewayError   = "GATEWAY_ERROR"
+
+	DefaultReconcileInterval   = 15 * time.Minute
+	DefaultReconcileStaleAfter = 30 * time.Minute
+	ReconcileBatchSize         = 200
+)
+
+type ReconciliationRun struct {
+	ID            int        `gorm:"primaryKey" json:"id"`
+	Trigger       string     `gorm:"size:20;not null" json:"trigger"`
+	TriggeredBy   *string    `gorm:"type:uuid" json:"triggered_by,omitempty"`
+	StartedAt     time.Time  `gorm:"not null" json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Checked       int        `gorm:"not null;default:0" json:"checked"`
+	Activated     int        `gorm:"not null;default:0" json:"activated"`
+	Expired       int        `gorm:"not null;default:0" json:"expired"`
+	Failed        int        `gorm:"not null;default:0" json:"failed"`
+	Discrepancies int        `gorm:"not null;default:0" json:"discrepancies"`
+
+	Items []PaymentDiscrepancy `gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE;" json:"items,omitempty"`
+}
+
+type PaymentDiscrepancy struct {
+	ID            int       `gorm:"primaryKey" json:"id"`
+	RunID         int       `gorm:"not null;index" json:"run_id"`
+	PaymentID     int       `gorm:"not null;index" json:"payment_id"`
+	ExternalID    string    `gorm:"not null" json:"external_id"`
+	Type          string    `gorm:"size:30;not null;index" json:"type"`
+	LocalStatus   string    `gorm:"size:20" json:"local_status"`
+	GatewayStatus string    `gorm:"size:20" json:"gateway_status"`
+	LocalAmount   float64   `json:"local_amount"`
+	GatewayAmount float64   `json:"gateway_amount"`
+	Detail        string    `gorm:"type:text" json:"detail"`
+	Resolved      bool      `gorm:"not null;default:false" json:"resolved"` // true when the reconciler fixed it itself
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type DiscrepancyFilter struct {
+	Page     int    `form:"page,default=1"`
+	Limit    int    `form:"limit,default=10"`
+	Type     string `form:"type"`
+	Resolved *bool  `form:"resolved"`
+}
+
+type ReconciliationUseCase interface {
+	ReconcilePendingPayments(ctx context.Context, trigger string, triggeredBy *string) (*ReconciliationRun, error)
+	GetReconciliationRuns(ctx context.Context, page, limit int) ([]ReconciliationRun, int64, error)
+	GetReconciliationRunByID(ctx context.Context, id int) (*ReconciliationRun, error)
+	GetDiscrepancies(ctx context.Context, filter DiscrepancyFilter) ([]PaymentDiscrepancy, int64, error)
+}
+
+type ReconciliationRepository interface {
+	// CreateRun stores the run; an open discrepancy already on record for the same payment
+	// and type isn't stored again.
+	CreateRun(ctx context.Context, run *ReconciliationRun) error
+	// ResolveDiscrepancies closes the open discrepancies of a type for a payment.
+	ResolveDiscrepancies(ctx context.Context, paymentID int, kind string) error
+	GetRuns(ctx context.Context, page, limit int) ([]ReconciliationRun, int64, error)
+	GetRunByID(ctx context.Context, id int) (*ReconciliationRun, error)
+	GetDiscrepancies(ctx context.Context, filter DiscrepancyFilter) ([]PaymentDiscrepancy, int64, error)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrRefreshTokenReused means a refresh token that was already rotated came back. The
+	// token may have leaked, so its whole family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token sudah pernah digunakan, sesi telah dicabut demi keamanan")
+	// ErrRefreshTokenRevoked means the family is unknown: logged out, revoked or expired.
+	ErrRefreshTokenRevoked = errors.New("sesi sudah berakhir, silakan login kembali")
+	// ErrSessionRevocationFailed means the password was changed but the other sessions could
+	// not be logged out, so whoever held them may still be signed in.
+	ErrSessionRevocationFailed = errors.New("password sudah diubah, tetapi gagal mengakhiri sesi lain, silakan coba lagi")
+)
+
+// MaxUserAgentLength caps what is stored per session.
+const MaxUserAgentLength = 255
+
+// ClientInfo describes the device a login or refresh comes from.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// RefreshTokenFamily is one login session. Every refresh rotates CurrentTokenID; only the
+// newest refresh token of the family is accepted. Users see their families as sessions.
+type RefreshTokenFamily struct {
+	FamilyID       string    `json:"id"`
+	UserUUID       string    `json:"-"`
+	CurrentTokenID string    `json:"-"`
+	UserAgent      string    `json:"user_agent"`
+	IPAddress      string    `json:"ip_address"` // of the last login or refresh
+	CreatedAt      time.Time `json:"created_at"`
+	LastUsedAt     time.Time `json:"last_used_at"`
+	// Current marks the session the request was made with.
+	Current bool `json:"current"`
+}
+
+type RefreshTokenRepository interface {
+	CreateFamily(ctx context.Context, family *RefreshTokenFamily, ttl time.Duration) error
+	// Rotate replaces the family's current token ID with newTokenID if tokenID is the
+	// current one. A stale tokenID revokes the family and returns ErrRefreshTokenReused;
+	// an unknown family returns ErrRefreshTokenRevoked.
+	Rotate(ctx context.Context, userUUID, familyID, tokenID, newTokenID, ipAddress string, now time.Time, ttl time.Duration) error
+	// GetFamily returns nil when the family does not exist (anymore).
+	GetFamily(ctx context.Context, familyID string) (*RefreshTokenFamily, error)
+	// FamilyExists is the cheap check AuthMiddleware runs on every request.
+	FamilyExists(ctx context.Context, familyID string) (bool, error)
+	GetFamiliesByUser(ctx context.Context, userUUID string) ([]RefreshTokenFamily, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeUserFamilies revokes every family of the user except exceptFamilyID (may be
+	// empty) and returns how many were revoked.
+	RevokeUserFamilies(ctx context.Context, userUUID, exceptFamilyID string) (int, error)
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	RefundModeFull     = "full"
+	RefundModeProrated = "prorated"
+
+	RefundMethodGateway = "gateway"
+	RefundMethodManual  = "manual"
+
+	RefundStatusSucceeded = "SUCCEEDED"
+	RefundStatusPending   = "PENDING" // accepted by the gateway but not settled yet
+)
+
+// IsSettledPaymentStatus reports whether a payment has already been paid, including
+// payments that were later refunded. Such payments must never be activated again.
+func IsSettledPaymentStatus(status string) bool {
+	switch status {
+	case PaymentStatusPaid, PaymentStatusPartiallyRefunded, PaymentStatusRefunded:
+		return true
+	}
+	return false
+}
+
+type PaymentRefund struct {
+	ID                int       `gorm:"primaryKey" json:"id"`
+	PaymentID         int       `gorm:"not null;index" json:"payment_id"`
+	StudentPackageID  *int      `gorm:"index" json:"student_package_id,omitempty"`
+	ReferenceID       string    `gorm:"size:100;unique;not null" json:"reference_id"`
+	Mode              string    `gorm:"size:20;not null" json:"mode"`
+	Method            string    `gorm:"size:20;not null" json:"method"`
+	Amount            float64   `gorm:"not null" json:"amount"`
+	Lessons           int       `gorm:"not null;default:0" json:"lessons"` // lessons taken away from the package
+	QuotaRevoked      int       `gorm:"not null;default:0" json:"quota_revoked"`
+	CancelledBookings int       `gorm:"not null;default:0" json:"cancelled_bookings"`
+	PackageRevoked    bool      `gorm:"not null;default:false" json:"package_revoked"`
+	Status            string    `gorm:"size:20;not null" json:"status"`
+	GatewayRefundID   *string   `json:"gateway_refund_id,omitempty"`
+	ManualReference   *string   `json:"manual_reference,omitempty"`
+	Reason            string    `gorm:"type:text;not null" json:"reason"`
+	CreatedBy         string    `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RefundRequest struct {
+	Mode            string  `json:"mode" binding:"required,oneof=full prorated"`
+	Method          string  `json:"method" binding:"required,oneof=gateway manual"`
+	Lessons         *int    `json:"lessons" binding:"omitempty,min=1"` // prorated only; defaults to every unused lesson
+	Reason          string  `json:"reason" binding:"required,min=3"`
+	ManualReference *string `json:"manual_reference"` // e.g. the bank transfer number of a manual refund
+}
+
+// RefundQuote is what a refund would do to a payment and its package, without doing it.
+type RefundQuote struct {
+	PaymentID        int     `json:"payment_id"`
+	Mode             string  `json:"mode"`
+	PaidAmount       float64 `json:"paid_amount"`
+	RefundedAmount   float64 `json:"refunded_amount"`
+	RefundableAmount float64 `json:"refundable_amount"`
+	TotalLessons     int     `json:"total_lessons"`
+	UsedLessons      int     `json:"used_lessons"`
+	UnusedLessons    int     `json:"unused_lessons"`
+	Lessons          int     `json:"lessons"`
+	Amount           float64 `json:"amount"`
+	StudentPackageID *int    `json:"student_package_id,omitempty"`
+	QuotaRevoked     int     `json:"quota_revoked"`
+	BookingsToCancel int     `json:"bookings_to_cancel"`
+	PackageRevoked   bool    `json:"package_revoked"`
+}
+
+type RefundResult struct {
+	Refund  PaymentRefund `json:"refund"`
+	Payment Payment       `json:"payment"`
+	// Bookings cancelled because the reduced package no longer covers them.
+	CancelledBookings []Booking `json:"cancelled_bookings"`
+}
+
+type RefundUseCase interface {
+	QuoteRefund(ctx context.Context, paymentID int, req RefundRequest) (*RefundQuote, error)
+	RefundPayment(ctx context.Context, paymentID int, adminUUID string, req RefundRequest) (*RefundResult, error)
+	GetRefundsByPayment(ctx context.Context, paymentID int) ([]PaymentRefund, error)
+}
+
+type RefundRepository interface {
+	GetRefundsByPayment(ctx context.Context, paymentID int) ([]PaymentRefund, error)
+}
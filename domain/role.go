@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Permissions checked by middleware.RequirePermission. Routes declare the one they need;
+// roles grant them.
+const (
+	PermissionAdminProfileWrite   = "admin_profile.write"
+	PermissionManagerProfileWrite = "manager_profile.write"
+	PermissionTeachersRead        = "teachers.read"
+	PermissionTeachersWrite       = "teachers.write"
+	PermissionManagersRead        = "managers.read"
+	PermissionManagersWrite       = "managers.write"
+	PermissionStudentsRead        = "students.read"
+	PermissionStudentsWrite       = "students.write"
+	PermissionUsersRead           = "users.read"
+	PermissionUsersWrite          = "users.write"
+	PermissionUsersSecurity       = "users.security"
+	PermissionRolesManage         = "roles.manage"
+	PermissionPackagesRead        = "packages.read"
+	PermissionPackagesWrite       = "packages.write"
+	PermissionPackagesAssign      = "packages.assign"
+	PermissionInstrumentsRead     = "instruments.read"
+	PermissionInstrumentsWrite    = "instruments.write"
+	PermissionQuotaModify         = "quota.modify"
+	PermissionClassHistoriesRead  = "class_histories.read"
+	PermissionPaymentsRead        = "payments.read"
+	PermissionPaymentsWrite       = "payments.write"
+	PermissionPaymentsRefund      = "payments.refund"
+	PermissionReportsRead         = "reports.read"
+	PermissionReportsExport       = "reports.export"
+	PermissionPayrollRead         = "payroll.read"
+	PermissionPayrollWrite        = "payroll.write"
+	PermissionRatingsRead         = "ratings.read"
+	PermissionVouchersRead        = "vouchers.read"
+	PermissionVouchersWrite       = "vouchers.write"
+	PermissionSettingsManage      = "settings.manage"
+	PermissionAuditRead           = "audit.read"
+	PermissionAPIKeysManage       = "api_keys.manage"
+	PermissionTwoFactorUse        = "two_factor.use"
+
+	// Portal permissions open the student and teacher self-service routes. Those routes
+	// work on the caller's own student or teacher profile, so they only make sense for the
+	// built-in student and teacher roles and can't be granted to other roles.
+	PermissionStudentPortal = "student.portal"
+	PermissionTeacherPortal = "teacher.portal"
+)
+
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Portal      bool   `json:"portal"`
+	// APIKey marks permissions that can be granted to API keys. Keys have no user behind
+	// them, so only routes that don't act as the caller's own account qualify.
+	APIKey bool `json:"api_key"`
+}
+
+// PermissionCatalog lists every permission, in the order the admin UI shows them.
+var PermissionCatalog = []PermissionInfo{
+	{Name: PermissionAdminProfileWrite, Description: "Ubah profil admin sendiri"},
+	{Name: PermissionManagerProfileWrite, Description: "Ubah profil manajemen sendiri"},
+	{Name: PermissionTeachersRead, Description: "Lihat data guru", APIKey: true},
+	{Name: PermissionTeachersWrite, Description: "Tambah dan ubah guru"},
+	{Name: PermissionManagersRead, Description: "Lihat data manajemen"},
+	{Name: PermissionManagersWrite, Description: "Tambah akun manajemen"},
+	{Name: PermissionStudentsRead, Description: "Lihat data murid", APIKey: true},
+	{Name: PermissionStudentsWrite, Description: "Daftarkan murid baru", APIKey: true},
+	{Name: PermissionUsersRead, Description: "Lihat semua pengguna"},
+	{Name: PermissionUsersWrite, Description: "Nonaktifkan dan aktifkan kembali pengguna"},
+	{Name: PermissionUsersSecurity, Description: "Paksa logout, buka kunci akun, reset 2FA dan lihat riwayat login"},
+	{Name: PermissionRolesManage, Description: "Kelola role, izin dan role pengguna"},
+	{Name: PermissionPackagesRead, Description: "Lihat paket", APIKey: true},
+	{Name: PermissionPackagesWrite, Description: "Tambah, ubah dan hapus paket"},
+	{Name: PermissionPackagesAssign, Description: "Berikan paket ke murid"},
+	{Name: PermissionInstrumentsRead, Description: "Lihat instrumen", APIKey: true},
+	{Name: PermissionInstrumentsWrite, Description: "Tambah, ubah dan hapus instrumen"},
+	{Name: PermissionQuotaModify, Description: "Ubah kuota paket murid"},
+	{Name: PermissionClassHistoriesRead, Description: "Lihat riwayat kelas", APIKey: true},
+	{Name: PermissionPaymentsRead, Description: "Lihat pembayaran, kwitansi, rekonsiliasi dan webhook"},
+	{Name: PermissionPaymentsWrite, Description: "Catat pembayaran offline, jalankan rekonsiliasi dan proses ulang webhook"},
+	{Name: PermissionPaymentsRefund, Description: "Refund pembayaran"},
+	{Name: PermissionReportsRead, Description: "Lihat laporan keuntungan, ringkasan paket dan analitik"},
+	{Name: PermissionReportsExport, Description: "Ekspor laporan ke CSV/XLSX"},
+	{Name: PermissionPayrollRead, Description: "Lihat tarif dan penggajian guru"},
+	{Name: PermissionPayrollWrite, Description: "Ubah tarif dan jalankan penggajian guru"},
+	{Name: PermissionRatingsRead, Description: "Lihat rating guru"},
+	{Name: PermissionVouchersRead, Description: "Lihat voucher"},
+	{Name: PermissionVouchersWrite, Description: "Tambah, ubah dan hapus voucher"},
+	{Name: PermissionSettingsManage, Description: "Lihat dan ubah pengaturan aplikasi"},
+	{Name: PermissionAuditRead, Description: "Lihat log audit"},
+	{Name: PermissionAPIKeysManage, Description: "Buat dan cabut API key"},
+	{Name: PermissionTwoFactorUse, Description: "Atur 2FA akun sendiri"},
+	{Name: PermissionStudentPortal, Description: "Akses portal murid", Portal: true},
+	{Name: PermissionTeacherPortal, Description: "Akses portal guru", Portal: true},
+}
+
+// LookupPermission returns the catalog entry of a permission name.
+func LookupPermission(name string) (PermissionInfo, bool) {
+	for _, p := range PermissionCatalog {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PermissionInfo{}, false
+}
+
+// DefaultRolePermissions are the permissions the built-in roles start with, matching the
+// access they had before roles were editable. The admin role isn't listed: it always has
+// every non-portal permission, so no edit can lock admins out.
+var DefaultRolePermissions = map[string][]string{
+	RoleManagement: {PermissionStudentsRead, PermissionQuotaModify, PermissionManagerProfileWrite, PermissionTwoFactorUse},
+	RoleTeacher:    {PermissionTeacherPortal},
+	RoleStudent:    {PermissionStudentPortal},
+}
+
+// IsStaffRole reports whether a role belongs to back-office staff: admins, management
+// and any custom role. Only students and teachers are not staff.
+func IsStaffRole(role string) bool {
+	return role != "" && role != RoleStudent && role != RoleTeacher
+}
+
+// Role groups permissions. Users reference it by name in User.Role, which also ends up
+// in their access token.
+type Role struct {
+	Name        string    `gorm:"primaryKey;size:50" json:"name"`
+	DisplayName string    `gorm:"size:100;not null" json:"display_name"`
+	Description string    `json:"description"`
+	IsSystem    bool      `gorm:"not null;default:false" json:"is_system"`
+	Permissions []string  `gorm:"-" json:"permissions"`
+	UserCount   int64     `gorm:"-" json:"user_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type RolePermission struct {
+	RoleName   string `gorm:"primaryKey;size:50" json:"role_name"`
+	Permission string `gorm:"primaryKey;size:100" json:"permission"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	DisplayName string   `json:"display_name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	DisplayName string   `json:"display_name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// PermissionChecker is what middleware.RequirePermission needs from the role service.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+type RoleUseCase interface {
+	PermissionChecker
+	GetPermissions(ctx context.Context, role string) ([]string, error)
+	GetRoles(ctx context.Context) ([]Role, error)
+	CreateRole(ctx context.Context, actorRole string, req CreateRoleRequest) (*Role, error)
+	UpdateRole(ctx context.Context, actorRole, name string, req UpdateRoleRequest) (*Role, error)
+	DeleteRole(ctx context.Context, name string) error
+	AssignRole(ctx context.Context, actorUUID, actorRole, userUUID, role string) error
+}
+
+type RoleRepository interface {
+	GetRoles(ctx context.Context) ([]Role, error)
+	GetRole(ctx context.Context, name string) (*Role, error) // nil when missing
+	GetAllRolePermissions(ctx context.Context) (map[string][]string, error)
+	CreateRole(ctx context.Context, role *Role) error
+	UpdateRole(ctx context.Context, role *Role) error
+	DeleteRole(ctx context.Context, name string) error
+	// AssignUserRole refuses to move an admin to another role unless allowAdmin is set.
+	AssignUserRole(ctx context.Context, userUUID, role string, allowAdmin bool) (previous string, err error)
+}
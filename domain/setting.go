@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// SettingMaxOpenInvoices caps how many unpaid, unexpired invoices a student may hold. 0 = no cap.
+	SettingMaxOpenInvoices        = "checkout.max_open_invoices"
+	DefaultMaxOpenInvoices        = 3
+	SettingMaxOpenInvoicesMaximum = 50
+
+	// SettingRequireTwoFactor makes 2FA mandatory for admin and management accounts when 1.
+	SettingRequireTwoFactor = "security.require_two_factor"
+)
+
+// AppSetting is an admin-editable runtime setting. Values are stored as text and
+// validated per key by the setting service.
+type AppSetting struct {
+	Key       string    `gorm:"primaryKey;size:100" json:"key"`
+	Value     string    `gorm:"type:text;not null" json:"value"`
+	UpdatedBy *string   `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type UpdateSettingRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+type SettingUseCase interface {
+	GetSettings(ctx context.Context) ([]AppSetting, error)
+	UpdateSetting(ctx context.Context, key, value, updatedBy string) (*AppSetting, error)
+	GetInt(ctx context.Context, key string) (int, error)
+}
+
+type SettingRepository interface {
+	GetAll(ctx context.Context) ([]AppSetting, error)
+	Get(ctx context.Context, key string) (*AppSetting, error) // nil when never set
+	Upsert(ctx context.Context, setting *AppSetting) error
+}
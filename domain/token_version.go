@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+// TokenVersionRepository tracks each user's token version. Access tokens carry the version
+// they were issued with, and bumping it invalidates every token issued before, which is
+// how password changes, deactivation and role changes log a user out everywhere.
+type TokenVersionRepository interface {
+	GetTokenVersion(ctx context.Context, userUUID string) (int, error)
+	BumpTokenVersion(ctx context.Context, userUUID string) (int, error)
+}
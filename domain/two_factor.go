@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// TwoFactorIssuer is the account name shown in authenticator apps.
+	TwoFactorIssuer = "MadEU"
+	// TwoFactorRecoveryCodeCount is how many single-use recovery codes each enrolment gets.
+	TwoFactorRecoveryCodeCount = 10
+	// TwoFactorChallengeTTL is how long a password-verified login waits for its 2FA code.
+	TwoFactorChallengeTTL = 5 * time.Minute
+	// TwoFactorMaxAttempts wrong codes end a login challenge; the user has to log in again.
+	TwoFactorMaxAttempts = 5
+)
+
+var (
+	ErrInvalidTwoFactorCode      = errors.New("kode 2FA tidak valid")
+	ErrTwoFactorChallengeInvalid = errors.New("verifikasi 2FA tidak valid atau sudah kedaluwarsa, silakan login kembali")
+	ErrTwoFactorTooManyAttempts  = errors.New("terlalu banyak kode 2FA yang salah, silakan login kembali")
+)
+
+// UserTwoFactor is a user's TOTP enrolment. The row exists with Enabled false while setup
+// is waiting for the first code.
+type UserTwoFactor struct {
+	UserUUID     string     `gorm:"primaryKey;type:uuid" json:"user_uuid"`
+	Secret       string     `gorm:"not null" json:"-"`
+	Enabled      bool       `gorm:"not null;default:false" json:"enabled"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"` // rejects replay of an accepted code
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TwoFactorRecoveryCode is a single-use code for logging in without the authenticator.
+// Only its SHA-256 hash is stored.
+type TwoFactorRecoveryCode struct {
+	ID        int        `gorm:"primaryKey" json:"id"`
+	UserUUID  string     `gorm:"type:uuid;not null;index" json:"user_uuid"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TwoFactorSetup is what the user needs to add the account to an authenticator app.
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURL string `json:"provisioning_url"`
+	QRCode          string `json:"qr_code"` // PNG data URL of ProvisioningURL
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorChallenge is returned by Login instead of tokens when the account uses 2FA.
+// SetupRequired means 2FA is mandatory for the account but not set up yet: the client
+// has to enrol with the challenge token before it can be completed.
+type TwoFactorChallenge struct {
+	Token         string    `json:"challenge_token"`
+	UserUUID      string    `json:"-"`
+	SetupRequired bool      `json:"setup_required"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// LoginResult holds either the tokens, the 2FA challenge to complete first, or for a
+// first OIDC login, the signup to complete. Recovery codes are only set when the login
+// finished a mandatory 2FA setup.
+type LoginResult struct {
+	Tokens        *AuthTokens
+	Challenge     *TwoFactorChallenge
+	Signup        *OIDCSignup
+	RecoveryCodes []string
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorUseCase interface {
+	GetStatus(ctx context.Context, userUUID, role string) (*TwoFactorStatus, error)
+	BeginSetup(ctx context.Context, userUUID string) (*TwoFactorSetup, error)
+	ConfirmSetup(ctx context.Context, userUUID, code string) ([]string, error)
+	Disable(ctx context.Context, userUUID, role, password, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error)
+	ResetForUser(ctx context.Context, userUUID string) error
+
+	// Login support: LoginChallenge returns nil when the user logs in with a password only.
+	LoginChallenge(ctx context.Context, user *User) (*TwoFactorChallenge, error)
+	BeginChallengeSetup(ctx context.Context, challengeToken string) (*TwoFactorSetup, error)
+	VerifyChallenge(ctx context.Context, challengeToken, code string) (userUUID string, recoveryCodes []string, err error)
+}
+
+type TwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userUUID string) (*UserTwoFactor, error) // nil when never set up
+	SavePendingSecret(ctx context.Context, userUUID, secret string) error
+	Enable(ctx context.Context, userUUID string, step int64, codeHashes []string) error
+	Delete(ctx context.Context, userUUID string) error
+	ConsumeStep(ctx context.Context, userUUID string, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, userUUID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userUUID string, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, userUUID string) (int, error)
+
+	CreateChallenge(ctx context.Context, challenge *TwoFactorChallenge, ttl time.Duration) error
+	GetChallenge(ctx context.Context, token string) (*TwoFactorChallenge, error) // nil when expired
+	RecordChallengeFailure(ctx context.Context, token string) (int, error)
+	DeleteChallenge(ctx context.Context, token string) (bool, error)
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	VoucherTypePercent = "percent"
+	VoucherTypeFixed   = "fixed"
+
+	// A redemption is reserved when the invoice is created and released again if the
+	// invoice expires or fails, so unpaid checkouts don't use up a voucher.
+	RedemptionReserved = "reserved"
+	RedemptionRedeemed = "redeemed"
+	RedemptionReleased = "released"
+
+	// MinCheckoutAmount is the smallest amount the gateway will invoice (IDR).
+	MinCheckoutAmount = 1000
+)
+
+type Voucher struct {
+	ID                int        `gorm:"primaryKey" json:"id"`
+	Code              string     `gorm:"size:50;uniqueIndex;not null" json:"code"`
+	Description       string     `json:"description"`
+	Type              string     `gorm:"size:10;not null" json:"type"`
+	Value             float64    `gorm:"not null" json:"value"`  // percent (0-100] or an IDR amount
+	MaxDiscount       *float64   `json:"max_discount,omitempty"` // caps a percent discount
+	StartsAt          *time.Time `json:"starts_at,omitempty"`
+	EndsAt            *time.Time `json:"ends_at,omitempty"`
+	MaxRedemptions    *int       `json:"max_redemptions,omitempty"` // nil = unlimited
+	MaxPerUser        *int       `json:"max_per_user,omitempty"`    // nil = unlimited
+	FirstPurchaseOnly bool       `gorm:"not null;default:false" json:"first_purchase_only"`
+	IsActive          bool       `gorm:"not null;default:true" json:"is_active"`
+	RedeemedCount     int        `gorm:"not null;default:0" json:"redeemed_count"`    // reserved + redeemed
+	Packages          []Package  `gorm:"many2many:voucher_packages;" json:"packages"` // empty = every package
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// NormalizeVoucherCode makes codes case- and whitespace-insensitive.
+func NormalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// AppliesTo reports whether the voucher may be used for the package.
+func (v *Voucher) AppliesTo(packageID int) bool {
+	if len(v.Packages) == 0 {
+		return true
+	}
+	for _, pkg := range v.Packages {
+		if pkg.ID == packageID {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountFor returns the discount on the given price, never more than the price itself.
+func (v *Voucher) DiscountFor(price float64) float64 {
+	discount := v.Value
+	if v.Type == VoucherTypePercent {
+		discount = math.Round(price * v.Value / 100)
+		if v.MaxDiscount != nil && discount > *v.MaxDiscount {
+			discount = *v.MaxDiscount
+		}
+	}
+	return math.Min(discount, price)
+}
+
+type VoucherRedemption struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	VoucherID   int       `gorm:"not null;index" json:"voucher_id"`
+	PaymentID   int       `gorm:"not null;uniqueIndex" json:"payment_id"`
+	StudentUUID string    `gorm:"type:uuid;not null;index" json:"student_uuid"`
+	Student     *User     `gorm:"foreignKey:StudentUUID;references:UUID" json:"student,omitempty"`
+	Discount    float64   `gorm:"not null" json:"discount"`
+	Status      string    `gorm:"size:20;not null" json:"status"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type VoucherFilter struct {
+	Page   int    `form:"page,default=1"`
+	Limit  int    `form:"limit,default=10"`
+	Search string `form:"search"`
+	Active *bool  `form:"active"`
+}
+
+type VoucherCheckRequest struct {
+	PackageID   int    `json:"package_id" binding:"required"`
+	VoucherCode string `json:"voucher_code" binding:"required"`
+}
+
+// PriceBreakdown is the price a student pays after any voucher.
+type PriceBreakdown struct {
+	PackageID      int     `json:"package_id"`
+	VoucherCode    *string `json:"voucher_code,omitempty"`
+	OriginalAmount float64 `json:"original_amount"`
+	DiscountAmount float64 `json:"discount_amount"`
+	Amount         float64 `json:"amount"`
+}
+
+type VoucherUseCase interface {
+	CreateVoucher(ctx context.Context, voucher *Voucher, packageIDs []int) (*Voucher, error)
+	UpdateVoucher(ctx context.Context, voucher *Voucher, packageIDs []int) (*Voucher, error)
+	DeleteVoucher(ctx context.Context, id int) error
+	GetVoucherByID(ctx context.Context, id int) (*Voucher, error)
+	GetVouchers(ctx context.Context, filter VoucherFilter) ([]Voucher, int64, error)
+	GetVoucherRedemptions(ctx context.Context, voucherID, page, limit int) ([]VoucherRedemption, int64, error)
+	CheckVoucher(ctx context.Context, studentUUID string, req VoucherCheckRequest) (*PriceBreakdown, error)
+}
+
+type VoucherRepository interface {
+	Create(ctx context.Context, voucher *Voucher, packageIDs []int) error
+	Update(ctx context.Context, voucher *Voucher, packageIDs []int) error
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*Voucher, error)
+	GetAll(ctx context.Context, filter VoucherFilter) ([]Voucher, int64, error)
+	GetRedemptions(ctx context.Context, voucherID, page, limit int) ([]VoucherRedemption, int64, error)
+}
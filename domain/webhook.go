@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	WebhookStatusReceived  = "RECEIVED"
+	WebhookStatusProcessed = "PROCESSED"
+	WebhookStatusFailed    = "FAILED"
+	WebhookStatusRejected  = "REJECTED"
+	WebhookStatusDuplicate = "DUPLICATE"
+
+	WebhookProviderPayment = "payment"
+
+	// MaxWebhookBodyBytes caps how much of an inbound webhook body is read and stored.
+	MaxWebhookBodyBytes = 1 << 20
+)
+
+// WebhookEvent is the raw record of an inbound webhook and what we did with it.
+type WebhookEvent struct {
+	ID           int        `gorm:"primaryKey" json:"id"`
+	Provider     string     `gorm:"size:30;not null;index" json:"provider"`
+	EventID      string     `gorm:"size:255;index" json:"event_id"`
+	Headers      string     `gorm:"type:text" json:"headers"` // JSON, secrets redacted
+	Body         string     `gorm:"type:text" json:"body"`
+	RemoteIP     string     `gorm:"size:64" json:"remote_ip"`
+	Verified     bool       `gorm:"not null;default:false" json:"verified"`
+	VerifyResult string     `gorm:"size:255" json:"verify_result"`
+	Status       string     `gorm:"size:20;not null;index" json:"status"`
+	Error        *string    `gorm:"type:text" json:"error,omitempty"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	ReceivedAt   time.Time  `gorm:"autoCreateTime" json:"received_at"`
+	ProcessedAt  *time.Time `json:"processed_at,omitempty"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type WebhookFilter struct {
+	Page     int    `form:"page,default=1"`
+	Limit    int    `form:"limit,default=10"`
+	Status   string `form:"status"`
+	Provider string `form:"provider"`
+	EventID  string `form:"event_id"`
+}
+
+type WebhookUseCase interface {
+	// ReceivePaymentWebhook stores the webhook, verifies it and hands it to the payment flow.
+	ReceivePaymentWebhook(ctx context.Context, headers http.Header, body []byte, remoteIP string) (*WebhookEvent, error)
+	GetWebhookEvents(ctx context.Context, filter WebhookFilter) ([]WebhookEvent, int64, error)
+	GetWebhookEventByID(ctx context.Context, id int) (*WebhookEvent, error)
+	ReprocessWebhookEvent(ctx context.Context, id int) (*WebhookEvent, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, event *WebhookEvent) error
+	Update(ctx context.Context, event *WebhookEvent) error
+	GetByID(ctx context.Context, id int) (*WebhookEvent, error)
+	HasProcessedEvent(ctx context.Context, provider, eventID string, excludeID int) (bool, error)
+	GetAll(ctx context.Context, filter WebhookFilter) ([]WebhookEvent, int64, error)
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "chronosphere/domain"
+import (
+	"chronosphere/domain"
+	"strings"
+)
 
 type UpdateStudentDataRequest struct {
 	Name string `json:"name" binding:"required,min=3,max=50"`
@@ -28,3 +31,30 @@ type BookClassRequest struct {
 type CancelBookingRequest struct {
 	Reason *string `json:"reason" binding:"omitempty,max=255"`
 }
+
+type SubmitRatingRequest struct {
+	Rating  int     `json:"rating" binding:"required,min=1,max=5"`
+	Comment *string `json:"comment" binding:"omitempty,max=1000"`
+}
+
+// Request untuk Create Student (admin / API key)
+type CreateStudentRequest struct {
+	Name     string  `json:"name" binding:"required,min=3,max=50"`
+	Email    string  `json:"email" binding:"required,email"`
+	Phone    string  `json:"phone" binding:"required,numeric,min=9,max=14"`
+	Password string  `json:"password" binding:"required,min=8"`
+	Gender   string  `json:"gender" binding:"required,oneof=male female"`
+	Image    *string `json:"image" binding:"omitempty,url"`
+}
+
+func MapCreateStudentRequestToUser(req *CreateStudentRequest) *domain.User {
+	return &domain.User{
+		Name:     req.Name,
+		Email:    strings.ToLower(req.Email),
+		Phone:    req.Phone,
+		Password: req.Password,
+		Role:     domain.RoleStudent,
+		Image:    req.Image,
+		Gender:   req.Gender,
+	}
+}
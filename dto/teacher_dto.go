@@ -71,9 +71,11 @@ func MapCreateTeacherRequestToUser(req *CreateTeacherRequest) *domain.User {
 	}
 }
 
-// Simplified request - teacher only needs to provide notes and optional photos
+// Simplified request - teacher only needs to provide notes and optional photos.
+// Status "no_show" records that the student didn't attend; it defaults to "completed".
 type FinishClassRequest struct {
 	BookingID    int      `json:"booking_id" binding:"required,gt=0"`
+	Status       string   `json:"status" binding:"omitempty,oneof=completed no_show"`
 	Notes        string   `json:"notes" binding:"omitempty,max=2000"`
 	DocumentURLs []string `json:"documentations,omitempty" binding:"omitempty,dive,url"`
 }
@@ -85,6 +87,9 @@ func MapFinishClassRequestToClassHistory(req *FinishClassRequest, bookingID int)
 		Notes:     &req.Notes,
 		Status:    domain.StatusCompleted,
 	}
+	if req.Status == domain.StatusNoShow {
+		history.Status = domain.StatusNoShow
+	}
 
 	// Add documentation URLs if provided
 	if len(req.DocumentURLs) > 0 {
@@ -0,0 +1,274 @@
+package gateway
+
+import (
+	"bytes"
+	"chronosphere/domain"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeChannels are the payment channels offered on the local payment page.
+var FakeChannels = map[string]string{
+	"BCA":  "BANK_TRANSFER",
+	"QRIS": "QR_CODE",
+	"OVO":  "EWALLET",
+}
+
+type fakeInvoice struct {
+	invoice            domain.GatewayInvoice
+	successRedirectURL string
+	failureRedirectURL string
+	refunded           float64
+	refunds            map[string]*domain.GatewayRefund // keyed by ReferenceID, like the Xendit idempotency key
+}
+
+// FakeGateway is an in-memory gateway for offline development. Invoices point at a local
+// payment page, and paying or expiring one there posts a Xendit-shaped callback to the app.
+type FakeGateway struct {
+	mu            sync.Mutex
+	invoices      map[string]*fakeInvoice
+	pageBaseURL   string
+	callbackURL   string
+	callbackToken string
+	httpClient    *http.Client
+}
+
+func NewFakeGateway(pageBaseURL, callbackURL, callbackToken string) *FakeGateway {
+	return &FakeGateway{
+		invoices:      make(map[string]*fakeInvoice),
+		pageBaseURL:   pageBaseURL,
+		callbackURL:   callbackURL,
+		callbackToken: callbackToken,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return domain.PaymentGatewayFake
+}
+
+func (g *FakeGateway) CreateInvoice(ctx context.Context, req domain.GatewayInvoiceRequest) (*domain.GatewayInvoice, error) {
+	if req.ExternalID == "" || req.Amount <= 0 {
+		return nil, errors.New("fake gateway: external id and a positive amount are required")
+	}
+
+	id, err := fakeID("inv")
+	if err != nil {
+		return nil, err
+	}
+
+	duration := req.Duration
+	if duration <= 0 {
+		duration = domain.DefaultInvoiceDuration
+	}
+
+	inv := &fakeInvoice{
+		invoice: domain.GatewayInvoice{
+			ID:          id,
+			ExternalID:  req.ExternalID,
+			Status:      domain.PaymentStatusPending,
+			Amount:      req.Amount,
+			InvoiceURL:  fmt.Sprintf("%s/fake-gateway/invoices/%s", g.pageBaseURL, id),
+			PayerEmail:  req.PayerEmail,
+			Description: req.Description,
+			ExpiresAt:   time.Now().Add(duration),
+		},
+		successRedirectURL: req.SuccessRedirectURL,
+		failureRedirectURL: req.FailureRedirectURL,
+	}
+
+	g.mu.Lock()
+	g.invoices[id] = inv
+	g.mu.Unlock()
+
+	result := inv.invoice
+	return &result, nil
+}
+
+func (g *FakeGateway) GetInvoice(ctx context.Context, invoiceID string) (*domain.GatewayInvoice, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	inv, ok := g.invoices[invoiceID]
+	if !ok {
+		return nil, errors.New("fake gateway: invoice not found")
+	}
+	g.expireIfDue(inv)
+
+	result := inv.invoice
+	return &result, nil
+}
+
+func (g *FakeGateway) ExpireInvoice(ctx context.Context, invoiceID string) (*domain.GatewayInvoice, error) {
+	g.mu.Lock()
+	inv, ok := g.invoices[invoiceID]
+	if !ok {
+		g.mu.Unlock()
+		return nil, errors.New("fake gateway: invoice not found")
+	}
+	if inv.invoice.Status != domain.PaymentStatusPending {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("fake gateway: invoice is %s, only PENDING invoices can be expired", inv.invoice.Status)
+	}
+	inv.invoice.Status = domain.PaymentStatusExpired
+	result := inv.invoice
+	g.mu.Unlock()
+
+	return &result, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, req domain.GatewayRefundRequest) (*domain.GatewayRefund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	inv, ok := g.invoices[req.InvoiceID]
+	if !ok {
+		return nil, errors.New("fake gateway: invoice not found")
+	}
+	if inv.invoice.Status != domain.PaymentStatusPaid {
+		return nil, errors.New("fake gateway: only PAID invoices can be refunded")
+	}
+	if previous, ok := inv.refunds[req.ReferenceID]; ok && req.ReferenceID != "" {
+		return previous, nil
+	}
+	if req.Amount <= 0 || inv.refunded+req.Amount > inv.invoice.Amount {
+		return nil, errors.New("fake gateway: refund amount exceeds the paid amount")
+	}
+
+	id, err := fakeID("rfd")
+	if err != nil {
+		return nil, err
+	}
+	inv.refunded += req.Amount
+
+	result := &domain.GatewayRefund{ID: id, Status: "SUCCEEDED", Amount: req.Amount}
+	if req.ReferenceID != "" {
+		if inv.refunds == nil {
+			inv.refunds = make(map[string]*domain.GatewayRefund)
+		}
+		inv.refunds[req.ReferenceID] = result
+	}
+	return result, nil
+}
+
+// Invoices lists every invoice the fake gateway knows about, newest first.
+func (g *FakeGateway) Invoices() []domain.GatewayInvoice {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	list := make([]domain.GatewayInvoice, 0, len(g.invoices))
+	for _, inv := range g.invoices {
+		g.expireIfDue(inv)
+		list = append(list, inv.invoice)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ExpiresAt.After(list[j].ExpiresAt) })
+	return list
+}
+
+// Simulate moves a pending invoice to the given status and delivers the callback the way
+// Xendit would. It returns the redirect URL the payer should be sent to, if any.
+func (g *FakeGateway) Simulate(ctx context.Context, invoiceID, status, channel string) (*domain.GatewayInvoice, string, error) {
+	g.mu.Lock()
+	inv, ok := g.invoices[invoiceID]
+	if !ok {
+		g.mu.Unlock()
+		return nil, "", errors.New("fake gateway: invoice not found")
+	}
+	g.expireIfDue(inv)
+	if inv.invoice.Status != domain.PaymentStatusPending {
+		g.mu.Unlock()
+		return nil, "", fmt.Errorf("fake gateway: invoice is already %s", inv.invoice.Status)
+	}
+
+	redirect := inv.failureRedirectURL
+	switch status {
+	case domain.PaymentStatusPaid:
+		method, ok := FakeChannels[channel]
+		if !ok {
+			g.mu.Unlock()
+			return nil, "", fmt.Errorf("fake gateway: unknown channel %q", channel)
+		}
+		now := time.Now()
+		inv.invoice.PaidAt = &now
+		inv.invoice.PaymentMethod = method
+		inv.invoice.PaymentChannel = channel
+		redirect = inv.successRedirectURL
+	case domain.PaymentStatusExpired, domain.PaymentStatusFailed:
+	default:
+		g.mu.Unlock()
+		return nil, "", fmt.Errorf("fake gateway: cannot simulate status %q", status)
+	}
+	inv.invoice.Status = status
+	result := inv.invoice
+	g.mu.Unlock()
+
+	if err := g.sendCallback(ctx, result); err != nil {
+		return &result, redirect, err
+	}
+	return &result, redirect, nil
+}
+
+func (g *FakeGateway) sendCallback(ctx context.Context, inv domain.GatewayInvoice) error {
+	payload := domain.PaymentCallback{
+		ID:             inv.ID,
+		ExternalID:     inv.ExternalID,
+		Status:         inv.Status,
+		MerchantName:   "Fake Gateway",
+		Amount:         inv.Amount,
+		PayerEmail:     inv.PayerEmail,
+		Description:    inv.Description,
+		PaymentMethod:  inv.PaymentMethod,
+		PaymentChannel: inv.PaymentChannel,
+	}
+	if inv.PaidAt != nil {
+		payload.PaidAt = *inv.PaidAt
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.callbackToken != "" {
+		req.Header.Set("x-callback-token", g.callbackToken)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fake gateway: callback failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("fake gateway: callback returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// expireIfDue must be called with g.mu held.
+func (g *FakeGateway) expireIfDue(inv *fakeInvoice) {
+	if inv.invoice.Status == domain.PaymentStatusPending && time.Now().After(inv.invoice.ExpiresAt) {
+		inv.invoice.Status = domain.PaymentStatusExpired
+	}
+}
+
+func fakeID(prefix string) (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("fake gateway: %w", err)
+	}
+	return prefix + "_" + hex.EncodeToString(buf), nil
+}
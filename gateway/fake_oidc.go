@@ -0,0 +1,253 @@
+package gateway
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	fakeOIDCCodeTTL  = 5 * time.Minute
+	fakeOIDCTokenTTL = time.Hour
+)
+
+// FakeOIDCError is an OAuth error as the fake issuer's token endpoint reports it.
+type FakeOIDCError struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *FakeOIDCError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// FakeOIDCLogin is what the fake issuer's login page submits: the authorization request
+// it was opened with and the user to log in as.
+type FakeOIDCLogin struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+
+	Email         string
+	Name          string
+	EmailVerified bool
+}
+
+type fakeOIDCCode struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	name          string
+	emailVerified bool
+	expiresAt     time.Time
+}
+
+// FakeOIDCIssuer is an in-memory OpenID Connect issuer for offline development. Its login
+// page lets you pick any email, so the whole OIDC flow can be exercised end-to-end without
+// a real provider. Subjects are derived from the email, so logging in again with the same
+// email is the same provider account.
+type FakeOIDCIssuer struct {
+	mu           sync.Mutex
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	key          *rsa.PrivateKey
+	keyID        string
+	codes        map[string]*fakeOIDCCode
+}
+
+func NewFakeOIDCIssuer(issuer, clientID, clientSecret, redirectURL string) (*FakeOIDCIssuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("fake oidc: failed to generate signing key: %w", err)
+	}
+	keyID, err := fakeID("key")
+	if err != nil {
+		return nil, err
+	}
+	return &FakeOIDCIssuer{
+		issuer:       strings.TrimRight(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		key:          key,
+		keyID:        keyID,
+		codes:        make(map[string]*fakeOIDCCode),
+	}, nil
+}
+
+func (f *FakeOIDCIssuer) Issuer() string {
+	return f.issuer
+}
+
+func (f *FakeOIDCIssuer) Discovery() map[string]interface{} {
+	return map[string]interface{}{
+		"issuer":                                f.issuer,
+		"authorization_endpoint":                f.issuer + "/authorize",
+		"token_endpoint":                        f.issuer + "/token",
+		"jwks_uri":                              f.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	}
+}
+
+func (f *FakeOIDCIssuer) JWKS() map[string]interface{} {
+	encode := base64.RawURLEncoding.EncodeToString
+	return map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": f.keyID,
+			"n":   encode(f.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(f.key.E)).Bytes()),
+		}},
+	}
+}
+
+// ValidateAuthorization checks an authorization request before the login page is shown.
+func (f *FakeOIDCIssuer) ValidateAuthorization(login FakeOIDCLogin) error {
+	switch {
+	case login.ClientID != f.clientID:
+		return fmt.Errorf("unknown client_id %q", login.ClientID)
+	case login.RedirectURI != f.redirectURL:
+		return fmt.Errorf("redirect_uri %q is not registered", login.RedirectURI)
+	case login.ResponseType != "code":
+		return fmt.Errorf("unsupported response_type %q", login.ResponseType)
+	case !strings.Contains(" "+login.Scope+" ", " openid "):
+		return fmt.Errorf("scope must include openid")
+	case login.CodeChallenge == "" || login.CodeChallengeMethod != "S256":
+		return fmt.Errorf("PKCE with code_challenge_method S256 is required")
+	case login.State == "":
+		return fmt.Errorf("state is required")
+	}
+	return nil
+}
+
+// Authorize logs the user in and returns the redirect back to the client with the code.
+func (f *FakeOIDCIssuer) Authorize(login FakeOIDCLogin) (string, error) {
+	if err := f.ValidateAuthorization(login); err != nil {
+		return "", err
+	}
+	if login.Email == "" {
+		return "", fmt.Errorf("email is required")
+	}
+
+	code, err := fakeID("code")
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	f.codes[code] = &fakeOIDCCode{
+		redirectURI:   login.RedirectURI,
+		codeChallenge: login.CodeChallenge,
+		nonce:         login.Nonce,
+		email:         strings.TrimSpace(login.Email),
+		name:          strings.TrimSpace(login.Name),
+		emailVerified: login.EmailVerified,
+		expiresAt:     time.Now().Add(fakeOIDCCodeTTL),
+	}
+	f.mu.Unlock()
+
+	redirect, err := url.Parse(login.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", login.State)
+	redirect.RawQuery = query.Encode()
+	return redirect.String(), nil
+}
+
+// Token redeems an authorization code. The client authenticates with HTTP basic auth or,
+// failing that, client_id and client_secret in the form.
+func (f *FakeOIDCIssuer) Token(r *http.Request) (map[string]interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, &FakeOIDCError{Status: http.StatusBadRequest, Code: "invalid_request", Description: err.Error()}
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != f.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(f.clientSecret)) != 1 {
+		return nil, &FakeOIDCError{Status: http.StatusUnauthorized, Code: "invalid_client", Description: "client authentication failed"}
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		return nil, &FakeOIDCError{Status: http.StatusBadRequest, Code: "unsupported_grant_type", Description: "only authorization_code is supported"}
+	}
+
+	f.mu.Lock()
+	code, found := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code")) // codes are single use, even when the exchange fails
+	f.mu.Unlock()
+
+	if !found || time.Now().After(code.expiresAt) {
+		return nil, &FakeOIDCError{Status: http.StatusBadRequest, Code: "invalid_grant", Description: "code is invalid or expired"}
+	}
+	if r.PostForm.Get("redirect_uri") != code.redirectURI {
+		return nil, &FakeOIDCError{Status: http.StatusBadRequest, Code: "invalid_grant", Description: "redirect_uri does not match"}
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != code.codeChallenge {
+		return nil, &FakeOIDCError{Status: http.StatusBadRequest, Code: "invalid_grant", Description: "code_verifier does not match"}
+	}
+
+	subject := sha256.Sum256([]byte(strings.ToLower(code.email)))
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            f.issuer,
+		"sub":            hex.EncodeToString(subject[:16]),
+		"aud":            f.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(fakeOIDCTokenTTL).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.email,
+		"email_verified": code.emailVerified,
+		"name":           code.name,
+	})
+	token.Header["kid"] = f.keyID
+	idToken, err := token.SignedString(f.key)
+	if err != nil {
+		return nil, &FakeOIDCError{Status: http.StatusInternalServerError, Code: "server_error", Description: err.Error()}
+	}
+
+	accessToken, err := fakeID("at")
+	if err != nil {
+		return nil, &FakeOIDCError{Status: http.StatusInternalServerError, Code: "server_error", Description: err.Error()}
+	}
+	return map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(fakeOIDCTokenTTL.Seconds()),
+		"id_token":     idToken,
+	}, nil
+}
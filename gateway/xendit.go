@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xendit/xendit-go/v6"
+	"github.com/xendit/xendit-go/v6/invoice"
+	"github.com/xendit/xendit-go/v6/refund"
+)
+
+type xenditGateway struct {
+	client *xendit.APIClient
+}
+
+func NewXenditGateway(apiKey string) domain.PaymentGateway {
+	return &xenditGateway{client: xendit.NewClient(apiKey)}
+}
+
+func (g *xenditGateway) Name() string {
+	return domain.PaymentGatewayXendit
+}
+
+func (g *xenditGateway) CreateInvoice(ctx context.Context, req domain.GatewayInvoiceRequest) (*domain.GatewayInvoice, error) {
+	createInvoiceRequest := *invoice.NewCreateInvoiceRequest(req.ExternalID, req.Amount)
+	if req.PayerEmail != "" {
+		createInvoiceRequest.SetPayerEmail(req.PayerEmail)
+	}
+	if req.Description != "" {
+		createInvoiceRequest.SetDescription(req.Description)
+	}
+	if req.SuccessRedirectURL != "" {
+		createInvoiceRequest.SetSuccessRedirectUrl(req.SuccessRedirectURL)
+	}
+	if req.FailureRedirectURL != "" {
+		createInvoiceRequest.SetFailureRedirectUrl(req.FailureRedirectURL)
+	}
+	if req.Duration > 0 {
+		createInvoiceRequest.SetInvoiceDuration(fmt.Sprintf("%d", int(req.Duration.Seconds())))
+	}
+
+	resp, _, xerr := g.client.InvoiceApi.CreateInvoice(ctx).CreateInvoiceRequest(createInvoiceRequest).Execute()
+	if xerr != nil {
+		return nil, fmt.Errorf("xendit error: %v", xerr.Error())
+	}
+	return toGatewayInvoice(resp), nil
+}
+
+func (g *xenditGateway) GetInvoice(ctx context.Context, invoiceID string) (*domain.GatewayInvoice, error) {
+	resp, _, xerr := g.client.InvoiceApi.GetInvoiceById(ctx, invoiceID).Execute()
+	if xerr != nil {
+		return nil, fmt.Errorf("xendit error: %v", xerr.Error())
+	}
+	return toGatewayInvoice(resp), nil
+}
+
+func (g *xenditGateway) ExpireInvoice(ctx context.Context, invoiceID string) (*domain.GatewayInvoice, error) {
+	resp, _, xerr := g.client.InvoiceApi.ExpireInvoice(ctx, invoiceID).Execute()
+	if xerr != nil {
+		return nil, fmt.Errorf("xendit error: %v", xerr.Error())
+	}
+	return toGatewayInvoice(resp), nil
+}
+
+func (g *xenditGateway) Refund(ctx context.Context, req domain.GatewayRefundRequest) (*domain.GatewayRefund, error) {
+	if req.InvoiceID == "" {
+		return nil, errors.New("invoice id is required for a refund")
+	}
+
+	body := *refund.NewCreateRefund()
+	body.InvoiceId = &req.InvoiceID
+	body.Amount = &req.Amount
+	if req.ReferenceID != "" {
+		body.ReferenceId = &req.ReferenceID
+	}
+	if req.Reason != "" {
+		body.Reason = &req.Reason
+	}
+
+	call := g.client.RefundApi.CreateRefund(ctx).CreateRefund(body)
+	if req.ReferenceID != "" {
+		call = call.IdempotencyKey(req.ReferenceID)
+	}
+
+	resp, _, xerr := call.Execute()
+	if xerr != nil {
+		return nil, fmt.Errorf("xendit error: %v", xerr.Error())
+	}
+
+	result := &domain.GatewayRefund{Status: "PENDING", Amount: req.Amount}
+	if resp.Id != nil {
+		result.ID = *resp.Id
+	}
+	if resp.Amount != nil {
+		result.Amount = *resp.Amount
+	}
+	return result, nil
+}
+
+func toGatewayInvoice(inv *invoice.Invoice) *domain.GatewayInvoice {
+	result := &domain.GatewayInvoice{
+		ExternalID: inv.ExternalId,
+		Status:     normalizeXenditStatus(string(inv.Status)),
+		Amount:     inv.Amount,
+		InvoiceURL: inv.InvoiceUrl,
+		ExpiresAt:  inv.ExpiryDate,
+	}
+	if inv.Id != nil {
+		result.ID = *inv.Id
+	}
+	if inv.PayerEmail != nil {
+		result.PayerEmail = *inv.PayerEmail
+	}
+	if inv.Description != nil {
+		result.Description = *inv.Description
+	}
+	if inv.PaymentMethod != nil {
+		result.PaymentMethod = string(*inv.PaymentMethod)
+	}
+	return result
+}
+
+// normalizeXenditStatus maps Xendit invoice statuses onto ours; SETTLED is a paid invoice whose
+// funds have reached the balance. Anything else, including statuses Xendit adds later and the
+// SDK's unknown-enum fallback, is passed through as is: it says nothing about whether the
+// invoice was paid, so it must not be read as FAILED.
+func normalizeXenditStatus(status string) string {
+	switch status {
+	case "PAID", "SETTLED":
+		return domain.PaymentStatusPaid
+	case "EXPIRED":
+		return domain.PaymentStatusExpired
+	case "PENDING":
+		return domain.PaymentStatusPending
+	}
+	return status
+}
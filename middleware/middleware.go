@@ -9,140 +9,60 @@ import (
 	"gorm.io/gorm"
 )
 
-// role checking middleware
-func AdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		name := utils.GetAPIHitter(c)
-		role, exists := c.Get("role")
-		if !exists || role != domain.RoleAdmin {
-			utils.PrintLogInfo(&name, 403, "AdminOnly Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Admin access required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
+var permissionChecker domain.PermissionChecker
 
-func TeacherAndAdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		name := utils.GetAPIHitter(c)
-		role, exists := c.Get("role")
-		if !exists || role == domain.RoleStudent {
-			utils.PrintLogInfo(&name, 403, "Admin and Teacher only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Admin and Teacher access required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
-
-func StudentAndAdminOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		name := utils.GetAPIHitter(c)
-		role, exists := c.Get("role")
-		if !exists || role == domain.RoleTeacher {
-			utils.PrintLogInfo(&name, 403, "Admin and Student only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Admin and Student access required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
-
-func StudentOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		name := utils.GetAPIHitter(c)
-		role, _ := c.Get("role")
-		if role != domain.RoleStudent {
-			utils.PrintLogInfo(&name, 403, "Student only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Student access required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
+// InitPermissions sets the resolver RequirePermission uses to map roles to permissions.
+func InitPermissions(checker domain.PermissionChecker) {
+	permissionChecker = checker
 }
 
-func ManagerAndAdminOnly() gin.HandlerFunc {
+// RequirePermission lets the request through only when the caller's role grants the
+// permission, or for API keys, when the key carries it as a scope. It must run after
+// config.AuthMiddleware or config.AuthOrAPIKeyMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		name := utils.GetAPIHitter(c)
-		role, exists := c.Get("role")
-		if !exists {
-			utils.PrintLogInfo(&name, 403, "Admin and Manager only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Admin and Manager access required",
-			})
-			c.Abort()
+		if value, ok := c.Get("apiKey"); ok {
+			key, _ := value.(*domain.APIKey)
+			if key == nil || !key.HasScope(permission) {
+				utils.PrintLogInfo(&name, 403, "RequirePermission Middleware - API Key Scope "+permission, nil)
+				c.JSON(http.StatusForbidden, gin.H{
+					"success": false,
+					"message": "API key scope " + permission + " required",
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
 			return
 		}
 
-		// Check if role is either Admin or Manager
-		if role != domain.RoleAdmin && role != domain.RoleManagement {
-			utils.PrintLogInfo(&name, 403, "Admin and Manager only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Admin and Manager access required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
-
-func ManagerOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		name := utils.GetAPIHitter(c)
-		role, exists := c.Get("role")
-		if !exists {
-			utils.PrintLogInfo(&name, 403, "Manager only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
+		if permissionChecker == nil {
+			utils.PrintLogInfo(&name, 500, "RequirePermission Middleware - Not Initialized", nil)
+			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "Manager access required",
+				"message": "Permission checker not initialized",
 			})
 			c.Abort()
 			return
 		}
 
-		// Check if role is either Admin or Manager
-		if role != domain.RoleManagement {
-			utils.PrintLogInfo(&name, 403, "Manager only Middleware - Role Check", nil)
-			c.JSON(http.StatusForbidden, gin.H{
+		allowed, err := permissionChecker.HasPermission(c.Request.Context(), c.GetString("role"), permission)
+		if err != nil {
+			utils.PrintLogInfo(&name, 500, "RequirePermission Middleware - Resolve Permissions", &err)
+			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "Manager access required",
+				"message": "Gagal memeriksa izin akses",
+				"error":   err.Error(),
 			})
 			c.Abort()
 			return
 		}
-		c.Next()
-	}
-}
-
-func TeacherOnly() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		name := utils.GetAPIHitter(c)
-		role, exists := c.Get("role")
-		if !exists || role == domain.RoleStudent || role == domain.RoleManagement || role == domain.RoleAdmin {
-			utils.PrintLogInfo(&name, 403, "Teacher only Middleware - Role Check", nil)
+		if !allowed {
+			utils.PrintLogInfo(&name, 403, "RequirePermission Middleware - "+permission, nil)
 			c.JSON(http.StatusForbidden, gin.H{
 				"success": false,
-				"message": "Teacher access required",
+				"message": "Permission " + permission + " required",
 			})
 			c.Abort()
 			return
@@ -165,7 +85,8 @@ func ValidateTurnedOffUserMiddleware(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if role != domain.RoleTeacher && role != domain.RoleManagement {
+		// Teachers and back-office staff can be turned off; students and admins can't.
+		if role == domain.RoleStudent || role == domain.RoleAdmin {
 			c.Next()
 			return
 		}
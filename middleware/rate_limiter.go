@@ -2,6 +2,7 @@
 package middleware
 
 import (
+	"chronosphere/domain"
 	"context"
 	"fmt"
 	"net/http"
@@ -73,6 +74,18 @@ var rateLimitRules = map[string]RateLimitConfig{
 		Algorithm:   "fixed_window",
 		Scope:       "ip",
 	},
+	"auth_two_factor": {
+		MaxRequests: 10, // 10 2FA code attempts per 15 minutes
+		Window:      15 * time.Minute,
+		Algorithm:   "sliding_window",
+		Scope:       "ip",
+	},
+	"auth_oidc": {
+		MaxRequests: 20, // 20 OIDC login steps per 15 minutes
+		Window:      15 * time.Minute,
+		Algorithm:   "sliding_window",
+		Scope:       "ip",
+	},
 	"auth_refresh_token": {
 		MaxRequests: 30, // 30 token refreshes per minute
 		Window:      time.Minute,
@@ -198,6 +211,15 @@ var rateLimitRules = map[string]RateLimitConfig{
 		Scope:       "user",
 	},
 
+	// ==================== API KEYS ====================
+	// MaxRequests is overridden by each key's own limit, see APIKeyRateLimit
+	"api_key": {
+		MaxRequests: 120,
+		Window:      time.Minute,
+		Algorithm:   "sliding_window",
+		Scope:       "api_key",
+	},
+
 	// ==================== GLOBAL SAFEGUARDS ====================
 	"global_ip": {
 		MaxRequests: 1000, // 1000 total requests per IP per minute
@@ -245,6 +267,10 @@ func getRateLimitRule(path, method string) RateLimitConfig {
 		return rateLimitRules["auth_forgot_password"]
 	case strings.Contains(path, "/auth/resend-otp"):
 		return rateLimitRules["auth_resend_otp"]
+	case strings.Contains(path, "/auth/2fa/verify"):
+		return rateLimitRules["auth_two_factor"]
+	case strings.Contains(path, "/auth/oidc/"):
+		return rateLimitRules["auth_oidc"]
 	case strings.Contains(path, "/auth/refresh-token"):
 		return rateLimitRules["auth_refresh_token"]
 
@@ -321,6 +347,15 @@ func getIdentifier(c *gin.Context, scope string) string {
 		return fmt.Sprintf("ip:%s", c.ClientIP())
 	case "global":
 		return "global"
+	case "api_key":
+		// Set by the API key middleware once the key is authenticated
+		if key, exists := c.Get("apiKey"); exists {
+			if apiKey, ok := key.(*domain.APIKey); ok {
+				return fmt.Sprintf("api_key:%d", apiKey.ID)
+			}
+		}
+		// Fallback to IP if no key context
+		return fmt.Sprintf("ip:%s", c.ClientIP())
 	default: // "ip"
 		return fmt.Sprintf("ip:%s", c.ClientIP())
 	}
@@ -530,64 +565,95 @@ func RateLimiter() gin.HandlerFunc {
 		}
 
 		// Apply specific endpoint rate limiting
-		var allowed bool
-		var remaining int
-
-		switch rule.Algorithm {
-		case "fixed_window":
-			allowed, remaining, err = fixedWindowRateLimit(fullKey, rule)
-		case "token_bucket":
-			allowed, remaining, err = tokenBucketRateLimit(fullKey, rule)
-		default: // sliding_window
-			allowed, remaining, err = slidingWindowRateLimit(fullKey, rule)
-		}
-
-		if err != nil {
-			// Don't block requests if Redis fails
-			c.Next()
+		if !applyRateLimitRule(c, rule, fullKey, identifier, "RATE_LIMIT_EXCEEDED") {
 			return
 		}
 
-		if !allowed {
-			// Log the blocked request
-			logRateLimitBlock(c, rule, identifier)
+		c.Next()
+	}
+}
 
-			// Return appropriate error response
-			c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", rule.MaxRequests))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", fmt.Sprintf("%d",
-				time.Now().Add(rule.Window).Unix()))
+// applyRateLimitRule checks the rule against key and sets the rate limit headers. It
+// returns false after aborting with 429. Requests are let through if Redis fails.
+func applyRateLimitRule(c *gin.Context, rule RateLimitConfig, key, identifier, code string) bool {
+	var allowed bool
+	var remaining int
+	var err error
+
+	switch rule.Algorithm {
+	case "fixed_window":
+		allowed, remaining, err = fixedWindowRateLimit(key, rule)
+	case "token_bucket":
+		allowed, remaining, err = tokenBucketRateLimit(key, rule)
+	default: // sliding_window
+		allowed, remaining, err = slidingWindowRateLimit(key, rule)
+	}
 
-			if os.Getenv("APP_API_RETURN_LANG") == "IDN" {
-				c.JSON(http.StatusTooManyRequests, gin.H{
-					"error":       fmt.Sprintf("Permintaan terlalu sering, harap coba lagi dalam %v", rule.Window.String()),
-					"code":        "RATE_LIMIT_EXCEEDED",
-					"retry_after": int(rule.Window.Seconds()),
-					"limit":       rule.MaxRequests,
-					"window":      rule.Window.String(),
-				})
-			} else {
-				c.JSON(http.StatusTooManyRequests, gin.H{
-					"error":       fmt.Sprintf("Too many requests, please try again in %v", rule.Window.String()),
-					"code":        "RATE_LIMIT_EXCEEDED",
-					"retry_after": int(rule.Window.Seconds()),
-					"limit":       rule.MaxRequests,
-					"window":      rule.Window.String(),
-				})
-			}
+	if err != nil {
+		// Don't block requests if Redis fails
+		logRateLimitError(c, rule, identifier, err)
+		return true
+	}
 
-			c.Abort()
-			return
-		}
+	if !allowed {
+		// Log the blocked request
+		logRateLimitBlock(c, rule, identifier)
 
-		// Add rate limit headers
+		// Return appropriate error response
 		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", rule.MaxRequests))
-		c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+		c.Header("X-RateLimit-Remaining", "0")
 		c.Header("X-RateLimit-Reset", fmt.Sprintf("%d",
 			time.Now().Add(rule.Window).Unix()))
 
-		c.Next()
+		if os.Getenv("APP_API_RETURN_LANG") == "IDN" {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       fmt.Sprintf("Permintaan terlalu sering, harap coba lagi dalam %v", rule.Window.String()),
+				"code":        code,
+				"retry_after": int(rule.Window.Seconds()),
+				"limit":       rule.MaxRequests,
+				"window":      rule.Window.String(),
+			})
+		} else {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       fmt.Sprintf("Too many requests, please try again in %v", rule.Window.String()),
+				"code":        code,
+				"retry_after": int(rule.Window.Seconds()),
+				"limit":       rule.MaxRequests,
+				"window":      rule.Window.String(),
+			})
+		}
+
+		c.Abort()
+		return false
+	}
+
+	// Add rate limit headers
+	c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", rule.MaxRequests))
+	c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+	c.Header("X-RateLimit-Reset", fmt.Sprintf("%d",
+		time.Now().Add(rule.Window).Unix()))
+
+	return true
+}
+
+// APIKeyRateLimit applies the "api_key" rule with the key's own per-minute limit, counted
+// per key ID. The global RateLimiter runs before authentication and can't tell keys apart,
+// so the API key middleware calls this once it has stored the key under "apiKey". It
+// returns false after aborting with 429.
+func APIKeyRateLimit(c *gin.Context) bool {
+	if rdb == nil {
+		return true
 	}
+
+	rule := rateLimitRules["api_key"]
+	if key, exists := c.Get("apiKey"); exists {
+		if apiKey, ok := key.(*domain.APIKey); ok {
+			rule.MaxRequests = apiKey.EffectiveRateLimit()
+		}
+	}
+	identifier := getIdentifier(c, rule.Scope)
+
+	return applyRateLimitRule(c, rule, fmt.Sprintf("%s:%s", rule.Scope, identifier), identifier, "RATE_LIMIT_API_KEY")
 }
 
 // Log rate limit blocks for monitoring
@@ -598,6 +664,12 @@ func logRateLimitBlock(c *gin.Context, rule RateLimitConfig, identifier string)
 		c.Request.Method, c.Request.URL.Path, identifier, rule)
 }
 
+// Log Redis failures, since the request is let through unchecked
+func logRateLimitError(c *gin.Context, rule RateLimitConfig, identifier string, err error) {
+	fmt.Printf("[RATE_LIMIT] Redis error, request not limited: %s %s | Identifier: %s | Rule: %+v | Error: %v\n",
+		c.Request.Method, c.Request.URL.Path, identifier, rule, err)
+}
+
 // Admin endpoint to view rate limit status
 func RateLimitStatusHandler(c *gin.Context) {
 	// Only accessible to admins
@@ -11,6 +11,7 @@ import (
 
 	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type adminRepo struct {
@@ -312,47 +313,114 @@ func (r *adminRepo) UpdateInstrument(ctx context.Context, instrument *domain.Ins
 	return nil
 }
 
+// UpdatePackage applies an edit. Fields left empty keep their current value. When the
+// price or terms change, a new PackageVersion is recorded and becomes the one sold from now
+// on; purchases already made keep pointing at theirs.
 func (r *adminRepo) UpdatePackage(ctx context.Context, pkg *domain.Package) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing domain.Package
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", pkg.ID).
+			First(&existing).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("paket tidak ditemukan")
+			}
+			return errors.New(utils.TranslateDBError(err))
+		}
 
-	//check the name
-	var existing domain.Package
-	if err := r.db.WithContext(ctx).
-		Where("id = ? AND deleted_at IS NULL", pkg.ID).
-		First(&existing).Error; err != nil {
+		if pkg.Name != "" {
+			existing.Name = pkg.Name
+		}
+		if pkg.Price != 0 {
+			existing.Price = pkg.Price
+		}
+		if pkg.Quota != 0 {
+			existing.Quota = pkg.Quota
+		}
+		if pkg.ExpiredDuration != 0 {
+			existing.ExpiredDuration = pkg.ExpiredDuration
+		}
+		existing.Duration = pkg.Duration
+		existing.Description = pkg.Description
+		existing.InstrumentID = pkg.InstrumentID
+
+		//check the name
+		var nameExistStruct domain.Package
+		err := tx.Model(&domain.Package{}).Where("name = ? AND id != ? AND deleted_at IS NULL", existing.Name, existing.ID).First(&nameExistStruct).Error
+		if err == nil {
+			return errors.New("nama paket sudah digunakan")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(utils.TranslateDBError(err))
+		}
 
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("paket tidak ditemukan")
+		// check instrument id exists
+		var instrumentCount int64
+		err = tx.Model(&domain.Instrument{}).
+			Where("id = ? AND deleted_at IS NULL", existing.InstrumentID).
+			Count(&instrumentCount).Error
+		if err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+		if instrumentCount == 0 {
+			return errors.New("instrumen tidak ditemukan")
 		}
-		return errors.New(utils.TranslateDBError(err))
-	}
 
-	//check the name
-	var nameExistStruct domain.Package
-	err := r.db.WithContext(ctx).Model(&domain.Package{}).Where("name = ? AND id != ? AND deleted_at IS NULL", pkg.Name, pkg.ID).First(&nameExistStruct).Error
-	if err == nil {
+		var current domain.PackageVersion
+		hasCurrent := false
+		if existing.CurrentVersionID != nil {
+			if err := tx.First(&current, *existing.CurrentVersionID).Error; err == nil {
+				hasCurrent = true
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(utils.TranslateDBError(err))
+			}
+		}
 
-		return errors.New("nama paket sudah digunakan")
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return errors.New(utils.TranslateDBError(err))
+		if !hasCurrent || !current.SameTerms(&existing) {
+			if err := createPackageVersion(tx, &existing, existing.Version+1); err != nil {
+				return err
+			}
+		}
+
+		existing.Instrument = domain.Instrument{}
+		if err := tx.Omit("Instrument").Save(&existing).Error; err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+
+		*pkg = existing
+		return nil
+	})
+}
+
+// createPackageVersion snapshots the package as the given version and makes it current.
+// The caller saves the package afterwards.
+func createPackageVersion(tx *gorm.DB, pkg *domain.Package, version int) error {
+	snapshot := domain.NewPackageVersion(pkg, version)
+	if err := tx.Create(snapshot).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan versi paket: %w", err)
 	}
+	pkg.Version = version
+	pkg.CurrentVersionID = &snapshot.ID
+	return nil
+}
 
-	// check instrument id exists
-	var instrumentCount int64
-	err = r.db.WithContext(ctx).Model(&domain.Instrument{}).
-		Where("id = ? AND deleted_at IS NULL", pkg.InstrumentID).
-		Count(&instrumentCount).Error
-	if err != nil {
-		return errors.New(utils.TranslateDBError(err))
+func (r *adminRepo) GetPackageVersions(ctx context.Context, packageID int) ([]domain.PackageVersion, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&domain.Package{}).Where("id = ?", packageID).Count(&count).Error; err != nil {
+		return nil, errors.New(utils.TranslateDBError(err))
 	}
-	if instrumentCount == 0 {
-		return errors.New("instrumen tidak ditemukan")
+	if count == 0 {
+		return nil, errors.New("paket tidak ditemukan")
 	}
 
-	if err := r.db.WithContext(ctx).Save(pkg).Error; err != nil {
-		return errors.New(utils.TranslateDBError(err))
+	var versions []domain.PackageVersion
+	if err := r.db.WithContext(ctx).
+		Where("package_id = ?", packageID).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, errors.New(utils.TranslateDBError(err))
 	}
-
-	return nil
+	return versions, nil
 }
 
 // AssignPackageToStudent assigns a package to a student
@@ -414,11 +482,12 @@ func (r *adminRepo) AssignPackageToStudent(ctx context.Context, studentUUID stri
 
 	// 5️⃣ Assign new package
 	newSub := domain.StudentPackage{
-		StudentUUID:    studentUUID,
-		PackageID:      packageID,
-		RemainingQuota: pkg.Quota,
-		StartDate:      time.Now(),
-		EndDate:        time.Now().AddDate(0, 0, pkg.ExpiredDuration),
+		StudentUUID:      studentUUID,
+		PackageID:        packageID,
+		RemainingQuota:   pkg.Quota,
+		StartDate:        time.Now(),
+		EndDate:          time.Now().AddDate(0, 0, pkg.ExpiredDuration),
+		PackageVersionID: pkg.CurrentVersionID,
 	}
 
 	if err := tx.Create(&newSub).Error; err != nil {
@@ -451,8 +520,17 @@ func (r *adminRepo) CreatePackage(ctx context.Context, pkg *domain.Package) (*do
 		return nil, errors.New("instrumen tidak ditemukan")
 	}
 
-	if err := r.db.WithContext(ctx).Create(pkg).Error; err != nil {
-		return nil, errors.New(utils.TranslateDBError(err))
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pkg).Error; err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+		if err := createPackageVersion(tx, pkg, 1); err != nil {
+			return err
+		}
+		return tx.Model(pkg).Updates(map[string]interface{}{"version": pkg.Version, "current_version_id": pkg.CurrentVersionID}).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	pkg.Instrument.ID = pkg.InstrumentID
@@ -528,6 +606,42 @@ func (r *adminRepo) GetAllStudents(ctx context.Context) ([]domain.User, error) {
 	return students, err
 }
 
+// CreateStudent creates a student account together with its (empty) student profile.
+func (r *adminRepo) CreateStudent(ctx context.Context, user *domain.User) (*domain.User, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&domain.User{}).
+			Where("email = ? OR phone = ?", user.Email, user.Phone).
+			Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check user: %w", err)
+		}
+		if existing > 0 {
+			return errors.New("email atau nomor telepon sudah digunakan")
+		}
+
+		user.TeacherProfile = nil
+		user.StudentProfile = nil
+		if user.Image == nil || *user.Image == "" {
+			defImage := os.Getenv("DEFAULT_PROFILE_IMAGE")
+			user.Image = &defImage
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		profile := domain.StudentProfile{UserUUID: user.UUID}
+		if err := tx.Create(&profile).Error; err != nil {
+			return err
+		}
+		user.StudentProfile = &profile
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // GetStudentByUUID fetches a student by UUID
 func (r *adminRepo) GetStudentByUUID(ctx context.Context, uuid string) (*domain.User, error) {
 	var student domain.User
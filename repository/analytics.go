@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// revenueStatuses are the payment statuses that still carry revenue.
+var revenueStatuses = []string{domain.PaymentStatusPaid, domain.PaymentStatusPartiallyRefunded}
+
+// firstPurchasesCTE gives each student's first revenue-carrying purchase, which decides
+// whether a buyer is new or returning.
+const firstPurchasesCTE = `first_purchases AS (
+	SELECT student_uuid, MIN(paid_at) AS first_paid_at
+	FROM payments
+	WHERE status IN @statuses AND paid_at IS NOT NULL
+	GROUP BY student_uuid
+)`
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) domain.AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+func (r *analyticsRepository) GetRevenueTotals(ctx context.Context, from, to time.Time) (*domain.RevenueTotals, error) {
+	var totals domain.RevenueTotals
+	err := r.db.WithContext(ctx).Raw(`WITH `+firstPurchasesCTE+`
+		SELECT COALESCE(SUM(p.amount - p.refunded_amount), 0) AS revenue,
+			COUNT(p.id) AS orders,
+			COUNT(DISTINCT p.student_uuid) AS buyers,
+			COUNT(DISTINCT p.student_uuid) FILTER (WHERE fp.first_paid_at >= @from) AS new_buyers
+		FROM payments p
+		JOIN first_purchases fp ON fp.student_uuid = p.student_uuid
+		WHERE p.status IN @statuses AND p.paid_at >= @from AND p.paid_at < @to`,
+		map[string]interface{}{"statuses": revenueStatuses, "from": from, "to": to}).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate revenue totals: %w", err)
+	}
+	return &totals, nil
+}
+
+// GetRevenueSeries returns only the buckets that have sales; Period is the local bucket start.
+func (r *analyticsRepository) GetRevenueSeries(ctx context.Context, from, to time.Time, interval string) ([]domain.RevenueBucket, error) {
+	var buckets []domain.RevenueBucket
+	err := r.db.WithContext(ctx).Raw(`WITH `+firstPurchasesCTE+`
+		SELECT date_trunc(@interval, p.paid_at AT TIME ZONE @tz) AS period,
+			COALESCE(SUM(p.amount - p.refunded_amount), 0) AS revenue,
+			COUNT(p.id) AS orders,
+			COUNT(DISTINCT p.student_uuid) FILTER (
+				WHERE date_trunc(@interval, fp.first_paid_at AT TIME ZONE @tz) = date_trunc(@interval, p.paid_at AT TIME ZONE @tz)
+			) AS new_buyers,
+			COUNT(DISTINCT p.student_uuid) FILTER (
+				WHERE date_trunc(@interval, fp.first_paid_at AT TIME ZONE @tz) < date_trunc(@interval, p.paid_at AT TIME ZONE @tz)
+			) AS returning_buyers
+		FROM payments p
+		JOIN first_purchases fp ON fp.student_uuid = p.student_uuid
+		WHERE p.status IN @statuses AND p.paid_at >= @from AND p.paid_at < @to
+		GROUP BY period
+		ORDER BY period ASC`,
+		map[string]interface{}{
+			"statuses": revenueStatuses,
+			"from":     from,
+			"to":       to,
+			"interval": interval,
+			"tz":       domain.AnalyticsTimezone,
+		}).
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revenue series: %w", err)
+	}
+	return buckets, nil
+}
+
+func (r *analyticsRepository) paidBetween(ctx context.Context, from, to time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&domain.Payment{}).
+		Where("payments.status IN ? AND payments.paid_at >= ? AND payments.paid_at < ?", revenueStatuses, from, to)
+}
+
+func (r *analyticsRepository) GetRevenueByPackage(ctx context.Context, from, to time.Time) ([]domain.RevenueByPackage, error) {
+	var rows []domain.RevenueByPackage
+	err := r.paidBetween(ctx, from, to).
+		Select("packages.id AS package_id, packages.name AS package_name, COUNT(payments.id) AS orders, COALESCE(SUM(payments.amount - payments.refunded_amount), 0) AS revenue").
+		Joins("JOIN packages ON packages.id = payments.package_id").
+		Group("packages.id, packages.name").
+		Order("revenue DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revenue by package: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *analyticsRepository) GetRevenueByInstrument(ctx context.Context, from, to time.Time) ([]domain.RevenueByInstrument, error) {
+	var rows []domain.RevenueByInstrument
+	err := r.paidBetween(ctx, from, to).
+		Select("instruments.id AS instrument_id, instruments.name AS instrument_name, COUNT(payments.id) AS orders, COALESCE(SUM(payments.amount - payments.refunded_amount), 0) AS revenue").
+		Joins("JOIN packages ON packages.id = payments.package_id").
+		Joins("JOIN instruments ON instruments.id = packages.instrument_id").
+		Group("instruments.id, instruments.name").
+		Order("revenue DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revenue by instrument: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *analyticsRepository) GetRevenueByChannel(ctx context.Context, from, to time.Time) ([]domain.RevenueByChannel, error) {
+	var rows []domain.RevenueByChannel
+	err := r.paidBetween(ctx, from, to).
+		Select(`payments.source AS source,
+			COALESCE(NULLIF(payments.payment_method, ''), 'UNKNOWN') AS payment_method,
+			COALESCE(payments.payment_channel, '') AS payment_channel,
+			COUNT(payments.id) AS orders,
+			COALESCE(SUM(payments.amount - payments.refunded_amount), 0) AS revenue`).
+		Group("1, 2, 3").
+		Order("revenue DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revenue by channel: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) domain.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create api key: %w", err)
+		}
+
+		rows := make([]domain.APIKeyScope, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			rows = append(rows, domain.APIKeyScope{APIKeyID: key.ID, Scope: scope})
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to save api key scopes: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	if err := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch api keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return keys, nil
+	}
+
+	ids := make([]int, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.ID)
+	}
+	var scopes []domain.APIKeyScope
+	if err := r.db.WithContext(ctx).Where("api_key_id IN ?", ids).Order("scope ASC").Find(&scopes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch api key scopes: %w", err)
+	}
+
+	byKey := make(map[int][]string, len(keys))
+	for _, scope := range scopes {
+		byKey[scope.APIKeyID] = append(byKey[scope.APIKeyID], scope.Scope)
+	}
+	for i := range keys {
+		keys[i].Scopes = byKey[keys[i].ID]
+		if keys[i].Scopes == nil {
+			keys[i].Scopes = []string{}
+		}
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+
+	key.Scopes = []string{}
+	err = r.db.WithContext(ctx).Model(&domain.APIKeyScope{}).
+		Where("api_key_id = ?", key.ID).
+		Order("scope ASC").
+		Pluck("scope", &key.Scopes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch api key scopes: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("API key tidak ditemukan")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch api key: %w", err)
+		}
+		if key.RevokedAt != nil {
+			return errors.New("API key sudah dicabut")
+		}
+
+		if err := tx.Model(&key).Update("revoked_at", at).Error; err != nil {
+			return fmt.Errorf("failed to revoke api key: %w", err)
+		}
+		key.RevokedAt = &at
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// TouchLastUsed records a use of the key, skipping the write when the last one is more
+// recent than domain.APIKeyTouchInterval so busy keys don't write on every request.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int, ipAddress string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-domain.APIKeyTouchInterval)).
+		UpdateColumns(map[string]interface{}{
+			"last_used_at": at,
+			"last_used_ip": ipAddress,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) domain.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to save audit log: %w", err)
+	}
+	return nil
+}
+
+func (r *auditRepository) GetAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, int64, error) {
+	var logs []domain.AuditLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+	if filter.ActorUUID != "" {
+		query = query.Where("actor_uuid = ?", filter.ActorUUID)
+	}
+	if filter.APIKey != "" {
+		query = query.Where("actor_api_key = ?", filter.APIKey)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.StartDate != "" {
+		query = query.Where("DATE(created_at) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(created_at) <= ?", filter.EndDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch audit logs: %w", err)
+	}
+
+	return logs, total, nil
+}
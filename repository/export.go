@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type exportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) domain.ExportRepository {
+	return &exportRepository{db: db}
+}
+
+func (r *exportRepository) paymentsQuery(ctx context.Context, filter domain.ExportFilter) *gorm.DB {
+	return filterPaymentHistory(r.db.WithContext(ctx).Model(&domain.Payment{}), filter.StartDate, filter.EndDate, filter.Status, filter.Source)
+}
+
+func (r *exportRepository) classHistoriesQuery(ctx context.Context, filter domain.ExportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&domain.ClassHistory{}).
+		Joins("LEFT JOIN bookings ON class_histories.booking_id = bookings.id")
+	if filter.StartDate != "" {
+		query = query.Where("DATE(bookings.class_date) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(bookings.class_date) <= ?", filter.EndDate)
+	}
+	if filter.Status != "" {
+		query = query.Where("class_histories.status = ?", filter.Status)
+	}
+	return query
+}
+
+// usersQuery matches the JSON lists: students default to active accounts only, teachers
+// include deleted ones unless a status is given.
+func (r *exportRepository) usersQuery(ctx context.Context, role string, filter domain.ExportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&domain.User{}).Where("role = ?", role)
+
+	status := filter.Status
+	if status == "" && role == domain.RoleStudent {
+		status = "active"
+	}
+	switch status {
+	case "active":
+		query = query.Where("deleted_at IS NULL")
+	case "deleted":
+		query = query.Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.StartDate != "" {
+		query = query.Where("DATE(created_at) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(created_at) <= ?", filter.EndDate)
+	}
+	return query
+}
+
+func (r *exportRepository) CountRows(ctx context.Context, report string, filter domain.ExportFilter) (int64, error) {
+	var query *gorm.DB
+	switch report {
+	case domain.ExportReportPayments:
+		query = r.paymentsQuery(ctx, filter)
+	case domain.ExportReportClassHistories:
+		query = r.classHistoriesQuery(ctx, filter)
+	case domain.ExportReportStudents:
+		query = r.usersQuery(ctx, domain.RoleStudent, filter)
+	case domain.ExportReportTeachers:
+		query = r.usersQuery(ctx, domain.RoleTeacher, filter)
+	default:
+		return 0, fmt.Errorf("unknown report %s", report)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", report, err)
+	}
+	return count, nil
+}
+
+func (r *exportRepository) StreamPayments(ctx context.Context, filter domain.ExportFilter, fn func(batch []domain.Payment) error) error {
+	var batch []domain.Payment
+	err := r.paymentsQuery(ctx, filter).
+		Preload("Student").
+		Preload("Package").
+		FindInBatches(&batch, domain.ExportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to export payments: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) StreamClassHistories(ctx context.Context, filter domain.ExportFilter, fn func(batch []domain.ClassHistory) error) error {
+	var batch []domain.ClassHistory
+	err := r.classHistoriesQuery(ctx, filter).
+		Preload("Booking").
+		Preload("Booking.Schedule").
+		Preload("Booking.Schedule.Teacher").
+		Preload("Booking.Student").
+		Preload("Booking.PackageUsed").
+		Preload("Booking.PackageUsed.Package").
+		Preload("Booking.PackageUsed.Package.Instrument").
+		Preload("Documentations").
+		FindInBatches(&batch, domain.ExportBatchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to export class histories: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) StreamUsers(ctx context.Context, role string, filter domain.ExportFilter, fn func(batch []domain.User) error) error {
+	var batch []domain.User
+	query := r.usersQuery(ctx, role, filter)
+	if role == domain.RoleTeacher {
+		query = query.Preload("TeacherProfile.Instruments")
+	}
+	err := query.FindInBatches(&batch, domain.ExportBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) CreateJob(ctx context.Context, job *domain.ExportJob) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) UpdateJob(ctx context.Context, job *domain.ExportJob) error {
+	if err := r.db.WithContext(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update export job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (r *exportRepository) GetJob(ctx context.Context, id int) (*domain.ExportJob, error) {
+	var job domain.ExportJob
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("export tidak ditemukan")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch export job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *exportRepository) GetJobsByRequester(ctx context.Context, requestedBy string, limit int) ([]domain.ExportJob, error) {
+	var jobs []domain.ExportJob
+	if err := r.db.WithContext(ctx).
+		Where("requested_by = ?", requestedBy).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch export jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// FailInterruptedJobs marks jobs that were queued or running when the app stopped as failed;
+// they will never finish on their own.
+func (r *exportRepository) FailInterruptedJobs(ctx context.Context) (int64, error) {
+	msg := "export terhenti karena server dimulai ulang"
+	result := r.db.WithContext(ctx).Model(&domain.ExportJob{}).
+		Where("status IN ?", []string{domain.ExportJobQueued, domain.ExportJobRunning}).
+		Updates(map[string]interface{}{"status": domain.ExportJobFailed, "error": msg, "completed_at": time.Now()})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to close interrupted export jobs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *exportRepository) GetExpiredJobFiles(ctx context.Context, now time.Time) ([]domain.ExportJob, error) {
+	var jobs []domain.ExportJob
+	if err := r.db.WithContext(ctx).
+		Where("file_key IS NOT NULL AND expires_at < ?", now).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch expired export jobs: %w", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type fileRepository struct {
+	db *gorm.DB
+}
+
+func NewFileRepository(db *gorm.DB) domain.FileRepository {
+	return &fileRepository{db: db}
+}
+
+func (r *fileRepository) Create(ctx context.Context, file *domain.StoredFile) error {
+	if err := r.db.WithContext(ctx).Create(file).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan data file: %w", err)
+	}
+	return nil
+}
+
+func (r *fileRepository) GetByKey(ctx context.Context, key string) (*domain.StoredFile, error) {
+	var file domain.StoredFile
+	if err := r.db.WithContext(ctx).Where("key = ?", key).First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("file tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data file: %w", err)
+	}
+	return &file, nil
+}
+
+func (r *fileRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithContext(ctx).Delete(&domain.StoredFile{}, id).Error
+}
+
+func (r *fileRepository) UpdateUserImage(ctx context.Context, userUUID, url string) error {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("uuid = ? AND deleted_at IS NULL", userUUID).
+		Update("image", url)
+	if result.Error != nil {
+		return fmt.Errorf("gagal memperbarui foto profil: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user tidak ditemukan")
+	}
+	return nil
+}
+
+// IsDocumentationParticipant matches documentation URLs on the file path rather than the
+// whole URL, so links saved under another FILE_PUBLIC_BASE_URL or with a signed query
+// still count.
+func (r *fileRepository) IsDocumentationParticipant(ctx context.Context, key, userUUID string) (bool, error) {
+	path := domain.FileRoutePrefix + key
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("class_documentations AS cd").
+		Joins("JOIN class_histories ch ON ch.id = cd.class_history_id").
+		Joins("JOIN bookings b ON b.id = ch.booking_id").
+		Joins("JOIN teacher_schedules ts ON ts.id = b.schedule_id").
+		Where("RIGHT(SPLIT_PART(cd.url, '?', 1), ?) = ?", len(path), path).
+		Where("b.student_uuid = ? OR ts.teacher_uuid = ?", userUUID, userUUID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("gagal memeriksa akses dokumentasi: %w", err)
+	}
+	return count > 0, nil
+}
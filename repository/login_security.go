@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginSecurityRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginSecurityRepository(db *gorm.DB) domain.LoginSecurityRepository {
+	return &loginSecurityRepository{db: db}
+}
+
+func (r *loginSecurityRepository) CreateHistory(ctx context.Context, entry *domain.LoginHistory) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to save login history: %w", err)
+	}
+	return nil
+}
+
+func (r *loginSecurityRepository) GetLoginHistory(ctx context.Context, filter domain.LoginHistoryFilter) ([]domain.LoginHistory, int64, error) {
+	var history []domain.LoginHistory
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.LoginHistory{})
+	if filter.UserUUID != "" {
+		query = query.Where("user_uuid = ?", filter.UserUUID)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", strings.ToLower(filter.Email))
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", strings.ToUpper(filter.Result))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count login history: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&history).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch login history: %w", err)
+	}
+
+	return history, total, nil
+}
+
+func (r *loginSecurityRepository) GetKnownLogins(ctx context.Context, userUUID, ipAddress, userAgent string) (*domain.KnownLogins, error) {
+	var known domain.KnownLogins
+	err := r.db.WithContext(ctx).Model(&domain.LoginHistory{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE ip_address = ?) AS same_ip,
+			COUNT(*) FILTER (WHERE user_agent = ?) AS same_device`, ipAddress, userAgent).
+		Where("user_uuid = ? AND result = ?", userUUID, domain.LoginResultSuccess).
+		Scan(&known).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check login history: %w", err)
+	}
+	return &known, nil
+}
+
+func (r *loginSecurityRepository) GetLockout(ctx context.Context, userUUID string) (*domain.AccountLockout, error) {
+	var lockout domain.AccountLockout
+	if err := r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).First(&lockout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch lockout: %w", err)
+	}
+	return &lockout, nil
+}
+
+func (r *loginSecurityRepository) UpdateLockout(ctx context.Context, userUUID string, update func(*domain.AccountLockout)) (*domain.AccountLockout, error) {
+	var lockout domain.AccountLockout
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Parallel failures for the same account serialise on the row lock.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&domain.AccountLockout{UserUUID: userUUID}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_uuid = ?", userUUID).
+			First(&lockout).Error; err != nil {
+			return err
+		}
+
+		update(&lockout)
+		return tx.Save(&lockout).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update lockout: %w", err)
+	}
+	return &lockout, nil
+}
+
+func (r *loginSecurityRepository) DeleteLockout(ctx context.Context, userUUID string) error {
+	if err := r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).Delete(&domain.AccountLockout{}).Error; err != nil {
+		return fmt.Errorf("failed to delete lockout: %w", err)
+	}
+	return nil
+}
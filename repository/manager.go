@@ -107,9 +107,9 @@ func (r *managerRepo) GetStudentByUUID(ctx context.Context, uuid string) (*domai
 	return &student, nil
 }
 
-func (r *managerRepo) ModifyStudentPackageQuota(ctx context.Context, studentUUID string, packageID int, incomingQuota int) (*domain.User, error) {
+func (r *managerRepo) ModifyStudentPackageQuota(ctx context.Context, studentUUID string, packageID int, incomingQuota int) (*domain.User, int, error) {
 	if incomingQuota > 50 {
-		return nil, fmt.Errorf("quota cannot exceed 50")
+		return nil, 0, fmt.Errorf("quota cannot exceed 50")
 	}
 
 	// First, find the student package directly
@@ -120,9 +120,9 @@ func (r *managerRepo) ModifyStudentPackageQuota(ctx context.Context, studentUUID
 		Where("student_uuid = ? AND package_id = ? AND end_date >= ?", studentUUID, packageID, time.Now()).
 		First(&studentPackage).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("active package not found for this student")
+			return nil, 0, fmt.Errorf("active package not found for this student")
 		}
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Verify the student exists and has the correct role
@@ -130,10 +130,11 @@ func (r *managerRepo) ModifyStudentPackageQuota(ctx context.Context, studentUUID
 	if err := r.db.WithContext(ctx).
 		Where("uuid = ? AND role = ? AND deleted_at IS NULL", studentUUID, domain.RoleStudent).
 		First(&student).Error; err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Update the remaining quota
+	previousQuota := studentPackage.RemainingQuota
 	studentPackage.RemainingQuota = incomingQuota
 
 	// Ensure remaining quota doesn't go negative
@@ -144,7 +145,7 @@ func (r *managerRepo) ModifyStudentPackageQuota(ctx context.Context, studentUUID
 	// Save the student package
 	err := r.db.WithContext(ctx).Save(&studentPackage).Error
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Now query the full student data with all relationships
@@ -164,8 +165,8 @@ func (r *managerRepo) ModifyStudentPackageQuota(ctx context.Context, studentUUID
 		// Main where clause
 		Where("uuid = ? AND role = ? AND deleted_at IS NULL", studentUUID, domain.RoleStudent).
 		First(&fullStudent).Error; err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return &fullStudent, nil
+	return &fullStudent, previousQuota, nil
 }
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+type oidcRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+// NewOIDCRepository stores linked identities in the database and the short-lived login
+// state and pending signups in Redis.
+func NewOIDCRepository(db *gorm.DB, redisClient *redis.Client) domain.OIDCRepository {
+	return &oidcRepository{db: db, redis: redisClient}
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
+
+func oidcSignupKey(token string) string {
+	return "oidc:signup:" + token
+}
+
+func (r *oidcRepository) SaveLoginState(ctx context.Context, state string, data domain.OIDCLoginState, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode OIDC login state: %w", err)
+	}
+	if err := r.redis.Set(ctx, oidcStateKey(state), payload, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save OIDC login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState returns the state and deletes it in one step, so a callback can't be
+// replayed.
+func (r *oidcRepository) ConsumeLoginState(ctx context.Context, state string) (*domain.OIDCLoginState, error) {
+	payload, err := r.redis.GetDel(ctx, oidcStateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC login state: %w", err)
+	}
+
+	var data domain.OIDCLoginState
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode OIDC login state: %w", err)
+	}
+	return &data, nil
+}
+
+// oidcSignupRecord is what is kept of a pending signup, including the identity the JSON
+// form of domain.OIDCSignup leaves out.
+type oidcSignupRecord struct {
+	Email     string              `json:"email"`
+	Name      string              `json:"name"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	Identity  domain.OIDCIdentity `json:"identity"`
+}
+
+func (r *oidcRepository) SaveSignup(ctx context.Context, signup *domain.OIDCSignup, ttl time.Duration) error {
+	payload, err := json.Marshal(oidcSignupRecord{
+		Email:     signup.Email,
+		Name:      signup.Name,
+		ExpiresAt: signup.ExpiresAt,
+		Identity:  signup.Identity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode OIDC signup: %w", err)
+	}
+	if err := r.redis.Set(ctx, oidcSignupKey(signup.Token), payload, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save OIDC signup: %w", err)
+	}
+	return nil
+}
+
+func (r *oidcRepository) GetSignup(ctx context.Context, token string) (*domain.OIDCSignup, error) {
+	payload, err := r.redis.Get(ctx, oidcSignupKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signup: %w", err)
+	}
+
+	var record oidcSignupRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode OIDC signup: %w", err)
+	}
+	return &domain.OIDCSignup{
+		Token:     token,
+		Email:     record.Email,
+		Name:      record.Name,
+		ExpiresAt: record.ExpiresAt,
+		Identity:  record.Identity,
+	}, nil
+}
+
+func (r *oidcRepository) DeleteSignup(ctx context.Context, token string) error {
+	if err := r.redis.Del(ctx, oidcSignupKey(token)).Err(); err != nil {
+		return fmt.Errorf("failed to delete OIDC signup: %w", err)
+	}
+	return nil
+}
+
+func (r *oidcRepository) GetIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	err := r.db.WithContext(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user identity: %w", err)
+	}
+	return &identity, nil
+}
+
+func (r *oidcRepository) CreateIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	if err := r.db.WithContext(ctx).Create(identity).Error; err != nil {
+		return fmt.Errorf("failed to link user identity: %w", err)
+	}
+	return nil
+}
+
+func (r *oidcRepository) TouchIdentity(ctx context.Context, issuer, subject string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&domain.UserIdentity{}).
+		Where("issuer = ? AND subject = ?", issuer, subject).
+		Update("last_login_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+	return nil
+}
+
+// FindUserByEmail ignores case: older accounts may have been stored with mixed-case emails.
+func (r *oidcRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return &user, nil
+}
+
+// CreateStudentWithIdentity creates the student account of a first OIDC login together
+// with its student profile and the identity link.
+func (r *oidcRepository) CreateStudentWithIdentity(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&domain.User{}).
+			Where("LOWER(email) = LOWER(?) OR phone = ?", user.Email, user.Phone).
+			Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check user: %w", err)
+		}
+		if existing > 0 {
+			return errors.New("email atau nomor telepon sudah digunakan")
+		}
+
+		user.TeacherProfile = nil
+		user.StudentProfile = nil
+		if user.Image == nil || *user.Image == "" {
+			defImage := os.Getenv("DEFAULT_PROFILE_IMAGE")
+			user.Image = &defImage
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create student: %w", err)
+		}
+		if err := tx.Create(&domain.StudentProfile{UserUUID: user.UUID}).Error; err != nil {
+			return fmt.Errorf("failed to create student profile: %w", err)
+		}
+
+		identity.UserUUID = user.UUID
+		if err := tx.Create(identity).Error; err != nil {
+			return fmt.Errorf("failed to link user identity: %w", err)
+		}
+		return nil
+	})
+}
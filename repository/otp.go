@@ -3,7 +3,6 @@ package repository
 import (
 	"chronosphere/domain"
 	"context"
-	"log"
 	"strings"
 	"time"
 
@@ -21,18 +20,21 @@ func NewOTPRedisRepository(redisClient *redis.Client) domain.OTPRepository {
 func (r *otpRedisRepository) SaveOTP(ctx context.Context, email, otp, hashedPassword, name, phone, gender string, ttl time.Duration) error {
 	key := "otp:" + email
 
-	data := map[string]string{
+	data := map[string]interface{}{
 		"otp":      strings.TrimSpace(otp),
 		"password": strings.TrimSpace(hashedPassword),
 		"name":     strings.TrimSpace(name),
 		"phone":    strings.TrimSpace(phone),
 		"gender":   strings.TrimSpace(gender),
+		"attempts": 0,
 	}
 
-	if err := r.client.HSet(ctx, key, data).Err(); err != nil {
-		return err
-	}
-	if err := r.client.Expire(ctx, key, ttl).Err(); err != nil {
+	// Replace the whole record so a new code also starts with a fresh attempt counter.
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, data)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return err
 	}
 	return nil
@@ -51,24 +53,67 @@ func (r *otpRedisRepository) GetOTP(ctx context.Context, email string) (map[stri
 	return data, nil
 }
 
+// verifyOTPScript compares the code and counts the failure in one step, so parallel
+// guesses can't get past the attempt limit. Returns 1 on a match, 0 when there is no OTP,
+// -1 for a wrong code and -2 for the wrong code that used up the last attempt (the OTP is
+// deleted).
+var verifyOTPScript = redis.NewScript(`
+local stored = redis.call('HGET', KEYS[1], 'otp')
+if not stored then
+	return 0
+end
+if stored == ARGV[1] then
+	return 1
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1])
+	return -2
+end
+return -1
+`)
+
 func (r *otpRedisRepository) VerifyOTP(ctx context.Context, email, otp string) (map[string]string, bool, error) {
 	key := "otp:" + email
-	vals, err := r.client.HGetAll(ctx, key).Result()
+	result, err := verifyOTPScript.Run(ctx, r.client, []string{key}, strings.TrimSpace(otp), domain.OTPMaxAttempts).Int()
 	if err != nil {
 		return nil, false, err
 	}
-	if len(vals) == 0 {
-		return nil, false, nil
-	}
 
-	if vals["otp"] != otp {
+	switch result {
+	case 1:
+		vals, err := r.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(vals) == 0 {
+			return nil, false, nil
+		}
+		return vals, true, nil
+	case -2:
+		return nil, false, domain.ErrOTPTooManyAttempts
+	default:
 		return nil, false, nil
 	}
-
-	log.Println("DEBUG VerifyOTP raw password from Redis:", vals["password"])
-	return vals, true, nil
 }
 
 func (r *otpRedisRepository) DeleteOTP(ctx context.Context, email string) error {
 	return r.client.Del(ctx, "otp:"+email).Err()
 }
+
+func (r *otpRedisRepository) AcquireResendSlot(ctx context.Context, email string, cooldown time.Duration) error {
+	key := "otp_cooldown:" + email
+	acquired, err := r.client.SetNX(ctx, key, 1, cooldown).Result()
+	if err != nil {
+		return err
+	}
+	if acquired {
+		return nil
+	}
+
+	remaining, err := r.client.PTTL(ctx, key).Result()
+	if err != nil || remaining <= 0 {
+		remaining = cooldown
+	}
+	return &domain.OTPCooldownError{RetryAfter: remaining}
+}
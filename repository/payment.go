@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type paymentRepository struct {
@@ -63,24 +64,65 @@ func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment)
 
 func (r *paymentRepository) UpdateStatus(ctx context.Context, externalID string, status string, paidAt *time.Time) (*domain.Payment, error) {
 	var payment domain.Payment
-	err := r.db.WithContext(ctx).Where("external_id = ?", externalID).First(&payment).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("payment not found")
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locked so a concurrent activation can't be turned back into EXPIRED or FAILED.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("external_id = ?", externalID).
+			First(&payment).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("payment not found")
+			}
+			return err
+		}
+		if payment.Status != domain.PaymentStatusPending {
+			return domain.ErrPaymentNotPending
+		}
+
+		payment.Status = status
+		if paidAt != nil {
+			payment.PaidAt = paidAt
+		}
+
+		if err := tx.Save(&payment).Error; err != nil {
+			return fmt.Errorf("failed to update payment status: %w", err)
+		}
+
+		// An invoice that will never be paid gives its voucher use back.
+		if status == domain.PaymentStatusExpired || status == domain.PaymentStatusFailed {
+			return releaseVoucherRedemption(tx, payment.ID)
 		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	payment.Status = status
-	if paidAt != nil {
-		payment.PaidAt = paidAt
-	}
+	return &payment, nil
+}
 
-	if err := r.db.WithContext(ctx).Save(&payment).Error; err != nil {
-		return nil, fmt.Errorf("failed to update payment status: %w", err)
+// releaseVoucherRedemption frees a reserved voucher use held by the payment, if any.
+func releaseVoucherRedemption(tx *gorm.DB, paymentID int) error {
+	var redemption domain.VoucherRedemption
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("payment_id = ? AND status = ?", paymentID, domain.RedemptionReserved).
+		First(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch voucher redemption: %w", err)
 	}
 
-	return &payment, nil
+	if err := tx.Model(&redemption).Update("status", domain.RedemptionReleased).Error; err != nil {
+		return fmt.Errorf("failed to release voucher redemption: %w", err)
+	}
+	if err := tx.Model(&domain.Voucher{}).
+		Where("id = ? AND redeemed_count > 0", redemption.VoucherID).
+		UpdateColumn("redeemed_count", gorm.Expr("redeemed_count - 1")).Error; err != nil {
+		return fmt.Errorf("failed to release voucher redemption: %w", err)
+	}
+	return nil
 }
 
 func (r *paymentRepository) FindByExternalID(ctx context.Context, externalID string) (*domain.Payment, error) {
@@ -88,6 +130,7 @@ func (r *paymentRepository) FindByExternalID(ctx context.Context, externalID str
 	err := r.db.WithContext(ctx).
 		Preload("Student").
 		Preload("Package").
+		Preload("PackageVersion").
 		Where("external_id = ?", externalID).
 		First(&payment).Error
 	if err != nil {
@@ -99,11 +142,164 @@ func (r *paymentRepository) FindByExternalID(ctx context.Context, externalID str
 	return &payment, nil
 }
 
-// GetTotalProfit calculates the total revenue from paid packages
+func (r *paymentRepository) FindByID(ctx context.Context, id int) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Student").
+		Preload("Package").
+		Preload("Package.Instrument").
+		Preload("PackageVersion").
+		First(&payment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("pembayaran tidak ditemukan")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch payment: %w", err)
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetPaymentsByStudent(ctx context.Context, studentUUID string, filter domain.StudentPaymentFilter) ([]domain.Payment, int64, error) {
+	var payments []domain.Payment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.Payment{}).Where("student_uuid = ?", studentUUID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count payments: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Preload("Package").
+		Preload("Package.Instrument").
+		Preload("PackageVersion").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&payments).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch payments: %w", err)
+	}
+
+	return payments, total, nil
+}
+
+func (r *paymentRepository) CancelPending(ctx context.Context, id int) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("pembayaran tidak ditemukan")
+			}
+			return err
+		}
+		if payment.Status != domain.PaymentStatusPending {
+			return fmt.Errorf("hanya pembayaran berstatus PENDING yang dapat dibatalkan (status saat ini: %s)", payment.Status)
+		}
+
+		if err := tx.Model(&payment).Update("status", domain.PaymentStatusCancelled).Error; err != nil {
+			return fmt.Errorf("failed to cancel payment: %w", err)
+		}
+		return releaseVoucherRedemption(tx, payment.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// GetStalePendingPayments returns PENDING payments created before the cutoff, oldest first.
+// Payments with an open discrepancy other than a gateway error need an admin and are
+// skipped; those with an open gateway error are retried after the rest, so they can't
+// crowd newer payments out of the batch.
+func (r *paymentRepository) GetStalePendingPayments(ctx context.Context, createdBefore time.Time, limit int) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", domain.PaymentStatusPending, createdBefore).
+		Where(`NOT EXISTS (SELECT 1 FROM payment_discrepancies d
+			WHERE d.payment_id = payments.id AND d.resolved = false AND d.type <> ?)`, domain.DiscrepancyGatewayError).
+		Order(`EXISTS (SELECT 1 FROM payment_discrepancies d
+			WHERE d.payment_id = payments.id AND d.resolved = false) ASC`).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&payments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stale pending payments: %w", err)
+	}
+	return payments, nil
+}
+
+func (r *paymentRepository) FindByIdempotencyKey(ctx context.Context, studentUUID, key string) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).
+		Where("student_uuid = ? AND idempotency_key = ?", studentUUID, key).
+		First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch payment by idempotency key: %w", err)
+	}
+	return &payment, nil
+}
+
+// openInvoiceExpiry is when a pending invoice stops being payable. Payments created before
+// expiry was recorded fall back to the gateway's default invoice lifetime.
+var openInvoiceExpiry = fmt.Sprintf("COALESCE(expires_at, created_at + INTERVAL '%d seconds')", int(domain.DefaultInvoiceDuration.Seconds()))
+
+// FindReusablePending returns the newest PENDING invoice for the same student, package
+// version and voucher that is still payable at validUntil. An invoice issued before the
+// package was edited carries the old price, so it is not reused. A checkout created after
+// inFlightSince is returned even without an invoice URL, since its invoice is on the way.
+func (r *paymentRepository) FindReusablePending(ctx context.Context, studentUUID string, packageID int, packageVersionID *int, voucherCode *string, validUntil, inFlightSince time.Time) (*domain.Payment, error) {
+	query := r.db.WithContext(ctx).
+		Where("student_uuid = ? AND package_id = ? AND status = ?", studentUUID, packageID, domain.PaymentStatusPending).
+		Where("invoice_url <> '' OR created_at > ?", inFlightSince).
+		Where(openInvoiceExpiry+" > ?", validUntil)
+	if packageVersionID == nil {
+		query = query.Where("package_version_id IS NULL")
+	} else {
+		query = query.Where("package_version_id = ?", *packageVersionID)
+	}
+	if voucherCode == nil {
+		query = query.Where("voucher_code IS NULL")
+	} else {
+		query = query.Where("voucher_code = ?", *voucherCode)
+	}
+
+	var payment domain.Payment
+	err := query.Order("created_at DESC").First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending payment: %w", err)
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) CountOpenInvoices(ctx context.Context, studentUUID string, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Payment{}).
+		Where("student_uuid = ? AND status = ?", studentUUID, domain.PaymentStatusPending).
+		Where(openInvoiceExpiry+" > ?", now).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count open invoices: %w", err)
+	}
+	return count, nil
+}
+
+// GetTotalProfit calculates the total revenue from paid packages, net of refunds
 func (r *paymentRepository) GetTotalProfit(ctx context.Context, filter domain.ProfitFilter) (float64, error) {
 	var total float64
 	query := r.db.WithContext(ctx).Model(&domain.Payment{}).
-		Where("status = ?", domain.PaymentStatusPaid)
+		Where("status IN ?", []string{domain.PaymentStatusPaid, domain.PaymentStatusPartiallyRefunded})
 
 	if filter.StartDate != "" {
 		query = query.Where("DATE(paid_at) >= ?", filter.StartDate)
@@ -112,31 +308,40 @@ func (r *paymentRepository) GetTotalProfit(ctx context.Context, filter domain.Pr
 		query = query.Where("DATE(paid_at) <= ?", filter.EndDate)
 	}
 
-	err := query.Select("COALESCE(SUM(amount), 0)").Scan(&total).Error
+	err := query.Select("COALESCE(SUM(amount - refunded_amount), 0)").Scan(&total).Error
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate profit: %w", err)
 	}
 	return total, nil
 }
 
+// filterPaymentHistory applies the payment history filters; the export uses it too, so both
+// return the same payments.
+func filterPaymentHistory(query *gorm.DB, startDate, endDate, status, source string) *gorm.DB {
+	if startDate != "" {
+		query = query.Where("DATE(created_at) >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("DATE(created_at) <= ?", endDate)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	return query
+}
+
 // GetPaymentHistory retrieves payment history with pagination and filters
 func (r *paymentRepository) GetPaymentHistory(ctx context.Context, filter domain.HistoryFilter) ([]domain.Payment, int64, error) {
 	var payments []domain.Payment
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&domain.Payment{}).
+	query := filterPaymentHistory(r.db.WithContext(ctx).Model(&domain.Payment{}), filter.StartDate, filter.EndDate, filter.Status, filter.Source).
 		Preload("Student").
-		Preload("Package")
-
-	if filter.StartDate != "" {
-		query = query.Where("DATE(created_at) >= ?", filter.StartDate)
-	}
-	if filter.EndDate != "" {
-		query = query.Where("DATE(created_at) <= ?", filter.EndDate)
-	}
-	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
-	}
+		Preload("Package").
+		Preload("PackageVersion")
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count payments: %w", err)
@@ -159,9 +364,12 @@ func (r *paymentRepository) GetPackageSummary(ctx context.Context) ([]domain.Pac
 	var summaries []domain.PackageSummary
 
 	err := r.db.WithContext(ctx).Model(&domain.Payment{}).
-		Select("packages.name as package_name, COUNT(payments.id) as total_sold, SUM(payments.amount) as total_revenue").
+		Select(`packages.name as package_name, COUNT(payments.id) as total_sold, SUM(payments.amount - payments.refunded_amount) as total_revenue,
+			COUNT(payments.id) FILTER (WHERE payments.source = ?) as offline_sold,
+			COALESCE(SUM(payments.amount - payments.refunded_amount) FILTER (WHERE payments.source = ?), 0) as offline_revenue`,
+			domain.PaymentSourceOffline, domain.PaymentSourceOffline).
 		Joins("JOIN packages ON packages.id = payments.package_id").
-		Where("payments.status = ?", domain.PaymentStatusPaid).
+		Where("payments.status IN ?", []string{domain.PaymentStatusPaid, domain.PaymentStatusPartiallyRefunded}).
 		Group("packages.id, packages.name").
 		Scan(&summaries).Error
 
@@ -171,3 +379,27 @@ func (r *paymentRepository) GetPackageSummary(ctx context.Context) ([]domain.Pac
 
 	return summaries, nil
 }
+
+// GetRevenueByMethod breaks net revenue down by source (gateway/offline) and payment method
+func (r *paymentRepository) GetRevenueByMethod(ctx context.Context, filter domain.ProfitFilter) ([]domain.RevenueByMethod, error) {
+	var rows []domain.RevenueByMethod
+	query := r.db.WithContext(ctx).Model(&domain.Payment{}).
+		Where("status IN ?", []string{domain.PaymentStatusPaid, domain.PaymentStatusPartiallyRefunded})
+
+	if filter.StartDate != "" {
+		query = query.Where("DATE(paid_at) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(paid_at) <= ?", filter.EndDate)
+	}
+
+	err := query.
+		Select("source, COALESCE(NULLIF(payment_method, ''), 'UNKNOWN') as payment_method, COUNT(id) as total_count, COALESCE(SUM(amount - refunded_amount), 0) as total_revenue").
+		Group("source, COALESCE(NULLIF(payment_method, ''), 'UNKNOWN')").
+		Order("total_revenue DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revenue by method: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+)
+
+type payrollRepository struct {
+	db *gorm.DB
+}
+
+func NewPayrollRepository(db *gorm.DB) domain.PayrollRepository {
+	return &payrollRepository{db: db}
+}
+
+func (r *payrollRepository) validateRateScope(ctx context.Context, rate *domain.TeacherPayRate) error {
+	if rate.TeacherUUID != nil {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&domain.User{}).
+			Where("uuid = ? AND role = ?", *rate.TeacherUUID, domain.RoleTeacher).
+			Count(&count).Error; err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+		if count == 0 {
+			return errors.New("guru tidak ditemukan")
+		}
+	}
+
+	if rate.InstrumentID != nil {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&domain.Instrument{}).
+			Where("id = ? AND deleted_at IS NULL", *rate.InstrumentID).
+			Count(&count).Error; err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+		if count == 0 {
+			return errors.New("instrumen tidak ditemukan")
+		}
+	}
+
+	// Two live rules with the exact same scope would make the winner ambiguous.
+	query := r.db.WithContext(ctx).Model(&domain.TeacherPayRate{}).
+		Where("deleted_at IS NULL AND id != ?", rate.ID)
+	if rate.TeacherUUID != nil {
+		query = query.Where("teacher_uuid = ?", *rate.TeacherUUID)
+	} else {
+		query = query.Where("teacher_uuid IS NULL")
+	}
+	if rate.InstrumentID != nil {
+		query = query.Where("instrument_id = ?", *rate.InstrumentID)
+	} else {
+		query = query.Where("instrument_id IS NULL")
+	}
+	if rate.PackageDuration != nil {
+		query = query.Where("package_duration = ?", *rate.PackageDuration)
+	} else {
+		query = query.Where("package_duration IS NULL")
+	}
+
+	var duplicates int64
+	if err := query.Count(&duplicates).Error; err != nil {
+		return errors.New(utils.TranslateDBError(err))
+	}
+	if duplicates > 0 {
+		return errors.New("tarif dengan cakupan yang sama sudah ada")
+	}
+
+	return nil
+}
+
+func (r *payrollRepository) CreatePayRate(ctx context.Context, rate *domain.TeacherPayRate) (*domain.TeacherPayRate, error) {
+	if err := r.validateRateScope(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Create(rate).Error; err != nil {
+		return nil, errors.New(utils.TranslateDBError(err))
+	}
+	return rate, nil
+}
+
+func (r *payrollRepository) GetAllPayRates(ctx context.Context) ([]domain.TeacherPayRate, error) {
+	var rates []domain.TeacherPayRate
+	if err := r.db.WithContext(ctx).
+		Preload("Teacher").
+		Preload("Instrument").
+		Where("deleted_at IS NULL").
+		Order("id ASC").
+		Find(&rates).Error; err != nil {
+		return nil, errors.New(utils.TranslateDBError(err))
+	}
+	return rates, nil
+}
+
+func (r *payrollRepository) UpdatePayRate(ctx context.Context, rate *domain.TeacherPayRate) error {
+	var existing domain.TeacherPayRate
+	if err := r.db.WithContext(ctx).
+		Where("id = ? AND deleted_at IS NULL", rate.ID).
+		First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("tarif tidak ditemukan")
+		}
+		return errors.New(utils.TranslateDBError(err))
+	}
+
+	if err := r.validateRateScope(ctx, rate); err != nil {
+		return err
+	}
+
+	rate.CreatedAt = existing.CreatedAt
+	if err := r.db.WithContext(ctx).Omit("Teacher", "Instrument").Save(rate).Error; err != nil {
+		return errors.New(utils.TranslateDBError(err))
+	}
+	return nil
+}
+
+func (r *payrollRepository) DeletePayRate(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Model(&domain.TeacherPayRate{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", time.Now())
+	if result.Error != nil {
+		return errors.New(utils.TranslateDBError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("tarif tidak ditemukan")
+	}
+	return nil
+}
+
+// GetUnpaidLessons returns every class history in the period that is not yet on a payslip.
+func (r *payrollRepository) GetUnpaidLessons(ctx context.Context, start, end time.Time) ([]domain.PayableLesson, error) {
+	var lessons []domain.PayableLesson
+	err := r.db.WithContext(ctx).
+		Table("class_histories AS ch").
+		Select(`ch.id AS class_history_id,
+			b.id AS booking_id,
+			ch.status AS history_status,
+			ts.teacher_uuid AS teacher_uuid,
+			t.name AS teacher_name,
+			b.student_uuid AS student_uuid,
+			s.name AS student_name,
+			p.instrument_id AS instrument_id,
+			i.name AS instrument_name,
+			p.duration AS duration,
+			b.class_date AS class_date,
+			b.canceled_by AS canceled_by,
+			b.cancelled_at AS cancelled_at`).
+		Joins("JOIN bookings b ON b.id = ch.booking_id").
+		Joins("JOIN teacher_schedules ts ON ts.id = b.schedule_id").
+		Joins("JOIN users t ON t.uuid = ts.teacher_uuid").
+		Joins("JOIN users s ON s.uuid = b.student_uuid").
+		Joins("JOIN student_packages sp ON sp.id = b.student_package_id").
+		Joins("JOIN packages p ON p.id = sp.package_id").
+		Joins("JOIN instruments i ON i.id = p.instrument_id").
+		Where("DATE(b.class_date) >= ? AND DATE(b.class_date) <= ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Where("NOT EXISTS (SELECT 1 FROM payslip_items pi WHERE pi.class_history_id = ch.id)").
+		Order("t.name ASC, b.class_date ASC").
+		Scan(&lessons).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unpaid lessons: %w", err)
+	}
+	return lessons, nil
+}
+
+func (r *payrollRepository) CreatePayrollRun(ctx context.Context, run *domain.PayrollRun) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialise payroll runs so two admins can't lock the same lessons concurrently.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('payroll_run'))").Error; err != nil {
+			return err
+		}
+		return tx.Omit("Payslips.Teacher").Create(run).Error
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("sebagian kelas sudah dibayarkan pada payroll lain, silakan hitung ulang")
+		}
+		return fmt.Errorf("gagal menyimpan payroll: %w", err)
+	}
+	return nil
+}
+
+func (r *payrollRepository) GetAllPayrollRuns(ctx context.Context) ([]domain.PayrollRun, error) {
+	var runs []domain.PayrollRun
+	if err := r.db.WithContext(ctx).
+		Order("period_start DESC, id DESC").
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch payroll runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (r *payrollRepository) GetPayrollRunByID(ctx context.Context, id int) (*domain.PayrollRun, error) {
+	var run domain.PayrollRun
+	err := r.db.WithContext(ctx).
+		Preload("Payslips", func(db *gorm.DB) *gorm.DB {
+			return db.Order("payslips.id ASC")
+		}).
+		Preload("Payslips.Teacher").
+		Preload("Payslips.Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("payslip_items.class_date ASC")
+		}).
+		First(&run, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("payroll tidak ditemukan")
+		}
+		return nil, fmt.Errorf("failed to fetch payroll run: %w", err)
+	}
+	return &run, nil
+}
+
+func (r *payrollRepository) GetPayslipsByTeacher(ctx context.Context, teacherUUID string) ([]domain.Payslip, error) {
+	var payslips []domain.Payslip
+	if err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("payslip_items.class_date ASC")
+		}).
+		Where("teacher_uuid = ?", teacherUUID).
+		Order("created_at DESC").
+		Find(&payslips).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch payslips: %w", err)
+	}
+	return payslips, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ratingRepository struct {
+	db *gorm.DB
+}
+
+func NewRatingRepository(db *gorm.DB) domain.RatingRepository {
+	return &ratingRepository{db: db}
+}
+
+func (r *ratingRepository) GetClassHistoryForRating(ctx context.Context, classHistoryID int) (*domain.ClassHistory, error) {
+	var history domain.ClassHistory
+	err := r.db.WithContext(ctx).
+		Preload("Booking").
+		Preload("Booking.Schedule").
+		Preload("Booking.Schedule.Teacher").
+		Preload("Booking.Student").
+		Preload("Booking.PackageUsed.Package.Instrument").
+		Where("id = ?", classHistoryID).
+		First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("riwayat kelas tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil riwayat kelas: %w", err)
+	}
+	return &history, nil
+}
+
+func (r *ratingRepository) CreateRating(ctx context.Context, rating *domain.ClassRating) error {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&domain.ClassRating{}).
+		Where("class_history_id = ?", rating.ClassHistoryID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("gagal memeriksa penilaian: %w", err)
+	}
+	if count > 0 {
+		return errors.New("kelas ini sudah dinilai")
+	}
+
+	if err := r.db.WithContext(ctx).Create(rating).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan penilaian: %w", err)
+	}
+	return nil
+}
+
+func (r *ratingRepository) GetRatingsByStudent(ctx context.Context, studentUUID string) ([]domain.ClassRating, error) {
+	var ratings []domain.ClassRating
+	if err := r.db.WithContext(ctx).
+		Where("student_uuid = ?", studentUUID).
+		Order("created_at DESC").
+		Find(&ratings).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch ratings: %w", err)
+	}
+	return ratings, nil
+}
+
+func (r *ratingRepository) GetTeacherRatingSummary(ctx context.Context, teacherUUID string, commentLimit int) (*domain.TeacherRatingSummary, error) {
+	var rows []struct {
+		Rating int
+		Total  int64
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&domain.ClassRating{}).
+		Select("rating, COUNT(*) AS total").
+		Where("teacher_uuid = ?", teacherUUID).
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch rating distribution: %w", err)
+	}
+
+	summary := &domain.TeacherRatingSummary{
+		Distribution:   make(map[int]int64, domain.RatingMax),
+		RecentComments: []domain.RatingComment{},
+	}
+	for i := domain.RatingMin; i <= domain.RatingMax; i++ {
+		summary.Distribution[i] = 0
+	}
+
+	var sum int64
+	for _, row := range rows {
+		summary.Distribution[row.Rating] = row.Total
+		summary.TotalRatings += row.Total
+		sum += int64(row.Rating) * row.Total
+	}
+	if summary.TotalRatings > 0 {
+		summary.AverageRating = float64(sum) / float64(summary.TotalRatings)
+	}
+
+	var comments []domain.ClassRating
+	if err := r.db.WithContext(ctx).
+		Select("rating, comment, created_at").
+		Where("teacher_uuid = ? AND comment IS NOT NULL AND comment <> ''", teacherUUID).
+		Order("created_at DESC").
+		Limit(commentLimit).
+		Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch rating comments: %w", err)
+	}
+
+	for _, c := range comments {
+		// Only the date is exposed so a comment can't be matched to a specific lesson slot.
+		y, m, d := c.CreatedAt.Date()
+		summary.RecentComments = append(summary.RecentComments, domain.RatingComment{
+			Rating:    c.Rating,
+			Comment:   *c.Comment,
+			CreatedAt: time.Date(y, m, d, 0, 0, 0, 0, c.CreatedAt.Location()),
+		})
+	}
+
+	return summary, nil
+}
+
+func (r *ratingRepository) GetTeacherRatingAverages(ctx context.Context, filter domain.RatingFilter, lowThreshold int) ([]domain.TeacherRatingAverage, error) {
+	var averages []domain.TeacherRatingAverage
+
+	query := r.db.WithContext(ctx).
+		Model(&domain.ClassRating{}).
+		Select(`class_ratings.teacher_uuid AS teacher_uuid,
+			users.name AS teacher_name,
+			COALESCE(AVG(class_ratings.rating), 0) AS average_rating,
+			COUNT(class_ratings.id) AS total_ratings,
+			SUM(CASE WHEN class_ratings.rating <= ? THEN 1 ELSE 0 END) AS low_ratings`, lowThreshold).
+		Joins("JOIN users ON users.uuid = class_ratings.teacher_uuid")
+
+	if filter.StartDate != "" {
+		query = query.Where("DATE(class_ratings.created_at) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(class_ratings.created_at) <= ?", filter.EndDate)
+	}
+
+	if err := query.
+		Group("class_ratings.teacher_uuid, users.name").
+		Order("average_rating ASC").
+		Scan(&averages).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch teacher rating averages: %w", err)
+	}
+
+	return averages, nil
+}
+
+func (r *ratingRepository) GetTeacherRatingTrend(ctx context.Context, teacherUUID string, filter domain.RatingFilter) ([]domain.RatingTrendPoint, error) {
+	period := filter.Period
+	if period == "" {
+		period = "month"
+	}
+
+	var points []domain.RatingTrendPoint
+	query := r.db.WithContext(ctx).
+		Model(&domain.ClassRating{}).
+		Select("date_trunc(?, created_at) AS period, AVG(rating) AS average_rating, COUNT(*) AS total_ratings", period).
+		Where("teacher_uuid = ?", teacherUUID)
+
+	if filter.StartDate != "" {
+		query = query.Where("DATE(created_at) >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("DATE(created_at) <= ?", filter.EndDate)
+	}
+
+	if err := query.Group("period").Order("period ASC").Scan(&points).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch rating trend: %w", err)
+	}
+
+	return points, nil
+}
+
+func (r *ratingRepository) GetActiveManagers(ctx context.Context) ([]domain.User, error) {
+	var managers []domain.User
+	if err := r.db.WithContext(ctx).
+		Where("role = ? AND deleted_at IS NULL", domain.RoleManagement).
+		Find(&managers).Error; err != nil {
+		return nil, err
+	}
+	return managers, nil
+}
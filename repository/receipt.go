@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type receiptRepository struct {
+	db *gorm.DB
+}
+
+func NewReceiptRepository(db *gorm.DB) domain.ReceiptRepository {
+	return &receiptRepository{db: db}
+}
+
+func (r *receiptRepository) GetOrCreate(ctx context.Context, paymentID int, issuedAt time.Time) (*domain.Receipt, error) {
+	var receipt domain.Receipt
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialise numbering so sequences stay gap-free and unique.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('receipt_number'))").Error; err != nil {
+			return fmt.Errorf("gagal mengunci nomor kwitansi: %w", err)
+		}
+
+		err := tx.Where("payment_id = ?", paymentID).First(&receipt).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("gagal mengambil kwitansi: %w", err)
+		}
+
+		var last int
+		if err := tx.Model(&domain.Receipt{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return fmt.Errorf("gagal mengambil nomor kwitansi terakhir: %w", err)
+		}
+
+		receipt = domain.Receipt{
+			PaymentID: paymentID,
+			Sequence:  last + 1,
+			Number:    fmt.Sprintf("RCP-%d-%06d", issuedAt.Year(), last+1),
+			IssuedAt:  issuedAt,
+		}
+		if err := tx.Create(&receipt).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan kwitansi: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+func (r *receiptRepository) GetPaymentForReceipt(ctx context.Context, paymentID int) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Student").
+		Preload("Package").
+		Preload("Package.Instrument").
+		Preload("PackageVersion").
+		First(&payment, paymentID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pembayaran tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil pembayaran: %w", err)
+	}
+	return &payment, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) domain.ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) CreateRun(ctx context.Context, run *domain.ReconciliationRun) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(run).Error; err != nil {
+			return err
+		}
+		for i := range run.Items {
+			run.Items[i].RunID = run.ID
+		}
+		if len(run.Items) == 0 {
+			return nil
+		}
+		// Matches idx_payment_discrepancies_open: one open row per payment and type.
+		return tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "payment_id"}, {Name: "type"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "resolved = false"}}},
+			DoNothing:   true,
+		}).Create(&run.Items).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store reconciliation run: %w", err)
+	}
+	return nil
+}
+
+func (r *reconciliationRepository) ResolveDiscrepancies(ctx context.Context, paymentID int, kind string) error {
+	err := r.db.WithContext(ctx).Model(&domain.PaymentDiscrepancy{}).
+		Where("payment_id = ? AND type = ? AND resolved = false", paymentID, kind).
+		Update("resolved", true).Error
+	if err != nil {
+		return fmt.Errorf("failed to resolve discrepancies: %w", err)
+	}
+	return nil
+}
+
+func (r *reconciliationRepository) GetRuns(ctx context.Context, page, limit int) ([]domain.ReconciliationRun, int64, error) {
+	var runs []domain.ReconciliationRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.ReconciliationRun{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count reconciliation runs: %w", err)
+	}
+
+	if err := query.Order("started_at DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch reconciliation runs: %w", err)
+	}
+	return runs, total, nil
+}
+
+func (r *reconciliationRepository) GetRunByID(ctx context.Context, id int) (*domain.ReconciliationRun, error) {
+	var run domain.ReconciliationRun
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("payment_discrepancies.id ASC")
+		}).
+		First(&run, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("rekonsiliasi tidak ditemukan")
+		}
+		return nil, fmt.Errorf("failed to fetch reconciliation run: %w", err)
+	}
+	return &run, nil
+}
+
+func (r *reconciliationRepository) GetDiscrepancies(ctx context.Context, filter domain.DiscrepancyFilter) ([]domain.PaymentDiscrepancy, int64, error) {
+	var items []domain.PaymentDiscrepancy
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.PaymentDiscrepancy{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Resolved != nil {
+		query = query.Where("resolved = ?", *filter.Resolved)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count discrepancies: %w", err)
+	}
+
+	if err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch discrepancies: %w", err)
+	}
+	return items, total, nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type refreshTokenRedisRepository struct {
+	client *redis.Client
+}
+
+func NewRefreshTokenRedisRepository(redisClient *redis.Client) domain.RefreshTokenRepository {
+	return &refreshTokenRedisRepository{client: redisClient}
+}
+
+func refreshFamilyKey(familyID string) string {
+	return "refresh:family:" + familyID
+}
+
+// refreshUserKey indexes a user's families. Entries of expired families are removed
+// lazily when the sessions are listed.
+func refreshUserKey(userUUID string) string {
+	return "refresh:user:" + userUUID
+}
+
+// rotateScript swaps the current token ID atomically, so two refreshes with the same token
+// can't both succeed. Returns 1 on success, 0 for an unknown family, -1 on reuse (the
+// family is deleted).
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'current_token_id')
+if not current then
+	return 0
+end
+if current ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[6])
+	return -1
+end
+redis.call('HSET', KEYS[1], 'current_token_id', ARGV[2], 'last_used_at', ARGV[3], 'ip_address', ARGV[5])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+return 1
+`)
+
+func (r *refreshTokenRedisRepository) CreateFamily(ctx context.Context, family *domain.RefreshTokenFamily, ttl time.Duration) error {
+	key := refreshFamilyKey(family.FamilyID)
+	userKey := refreshUserKey(family.UserUUID)
+	data := map[string]interface{}{
+		"user_uuid":        family.UserUUID,
+		"current_token_id": family.CurrentTokenID,
+		"user_agent":       family.UserAgent,
+		"ip_address":       family.IPAddress,
+		"created_at":       family.CreatedAt.Unix(),
+		"last_used_at":     family.LastUsedAt.Unix(),
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, data)
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, userKey, family.FamilyID)
+	pipe.Expire(ctx, userKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRedisRepository) Rotate(ctx context.Context, userUUID, familyID, tokenID, newTokenID, ipAddress string, now time.Time, ttl time.Duration) error {
+	result, err := rotateScript.Run(ctx, r.client,
+		[]string{refreshFamilyKey(familyID), refreshUserKey(userUUID)},
+		tokenID, newTokenID, strconv.FormatInt(now.Unix(), 10), int64(ttl.Seconds()), ipAddress, familyID,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	switch result {
+	case 1:
+		return nil
+	case -1:
+		return domain.ErrRefreshTokenReused
+	default:
+		return domain.ErrRefreshTokenRevoked
+	}
+}
+
+func parseRefreshFamily(familyID string, data map[string]string) *domain.RefreshTokenFamily {
+	createdAt, _ := strconv.ParseInt(data["created_at"], 10, 64)
+	lastUsedAt, _ := strconv.ParseInt(data["last_used_at"], 10, 64)
+	return &domain.RefreshTokenFamily{
+		FamilyID:       familyID,
+		UserUUID:       data["user_uuid"],
+		CurrentTokenID: data["current_token_id"],
+		UserAgent:      data["user_agent"],
+		IPAddress:      data["ip_address"],
+		CreatedAt:      time.Unix(createdAt, 0),
+		LastUsedAt:     time.Unix(lastUsedAt, 0),
+	}
+}
+
+func (r *refreshTokenRedisRepository) GetFamily(ctx context.Context, familyID string) (*domain.RefreshTokenFamily, error) {
+	data, err := r.client.HGetAll(ctx, refreshFamilyKey(familyID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return parseRefreshFamily(familyID, data), nil
+}
+
+func (r *refreshTokenRedisRepository) FamilyExists(ctx context.Context, familyID string) (bool, error) {
+	n, err := r.client.Exists(ctx, refreshFamilyKey(familyID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return n == 1, nil
+}
+
+// GetFamiliesByUser returns the user's live sessions, most recently used first.
+func (r *refreshTokenRedisRepository) GetFamiliesByUser(ctx context.Context, userUUID string) ([]domain.RefreshTokenFamily, error) {
+	userKey := refreshUserKey(userUUID)
+	familyIDs, err := r.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	if len(familyIDs) == 0 {
+		return []domain.RefreshTokenFamily{}, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(familyIDs))
+	for i, id := range familyIDs {
+		cmds[i] = pipe.HGetAll(ctx, refreshFamilyKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	families := make([]domain.RefreshTokenFamily, 0, len(familyIDs))
+	var stale []interface{}
+	for i, cmd := range cmds {
+		data := cmd.Val()
+		if len(data) == 0 {
+			stale = append(stale, familyIDs[i])
+			continue
+		}
+		families = append(families, *parseRefreshFamily(familyIDs[i], data))
+	}
+	if len(stale) > 0 {
+		r.client.SRem(ctx, userKey, stale...)
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].LastUsedAt.After(families[j].LastUsedAt)
+	})
+	return families, nil
+}
+
+func (r *refreshTokenRedisRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	key := refreshFamilyKey(familyID)
+	userUUID, err := r.client.HGet(ctx, key, "user_uuid").Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if userUUID != "" {
+		pipe.SRem(ctx, refreshUserKey(userUUID), familyID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRedisRepository) RevokeUserFamilies(ctx context.Context, userUUID, exceptFamilyID string) (int, error) {
+	userKey := refreshUserKey(userUUID)
+	familyIDs, err := r.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	var dels []*redis.IntCmd
+	for _, id := range familyIDs {
+		if id == exceptFamilyID {
+			continue
+		}
+		dels = append(dels, pipe.Del(ctx, refreshFamilyKey(id)))
+		pipe.SRem(ctx, userKey, id)
+	}
+	if len(dels) == 0 {
+		return 0, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	revoked := 0
+	for _, cmd := range dels {
+		revoked += int(cmd.Val())
+	}
+	return revoked, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) domain.RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) GetRefundsByPayment(ctx context.Context, paymentID int) ([]domain.PaymentRefund, error) {
+	var refunds []domain.PaymentRefund
+	if err := r.db.WithContext(ctx).
+		Where("payment_id = ?", paymentID).
+		Order("created_at ASC").
+		Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch refunds: %w", err)
+	}
+	return refunds, nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) domain.RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) GetRoles(ctx context.Context) ([]domain.Role, error) {
+	var roles []domain.Role
+	if err := r.db.WithContext(ctx).Order("is_system DESC, name ASC").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+
+	permissions, err := r.GetAllRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		Role  string
+		Total int64
+	}
+	err = r.db.WithContext(ctx).Model(&domain.User{}).
+		Select("role, COUNT(*) AS total").
+		Group("role").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users per role: %w", err)
+	}
+	countByRole := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		countByRole[c.Role] = c.Total
+	}
+
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+		roles[i].UserCount = countByRole[roles[i].Name]
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	var role domain.Role
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch role: %w", err)
+	}
+
+	role.Permissions = []string{}
+	err = r.db.WithContext(ctx).Model(&domain.RolePermission{}).
+		Where("role_name = ?", name).
+		Order("permission ASC").
+		Pluck("permission", &role.Permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch role permissions: %w", err)
+	}
+
+	if err := r.db.WithContext(ctx).Model(&domain.User{}).Where("role = ?", name).Count(&role.UserCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count role users: %w", err)
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) GetAllRolePermissions(ctx context.Context) (map[string][]string, error) {
+	var rows []domain.RolePermission
+	if err := r.db.WithContext(ctx).Order("role_name ASC, permission ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch role permissions: %w", err)
+	}
+
+	byRole := make(map[string][]string)
+	for _, row := range rows {
+		byRole[row.RoleName] = append(byRole[row.RoleName], row.Permission)
+	}
+	return byRole, nil
+}
+
+func (r *roleRepository) CreateRole(ctx context.Context, role *domain.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&domain.Role{}).Where("name = ?", role.Name).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check role: %w", err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("role %s sudah ada", role.Name)
+		}
+
+		if err := tx.Create(role).Error; err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		return replaceRolePermissions(tx, role.Name, role.Permissions)
+	})
+}
+
+func (r *roleRepository) UpdateRole(ctx context.Context, role *domain.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Role{}).
+			Where("name = ?", role.Name).
+			Updates(map[string]interface{}{
+				"display_name": role.DisplayName,
+				"description":  role.Description,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update role: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("role tidak ditemukan")
+		}
+		return replaceRolePermissions(tx, role.Name, role.Permissions)
+	})
+}
+
+func replaceRolePermissions(tx *gorm.DB, roleName string, permissions []string) error {
+	if err := tx.Where("role_name = ?", roleName).Delete(&domain.RolePermission{}).Error; err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	rows := make([]domain.RolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		rows = append(rows, domain.RolePermission{RoleName: roleName, Permission: permission})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to save role permissions: %w", err)
+	}
+	return nil
+}
+
+func (r *roleRepository) DeleteRole(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role domain.Role
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&role).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("role tidak ditemukan")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch role: %w", err)
+		}
+
+		var users int64
+		if err := tx.Model(&domain.User{}).Where("role = ?", name).Count(&users).Error; err != nil {
+			return fmt.Errorf("failed to count role users: %w", err)
+		}
+		if users > 0 {
+			return fmt.Errorf("role masih digunakan oleh %d pengguna", users)
+		}
+
+		if err := tx.Where("role_name = ?", name).Delete(&domain.RolePermission{}).Error; err != nil {
+			return fmt.Errorf("failed to delete role permissions: %w", err)
+		}
+		if err := tx.Delete(&role).Error; err != nil {
+			return fmt.Errorf("failed to delete role: %w", err)
+		}
+		return nil
+	})
+}
+
+// AssignUserRole moves a back-office user to another role. Students and teachers keep
+// their role because their profiles hang off it, an admin is only demoted when allowAdmin
+// is set, and the last active admin can't be demoted.
+func (r *roleRepository) AssignUserRole(ctx context.Context, userUUID, role string, allowAdmin bool) (string, error) {
+	var previous string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user domain.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uuid = ?", userUUID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("pengguna tidak ditemukan")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch user: %w", err)
+		}
+
+		if user.Role == domain.RoleStudent || user.Role == domain.RoleTeacher {
+			return fmt.Errorf("role %s tidak dapat diubah", user.Role)
+		}
+		if user.Role == role {
+			return fmt.Errorf("pengguna sudah memiliki role %s", role)
+		}
+
+		if user.Role == domain.RoleAdmin {
+			if !allowAdmin {
+				return errors.New("hanya admin yang dapat memberikan atau mencabut role admin")
+			}
+			// Lock the remaining admins so two concurrent demotions can't both pass.
+			var admins []domain.User
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("role = ? AND deleted_at IS NULL AND uuid <> ?", domain.RoleAdmin, userUUID).
+				Find(&admins).Error
+			if err != nil {
+				return fmt.Errorf("failed to count admins: %w", err)
+			}
+			if len(admins) == 0 {
+				return errors.New("tidak dapat mengubah role admin terakhir")
+			}
+		}
+
+		if err := tx.Model(&domain.User{}).Where("uuid = ?", userUUID).Update("role", role).Error; err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
+		previous = user.Role
+		return nil
+	})
+	return previous, err
+}
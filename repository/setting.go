@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type settingRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) domain.SettingRepository {
+	return &settingRepository{db: db}
+}
+
+func (r *settingRepository) GetAll(ctx context.Context) ([]domain.AppSetting, error) {
+	var settings []domain.AppSetting
+	if err := r.db.WithContext(ctx).Order("key ASC").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *settingRepository) Get(ctx context.Context, key string) (*domain.AppSetting, error) {
+	var setting domain.AppSetting
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch setting %s: %w", key, err)
+	}
+	return &setting, nil
+}
+
+func (r *settingRepository) Upsert(ctx context.Context, setting *domain.AppSetting) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+	}).Create(setting).Error
+	if err != nil {
+		return fmt.Errorf("failed to save setting %s: %w", setting.Key, err)
+	}
+	return nil
+}
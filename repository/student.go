@@ -212,12 +212,15 @@ func (r *studentRepository) BookClass(
 	// 3️⃣ Find Best Student Package (Smart Selection)
 	// Criteria: Matches InstrumentID, Matches Schedule Duration, Active, Has Quota
 	// Priority: Soonest EndDate
+	// Instrument and duration come from the version the student bought, so editing the
+	// package later does not change which schedules an existing package can book.
 	var studentPackage domain.StudentPackage
 	err = tx.Joins("JOIN packages ON packages.id = student_packages.package_id").
+		Joins("LEFT JOIN package_versions ON package_versions.id = student_packages.package_version_id").
 		Preload("Package.Instrument").
 		Where("student_packages.student_uuid = ?", studentUUID).
-		Where("packages.instrument_id = ?", instrumentID).
-		Where("packages.duration = ?", schedule.Duration). // Strict duration match
+		Where("COALESCE(package_versions.instrument_id, packages.instrument_id) = ?", instrumentID).
+		Where("COALESCE(package_versions.duration, packages.duration) = ?", schedule.Duration). // Strict duration match
 		Where("student_packages.remaining_quota > 0").
 		Where("student_packages.end_date >= ?", time.Now()).
 		Order("student_packages.end_date ASC"). // Prioritize expiring soonest
@@ -435,6 +438,7 @@ func (r *studentRepository) GetAvailableSchedules(ctx context.Context, studentUU
 	err := r.db.WithContext(ctx).
 		Preload("StudentProfile.Packages", "end_date >= ? AND remaining_quota > 0", time.Now()).
 		Preload("StudentProfile.Packages.Package.Instrument").
+		Preload("StudentProfile.Packages.PackageVersion").
 		Where("uuid = ? AND role = ? AND deleted_at IS NULL", studentUUID, domain.RoleStudent).
 		First(&student).Error
 
@@ -455,12 +459,13 @@ func (r *studentRepository) GetAvailableSchedules(ctx context.Context, studentUU
 	var validInstrumentIDs []int
 
 	for _, sp := range student.StudentProfile.Packages {
-		if sp.Package == nil {
+		pkg := sp.PurchasedPackage()
+		if pkg == nil {
 			continue
 		}
 
-		instID := sp.Package.InstrumentID
-		duration := sp.Package.Duration // e.g., 30 or 60
+		instID := pkg.InstrumentID
+		duration := pkg.Duration // e.g., 30 or 60
 
 		_, exists := validPackages[instID]
 		if !exists {
@@ -157,6 +157,7 @@ func (r *teacherRepository) FinishClass(ctx context.Context, bookingID int, teac
 	var booking domain.Booking
 	err := tx.Preload("Schedule").
 		Preload("PackageUsed.Package").
+		Preload("PackageUsed.PackageVersion").
 		Where("id = ? AND status = ?", bookingID, domain.StatusBooked).
 		First(&booking).Error
 	if err != nil {
@@ -199,8 +200,8 @@ func (r *teacherRepository) FinishClass(ctx context.Context, bookingID int, teac
 	var classEnd time.Time
 	is30MinPackage := false
 
-	if booking.PackageUsed.Package != nil {
-		is30MinPackage = booking.PackageUsed.Package.Duration == 30
+	if pkg := booking.PackageUsed.PurchasedPackage(); pkg != nil {
+		is30MinPackage = pkg.Duration == 30
 	}
 
 	if is30MinPackage {
@@ -271,7 +272,13 @@ func (r *teacherRepository) FinishClass(ctx context.Context, bookingID int, teac
 		return fmt.Errorf("Kelas belum dimulai. Kelas akan dimulai pukul %s", startFormatted)
 	}
 
+	// A no-show still ends the booking; only the class history tells the two apart.
+	historyStatus := domain.StatusCompleted
 	defaultNotes := "Kelas selesai, tanpa catatan"
+	if payload.Status == domain.StatusNoShow {
+		historyStatus = domain.StatusNoShow
+		defaultNotes = "Murid tidak hadir"
+	}
 
 	if payload.Notes == nil || *payload.Notes == "" {
 		payload.Notes = &defaultNotes
@@ -280,7 +287,7 @@ func (r *teacherRepository) FinishClass(ctx context.Context, bookingID int, teac
 	// 6️⃣ Create ClassHistory
 	classHistory := domain.ClassHistory{
 		BookingID: booking.ID,
-		Status:    domain.StatusCompleted,
+		Status:    historyStatus,
 		Notes:     payload.Notes,
 	}
 
@@ -492,6 +499,7 @@ func (r *teacherRepository) GetAllBookedClass(ctx context.Context, teacherUUID s
 		Preload("PackageUsed").
 		Preload("PackageUsed.Package").
 		Preload("PackageUsed.Package.Instrument").
+		Preload("PackageUsed.PackageVersion").
 		Preload("Schedule").
 		Preload("Schedule.Teacher").
 		Where("schedule_id IN (SELECT id FROM teacher_schedules WHERE teacher_uuid = ? AND deleted_at IS NULL)", teacherUUID).
@@ -530,8 +538,8 @@ func (r *teacherRepository) GetAllBookedClass(ctx context.Context, teacherUUID s
 
 		// Check if 30-minute package
 		is30MinPackage := false
-		if bookings[i].PackageUsed.Package != nil {
-			is30MinPackage = bookings[i].PackageUsed.Package.Duration == 30
+		if pkg := bookings[i].PackageUsed.PurchasedPackage(); pkg != nil {
+			is30MinPackage = pkg.Duration == 30
 		}
 
 		switch {
@@ -560,8 +568,8 @@ func (r *teacherRepository) GetAllBookedClass(ctx context.Context, teacherUUID s
 
 		// Get instrument ID from the booked package
 		var instrumentID int
-		if bookings[i].PackageUsed.Package != nil {
-			instrumentID = bookings[i].PackageUsed.Package.InstrumentID
+		if pkg := bookings[i].PackageUsed.PurchasedPackage(); pkg != nil {
+			instrumentID = pkg.InstrumentID
 		}
 
 		// Fetch completed class histories for this student, filtered by instrument
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// tokenVersionCacheTTL only bounds how long an idle user's entry stays in Redis; bumps
+// overwrite the cache, so it never serves a stale version.
+const tokenVersionCacheTTL = 24 * time.Hour
+
+type tokenVersionRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+func NewTokenVersionRepository(db *gorm.DB, redisClient *redis.Client) domain.TokenVersionRepository {
+	return &tokenVersionRepository{db: db, redis: redisClient}
+}
+
+func tokenVersionKey(userUUID string) string {
+	return "token_version:" + userUUID
+}
+
+// GetTokenVersion is called on every authenticated request, so it reads Redis first and
+// only falls back to the database on a cache miss.
+func (r *tokenVersionRepository) GetTokenVersion(ctx context.Context, userUUID string) (int, error) {
+	version, err := r.redis.Get(ctx, tokenVersionKey(userUUID)).Int()
+	if err == nil {
+		return version, nil
+	}
+	if err != redis.Nil {
+		return 0, fmt.Errorf("failed to read token version: %w", err)
+	}
+
+	var user domain.User
+	if err := r.db.WithContext(ctx).Select("token_version").Where("uuid = ?", userUUID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, domain.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to read token version: %w", err)
+	}
+
+	// SetNX so a concurrent bump that already cached a newer version isn't overwritten.
+	r.redis.SetNX(ctx, tokenVersionKey(userUUID), user.TokenVersion, tokenVersionCacheTTL)
+	return user.TokenVersion, nil
+}
+
+func (r *tokenVersionRepository) BumpTokenVersion(ctx context.Context, userUUID string) (int, error) {
+	var version int
+	result := r.db.WithContext(ctx).
+		Raw("UPDATE users SET token_version = token_version + 1 WHERE uuid = ? RETURNING token_version", userUUID).
+		Scan(&version)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to bump token version: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, domain.ErrUserNotFound
+	}
+
+	if err := r.redis.Set(ctx, tokenVersionKey(userUUID), version, tokenVersionCacheTTL).Err(); err != nil {
+		// Without the overwrite the cache would keep accepting old tokens, so drop the key
+		// and let the next request reload it from the database.
+		if delErr := r.redis.Del(ctx, tokenVersionKey(userUUID)).Err(); delErr != nil {
+			return version, fmt.Errorf("failed to update cached token version: %w", err)
+		}
+	}
+	return version, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type twoFactorRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+// NewTwoFactorRepository stores enrolments in the database and the short-lived login
+// challenges in Redis.
+func NewTwoFactorRepository(db *gorm.DB, redisClient *redis.Client) domain.TwoFactorRepository {
+	return &twoFactorRepository{db: db, redis: redisClient}
+}
+
+func (r *twoFactorRepository) GetTwoFactor(ctx context.Context, userUUID string) (*domain.UserTwoFactor, error) {
+	var tf domain.UserTwoFactor
+	if err := r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).First(&tf).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("gagal mengambil data 2FA: %w", err)
+	}
+	return &tf, nil
+}
+
+// SavePendingSecret starts (or restarts) setup. An enabled enrolment is never replaced;
+// it has to be disabled first.
+func (r *twoFactorRepository) SavePendingSecret(ctx context.Context, userUUID, secret string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing domain.UserTwoFactor
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_uuid = ?", userUUID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(&domain.UserTwoFactor{UserUUID: userUUID, Secret: secret}).Error
+		case err != nil:
+			return err
+		case existing.Enabled:
+			return errors.New("2FA sudah aktif")
+		}
+		return tx.Model(&existing).Updates(map[string]interface{}{"secret": secret, "last_used_step": 0}).Error
+	})
+}
+
+// Enable turns a pending enrolment on, recording the step of the confirming code and
+// replacing any recovery codes.
+func (r *twoFactorRepository) Enable(ctx context.Context, userUUID string, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tf domain.UserTwoFactor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_uuid = ?", userUUID).First(&tf).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("setup 2FA belum dimulai")
+			}
+			return err
+		}
+		if tf.Enabled {
+			return errors.New("2FA sudah aktif")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&tf).Updates(map[string]interface{}{
+			"enabled":        true,
+			"enabled_at":     now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userUUID, codeHashes)
+	})
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userUUID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_uuid = ?", userUUID).Delete(&domain.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_uuid = ?", userUUID).Delete(&domain.UserTwoFactor{}).Error
+	})
+}
+
+// ConsumeStep marks a TOTP step as used. It fails when that step or a later one was
+// already accepted, so an intercepted code can't be replayed within its window.
+func (r *twoFactorRepository) ConsumeStep(ctx context.Context, userUUID string, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.UserTwoFactor{}).
+		Where("user_uuid = ? AND enabled = ? AND last_used_step < ?", userUUID, true, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *twoFactorRepository) ConsumeRecoveryCode(ctx context.Context, userUUID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.TwoFactorRecoveryCode{}).
+		Where("user_uuid = ? AND code_hash = ? AND used_at IS NULL", userUUID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userUUID string, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userUUID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userUUID string, codeHashes []string) error {
+	if err := tx.Where("user_uuid = ?", userUUID).Delete(&domain.TwoFactorRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]domain.TwoFactorRecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = domain.TwoFactorRecoveryCode{UserUUID: userUUID, CodeHash: hash}
+	}
+	return tx.Create(&codes).Error
+}
+
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userUUID string) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.TwoFactorRecoveryCode{}).
+		Where("user_uuid = ? AND used_at IS NULL", userUUID).
+		Count(&count).Error
+	return int(count), err
+}
+
+func twoFactorChallengeKey(token string) string {
+	return "2fa:challenge:" + token
+}
+
+func (r *twoFactorRepository) CreateChallenge(ctx context.Context, challenge *domain.TwoFactorChallenge, ttl time.Duration) error {
+	key := twoFactorChallengeKey(challenge.Token)
+	pipe := r.redis.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"user_uuid":      challenge.UserUUID,
+		"setup_required": strconv.FormatBool(challenge.SetupRequired),
+		"expires_at":     challenge.ExpiresAt.Unix(),
+		"attempts":       0,
+	})
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save 2FA challenge: %w", err)
+	}
+	return nil
+}
+
+func (r *twoFactorRepository) GetChallenge(ctx context.Context, token string) (*domain.TwoFactorChallenge, error) {
+	data, err := r.redis.HGetAll(ctx, twoFactorChallengeKey(token)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch 2FA challenge: %w", err)
+	}
+	if len(data) == 0 || data["user_uuid"] == "" {
+		return nil, nil
+	}
+
+	setupRequired, _ := strconv.ParseBool(data["setup_required"])
+	expiresAt, _ := strconv.ParseInt(data["expires_at"], 10, 64)
+	return &domain.TwoFactorChallenge{
+		Token:         token,
+		UserUUID:      data["user_uuid"],
+		SetupRequired: setupRequired,
+		ExpiresAt:     time.Unix(expiresAt, 0),
+	}, nil
+}
+
+// challengeFailureScript counts a failed attempt without recreating a challenge that
+// expired or was completed in the meantime (HINCRBY alone would, and without a TTL).
+var challengeFailureScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+`)
+
+// RecordChallengeFailure counts a wrong code and returns the number of failures so far, or
+// -1 when the challenge is gone.
+func (r *twoFactorRepository) RecordChallengeFailure(ctx context.Context, token string) (int, error) {
+	attempts, err := challengeFailureScript.Run(ctx, r.redis, []string{twoFactorChallengeKey(token)}).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to record 2FA attempt: %w", err)
+	}
+	return attempts, nil
+}
+
+// DeleteChallenge reports whether this call removed the challenge, so only one request
+// can complete it.
+func (r *twoFactorRepository) DeleteChallenge(ctx context.Context, token string) (bool, error) {
+	deleted, err := r.redis.Del(ctx, twoFactorChallengeKey(token)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete 2FA challenge: %w", err)
+	}
+	return deleted == 1, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type voucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) domain.VoucherRepository {
+	return &voucherRepository{db: db}
+}
+
+// loadPackages resolves the package restriction, rejecting unknown or deleted packages.
+func loadPackages(tx *gorm.DB, packageIDs []int) ([]domain.Package, error) {
+	packages := []domain.Package{}
+	if len(packageIDs) == 0 {
+		return packages, nil
+	}
+
+	if err := tx.Where("id IN ? AND deleted_at IS NULL", packageIDs).Find(&packages).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil paket: %w", err)
+	}
+	if len(packages) != len(packageIDs) {
+		return nil, errors.New("satu atau lebih paket tidak ditemukan")
+	}
+	return packages, nil
+}
+
+func (r *voucherRepository) Create(ctx context.Context, voucher *domain.Voucher, packageIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.Voucher{}).Where("code = ?", voucher.Code).Count(&count).Error; err != nil {
+			return fmt.Errorf("gagal memeriksa kode voucher: %w", err)
+		}
+		if count > 0 {
+			return errors.New("kode voucher sudah ada")
+		}
+
+		packages, err := loadPackages(tx, packageIDs)
+		if err != nil {
+			return err
+		}
+		voucher.Packages = packages
+
+		if err := tx.Omit("Packages.*").Create(voucher).Error; err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+		return nil
+	})
+}
+
+func (r *voucherRepository) Update(ctx context.Context, voucher *domain.Voucher, packageIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing domain.Voucher
+		if err := tx.Where("id = ? AND deleted_at IS NULL", voucher.ID).First(&existing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("voucher tidak ditemukan")
+			}
+			return fmt.Errorf("gagal mengambil voucher: %w", err)
+		}
+
+		var count int64
+		if err := tx.Model(&domain.Voucher{}).Where("code = ? AND id <> ?", voucher.Code, voucher.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("gagal memeriksa kode voucher: %w", err)
+		}
+		if count > 0 {
+			return errors.New("kode voucher sudah ada")
+		}
+
+		packages, err := loadPackages(tx, packageIDs)
+		if err != nil {
+			return err
+		}
+
+		// redeemed_count is owned by checkout and never written from here.
+		if err := tx.Model(&existing).
+			Select("code", "description", "type", "value", "max_discount", "starts_at", "ends_at",
+				"max_redemptions", "max_per_user", "first_purchase_only", "is_active").
+			Updates(voucher).Error; err != nil {
+			return errors.New(utils.TranslateDBError(err))
+		}
+
+		if err := tx.Model(&existing).Association("Packages").Replace(packages); err != nil {
+			return fmt.Errorf("gagal memperbarui paket voucher: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *voucherRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Model(&domain.Voucher{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "is_active": false})
+	if result.Error != nil {
+		return errors.New(utils.TranslateDBError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("voucher tidak ditemukan")
+	}
+	return nil
+}
+
+func (r *voucherRepository) GetByID(ctx context.Context, id int) (*domain.Voucher, error) {
+	var voucher domain.Voucher
+	err := r.db.WithContext(ctx).
+		Preload("Packages").
+		Where("id = ? AND deleted_at IS NULL", id).
+		First(&voucher).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("voucher tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil voucher: %w", err)
+	}
+	return &voucher, nil
+}
+
+func (r *voucherRepository) GetAll(ctx context.Context, filter domain.VoucherFilter) ([]domain.Voucher, int64, error) {
+	var vouchers []domain.Voucher
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.Voucher{}).Where("deleted_at IS NULL")
+	if filter.Search != "" {
+		query = query.Where("code ILIKE ? OR description ILIKE ?", "%"+filter.Search+"%", "%"+filter.Search+"%")
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count vouchers: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Preload("Packages").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&vouchers).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch vouchers: %w", err)
+	}
+	return vouchers, total, nil
+}
+
+func (r *voucherRepository) GetRedemptions(ctx context.Context, voucherID, page, limit int) ([]domain.VoucherRedemption, int64, error) {
+	var redemptions []domain.VoucherRedemption
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.VoucherRedemption{}).Where("voucher_id = ?", voucherID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count voucher redemptions: %w", err)
+	}
+
+	if err := query.Preload("Student").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&redemptions).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch voucher redemptions: %w", err)
+	}
+	return redemptions, total, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) domain.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, event *domain.WebhookEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to store webhook event: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) Update(ctx context.Context, event *domain.WebhookEvent) error {
+	if err := r.db.WithContext(ctx).Save(event).Error; err != nil {
+		return fmt.Errorf("failed to update webhook event: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id int) (*domain.WebhookEvent, error) {
+	var event domain.WebhookEvent
+	if err := r.db.WithContext(ctx).First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook event tidak ditemukan")
+		}
+		return nil, fmt.Errorf("failed to fetch webhook event: %w", err)
+	}
+	return &event, nil
+}
+
+func (r *webhookRepository) HasProcessedEvent(ctx context.Context, provider, eventID string, excludeID int) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&domain.WebhookEvent{}).
+		Where("provider = ? AND event_id = ? AND status = ? AND id != ?", provider, eventID, domain.WebhookStatusProcessed, excludeID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check webhook duplicates: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *webhookRepository) GetAll(ctx context.Context, filter domain.WebhookFilter) ([]domain.WebhookEvent, int64, error) {
+	var events []domain.WebhookEvent
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.WebhookEvent{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.EventID != "" {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook events: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("received_at DESC").
+		Limit(filter.Limit).
+		Offset(offset).
+		Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch webhook events: %w", err)
+	}
+
+	return events, total, nil
+}
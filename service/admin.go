@@ -6,7 +6,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"os"
+	"strconv"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
@@ -15,16 +17,20 @@ import (
 )
 
 type adminService struct {
-	adminRepo   domain.AdminRepository
-	paymentRepo domain.PaymentRepository
-	messenger   *whatsmeow.Client
+	adminRepo    domain.AdminRepository
+	paymentRepo  domain.PaymentRepository
+	tokenVersion domain.TokenVersionRepository
+	audit        domain.AuditRecorder
+	messenger    *whatsmeow.Client
 }
 
-func NewAdminService(adminRepo domain.AdminRepository, paymentRepo domain.PaymentRepository, meow *whatsmeow.Client) domain.AdminUseCase {
+func NewAdminService(adminRepo domain.AdminRepository, paymentRepo domain.PaymentRepository, tokenVersion domain.TokenVersionRepository, audit domain.AuditRecorder, meow *whatsmeow.Client) domain.AdminUseCase {
 	return &adminService{
-		adminRepo:   adminRepo,
-		paymentRepo: paymentRepo,
-		messenger:   meow,
+		adminRepo:    adminRepo,
+		paymentRepo:  paymentRepo,
+		tokenVersion: tokenVersion,
+		audit:        audit,
+		messenger:    meow,
 	}
 }
 
@@ -47,12 +53,18 @@ func (s *adminService) GetPackageSummary(ctx context.Context) ([]domain.PackageS
 	return s.paymentRepo.GetPackageSummary(ctx)
 }
 
+func (s *adminService) GetRevenueByMethod(ctx context.Context, filter domain.ProfitFilter) ([]domain.RevenueByMethod, error) {
+	return s.paymentRepo.GetRevenueByMethod(ctx, filter)
+}
+
 func (s *adminService) ClearUserDeletedAt(ctx context.Context, userUUID string) error {
 	err := s.adminRepo.ClearUserDeletedAt(ctx, userUUID)
 	if err != nil {
 		return err
 	}
 
+	s.audit.Record(ctx, domain.AuditActionUserReactivate, domain.AuditTargetUser, userUUID,
+		map[string]bool{"active": false}, map[string]bool{"active": true})
 	return nil
 }
 
@@ -94,6 +106,7 @@ func (s *adminService) CreateManager(ctx context.Context, user *domain.User) (*d
 		return nil, errors.New(utils.TranslateDBError(err))
 	}
 
+	s.audit.Record(ctx, domain.AuditActionManagerCreate, domain.AuditTargetUser, created.UUID, nil, created)
 	return created, nil
 }
 
@@ -150,6 +163,12 @@ func (s *adminService) AssignPackageToStudent(ctx context.Context, studentUUID s
 		return err
 	}
 
+	s.audit.Record(ctx, domain.AuditActionPackageAssign, domain.AuditTargetUser, studentUUID, nil, map[string]interface{}{
+		"package_id":   dataPackage.ID,
+		"package_name": dataPackage.Name,
+		"quota":        dataPackage.Quota,
+	})
+
 	if s.messenger != nil {
 		// Send WhatsApp notification to student
 		phoneNormalized := utils.NormalizePhoneNumber(dataStudent.Phone)
@@ -201,6 +220,7 @@ func (s *adminService) CreatePackage(ctx context.Context, pkg *domain.Package) (
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, domain.AuditActionPackageCreate, domain.AuditTargetPackage, strconv.Itoa(created.ID), nil, created)
 	return created, nil
 }
 
@@ -208,14 +228,30 @@ func (s *adminService) UpdatePackage(ctx context.Context, pkg *domain.Package) e
 	if pkg == nil {
 		return errors.New("pkg is nil")
 	}
-	return s.adminRepo.UpdatePackage(ctx, pkg)
+	before, _ := s.adminRepo.GetPackagesByID(ctx, pkg.ID)
+	if err := s.adminRepo.UpdatePackage(ctx, pkg); err != nil {
+		return err
+	}
+	after, _ := s.adminRepo.GetPackagesByID(ctx, pkg.ID)
+	s.audit.Record(ctx, domain.AuditActionPackageUpdate, domain.AuditTargetPackage, strconv.Itoa(pkg.ID), before, after)
+	return nil
 }
 
 func (s *adminService) DeletePackage(ctx context.Context, id int) error {
 	if id <= 0 {
 		return errors.New("invalid package id")
 	}
-	return s.adminRepo.DeletePackage(ctx, id)
+	before, _ := s.adminRepo.GetPackagesByID(ctx, id)
+	if err := s.adminRepo.DeletePackage(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionPackageDelete, domain.AuditTargetPackage, strconv.Itoa(id), before, nil)
+	return nil
+}
+
+// GetPackageVersions returns the price and terms history of a package, newest first.
+func (s *adminService) GetPackageVersions(ctx context.Context, packageID int) ([]domain.PackageVersion, error) {
+	return s.adminRepo.GetPackageVersions(ctx, packageID)
 }
 
 // CreateInstrument creates a new instrument (note: accepts *domain.Instrument)
@@ -230,6 +266,7 @@ func (s *adminService) CreateInstrument(ctx context.Context, instrument *domain.
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, domain.AuditActionInstrumentCreate, domain.AuditTargetInstrument, strconv.Itoa(created.ID), nil, created)
 	return created, nil
 }
 
@@ -237,14 +274,38 @@ func (s *adminService) UpdateInstrument(ctx context.Context, instrument *domain.
 	if instrument == nil {
 		return errors.New("instrument is nil")
 	}
-	return s.adminRepo.UpdateInstrument(ctx, instrument)
+	before := s.findInstrument(ctx, instrument.ID)
+	if err := s.adminRepo.UpdateInstrument(ctx, instrument); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionInstrumentUpdate, domain.AuditTargetInstrument, strconv.Itoa(instrument.ID), before, instrument)
+	return nil
 }
 
 func (s *adminService) DeleteInstrument(ctx context.Context, id int) error {
 	if id <= 0 {
 		return errors.New("invalid instrument id")
 	}
-	return s.adminRepo.DeleteInstrument(ctx, id)
+	before := s.findInstrument(ctx, id)
+	if err := s.adminRepo.DeleteInstrument(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionInstrumentDelete, domain.AuditTargetInstrument, strconv.Itoa(id), before, nil)
+	return nil
+}
+
+// findInstrument looks up an active instrument for the audit log's before value.
+func (s *adminService) findInstrument(ctx context.Context, id int) *domain.Instrument {
+	instruments, err := s.adminRepo.GetAllInstruments(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range instruments {
+		if instruments[i].ID == id {
+			return &instruments[i]
+		}
+	}
+	return nil
 }
 
 // GetAllPackages returns all packages
@@ -262,6 +323,28 @@ func (s *adminService) GetAllStudents(ctx context.Context) ([]domain.User, error
 	return s.adminRepo.GetAllStudents(ctx)
 }
 
+func (s *adminService) CreateStudent(ctx context.Context, user *domain.User) (*domain.User, error) {
+	if user.Name == "" || user.Email == "" || user.Phone == "" || user.Password == "" {
+		return nil, errors.New("semua field wajib diisi")
+	}
+
+	user.Role = domain.RoleStudent
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("gagal mengenkripsi password")
+	}
+	user.Password = string(hashed)
+
+	created, err := s.adminRepo.CreateStudent(ctx, user)
+	if err != nil {
+		return nil, errors.New(utils.TranslateDBError(err))
+	}
+
+	s.audit.Record(ctx, domain.AuditActionStudentCreate, domain.AuditTargetUser, created.UUID, nil, created)
+	return created, nil
+}
+
 // GetAllUsers returns all users
 func (s *adminService) GetAllUsers(ctx context.Context) ([]domain.User, error) {
 	return s.adminRepo.GetAllUsers(ctx)
@@ -296,6 +379,7 @@ func (s *adminService) CreateTeacher(ctx context.Context, user *domain.User, ins
 		return nil, errors.New(utils.TranslateDBError(err))
 	}
 
+	s.audit.Record(ctx, domain.AuditActionTeacherCreate, domain.AuditTargetUser, created.UUID, nil, created)
 	return created, nil
 }
 
@@ -305,9 +389,12 @@ func (s *adminService) UpdateTeacher(ctx context.Context, user *domain.User, ins
 		return errors.New("uuid teacher tidak boleh kosong")
 	}
 
+	before, _ := s.adminRepo.GetTeacherByUUID(ctx, user.UUID)
 	if err := s.adminRepo.UpdateTeacher(ctx, user, instrumentIDs); err != nil {
 		return errors.New(utils.TranslateDBError(err))
 	}
+	after, _ := s.adminRepo.GetTeacherByUUID(ctx, user.UUID)
+	s.audit.Record(ctx, domain.AuditActionTeacherUpdate, domain.AuditTargetUser, user.UUID, before, after)
 	return nil
 }
 
@@ -342,5 +429,12 @@ func (s *adminService) DeleteUser(ctx context.Context, uuid string) error {
 	if err := s.adminRepo.DeleteUser(ctx, uuid); err != nil {
 		return errors.New(utils.TranslateDBError(err))
 	}
+	s.audit.Record(ctx, domain.AuditActionUserDeactivate, domain.AuditTargetUser, uuid,
+		map[string]bool{"active": true}, map[string]bool{"active": false})
+
+	// A deactivated user's tokens must stop working right away, not when they expire.
+	if _, err := s.tokenVersion.BumpTokenVersion(ctx, uuid); err != nil {
+		log.Printf("⚠️ Failed to revoke tokens of deactivated user %s: %v", uuid, err)
+	}
 	return nil
 }
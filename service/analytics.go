@@ -0,0 +1,211 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type analyticsService struct {
+	repo domain.AnalyticsRepository
+	loc  *time.Location
+}
+
+func NewAnalyticsService(repo domain.AnalyticsRepository) domain.AnalyticsUseCase {
+	loc, err := time.LoadLocation(domain.AnalyticsTimezone)
+	if err != nil {
+		loc = time.FixedZone("WITA", 8*60*60)
+	}
+	return &analyticsService{repo: repo, loc: loc}
+}
+
+// GetRevenueAnalytics reports revenue (net of refunds) between start_date and end_date,
+// bucketed by interval, compared against the equally long period right before it.
+func (s *analyticsService) GetRevenueAnalytics(ctx context.Context, filter domain.RevenueAnalyticsFilter) (*domain.RevenueAnalytics, error) {
+	interval := filter.Interval
+	if interval == "" {
+		interval = domain.AnalyticsIntervalDay
+	}
+
+	from, to, err := s.parseRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := bucketStarts(from, to, interval)
+	if len(buckets) > domain.MaxAnalyticsBuckets {
+		return nil, fmt.Errorf("rentang tanggal terlalu panjang untuk interval %s, maksimal %d titik", interval, domain.MaxAnalyticsBuckets)
+	}
+
+	days := int(math.Round(to.Sub(from).Hours() / 24))
+	prevFrom, prevTo := from.AddDate(0, 0, -days), from
+
+	current, err := s.repo.GetRevenueTotals(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.repo.GetRevenueTotals(ctx, prevFrom, prevTo)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.repo.GetRevenueSeries(ctx, from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+	byPackage, err := s.repo.GetRevenueByPackage(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byInstrument, err := s.repo.GetRevenueByInstrument(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byChannel, err := s.repo.GetRevenueByChannel(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range byPackage {
+		byPackage[i].SharePct = sharePct(byPackage[i].Revenue, current.Revenue)
+	}
+	for i := range byInstrument {
+		byInstrument[i].SharePct = sharePct(byInstrument[i].Revenue, current.Revenue)
+	}
+	for i := range byChannel {
+		byChannel[i].SharePct = sharePct(byChannel[i].Revenue, current.Revenue)
+	}
+
+	cur := s.periodSummary(from, to, current)
+	prev := s.periodSummary(prevFrom, prevTo, previous)
+
+	return &domain.RevenueAnalytics{
+		Interval: interval,
+		Current:  cur,
+		Previous: prev,
+		Comparison: domain.RevenueComparison{
+			RevenueChangePct:           changePct(cur.Revenue, prev.Revenue),
+			OrdersChangePct:            changePct(float64(cur.Orders), float64(prev.Orders)),
+			AverageOrderValueChangePct: changePct(cur.AverageOrderValue, prev.AverageOrderValue),
+			BuyersChangePct:            changePct(float64(cur.Buyers), float64(prev.Buyers)),
+		},
+		Series:       s.fillSeries(buckets, series),
+		ByPackage:    byPackage,
+		ByInstrument: byInstrument,
+		ByChannel:    byChannel,
+	}, nil
+}
+
+// parseRange turns the inclusive local dates of the filter into a half-open [from, to) range.
+func (s *analyticsService) parseRange(filter domain.RevenueAnalyticsFilter) (time.Time, time.Time, error) {
+	now := time.Now().In(s.loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	if filter.EndDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.EndDate, s.loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("format end_date tidak valid, gunakan YYYY-MM-DD")
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -(domain.DefaultAnalyticsRangeDays - 1))
+	if filter.StartDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", filter.StartDate, s.loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("format start_date tidak valid, gunakan YYYY-MM-DD")
+		}
+		start = parsed
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, errors.New("start_date tidak valid: harus sebelum end_date")
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+func (s *analyticsService) periodSummary(from, to time.Time, totals *domain.RevenueTotals) domain.RevenuePeriodSummary {
+	return domain.RevenuePeriodSummary{
+		StartDate:         from.Format("2006-01-02"),
+		EndDate:           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Revenue:           totals.Revenue,
+		Orders:            totals.Orders,
+		AverageOrderValue: averageOrderValue(totals.Revenue, totals.Orders),
+		Buyers:            totals.Buyers,
+		NewBuyers:         totals.NewBuyers,
+		ReturningBuyers:   totals.Buyers - totals.NewBuyers,
+	}
+}
+
+// fillSeries returns one bucket per interval, including the ones without sales.
+func (s *analyticsService) fillSeries(starts []time.Time, rows []domain.RevenueBucket) []domain.RevenueBucket {
+	// The database returns local wall-clock bucket starts without a zone, so match on the date.
+	byDate := make(map[string]domain.RevenueBucket, len(rows))
+	for _, row := range rows {
+		byDate[row.Period.Format("2006-01-02")] = row
+	}
+
+	series := make([]domain.RevenueBucket, 0, len(starts))
+	for _, start := range starts {
+		bucket, ok := byDate[start.Format("2006-01-02")]
+		if !ok {
+			bucket = domain.RevenueBucket{}
+		}
+		bucket.Period = start
+		bucket.AverageOrderValue = averageOrderValue(bucket.Revenue, bucket.Orders)
+		series = append(series, bucket)
+	}
+	return series
+}
+
+// bucketStarts lists the local start of every interval touching [from, to).
+func bucketStarts(from, to time.Time, interval string) []time.Time {
+	cur := from
+	switch interval {
+	case domain.AnalyticsIntervalWeek:
+		offset := (int(cur.Weekday()) + 6) % 7 // days since Monday
+		cur = cur.AddDate(0, 0, -offset)
+	case domain.AnalyticsIntervalMonth:
+		cur = time.Date(cur.Year(), cur.Month(), 1, 0, 0, 0, 0, cur.Location())
+	}
+
+	var starts []time.Time
+	for cur.Before(to) {
+		starts = append(starts, cur)
+		if len(starts) > domain.MaxAnalyticsBuckets {
+			break
+		}
+		switch interval {
+		case domain.AnalyticsIntervalWeek:
+			cur = cur.AddDate(0, 0, 7)
+		case domain.AnalyticsIntervalMonth:
+			cur = cur.AddDate(0, 1, 0)
+		default:
+			cur = cur.AddDate(0, 0, 1)
+		}
+	}
+	return starts
+}
+
+func averageOrderValue(revenue float64, orders int64) float64 {
+	if orders == 0 {
+		return 0
+	}
+	return math.Round(revenue/float64(orders)*100) / 100
+}
+
+func sharePct(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(part/total*10000) / 100
+}
+
+func changePct(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	pct := math.Round((current-previous)/previous*10000) / 100
+	return &pct
+}
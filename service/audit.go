@@ -0,0 +1,73 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+type auditService struct {
+	repo domain.AuditRepository
+}
+
+func NewAuditService(repo domain.AuditRepository) domain.AuditUseCase {
+	return &auditService{repo: repo}
+}
+
+// Record appends an audit entry for an action that already happened. Failures are logged
+// rather than returned: the action can't be undone at this point.
+func (s *auditService) Record(ctx context.Context, action, targetType, targetID string, before, after interface{}) {
+	entry := &domain.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+	}
+	if actor, ok := domain.AuditActorFrom(ctx); ok {
+		if actor.UUID != "" {
+			entry.ActorUUID = &actor.UUID
+		}
+		if actor.APIKeyPrefix != "" {
+			entry.ActorAPIKey = &actor.APIKeyPrefix
+		}
+		entry.ActorRole = actor.Role
+		entry.IPAddress = actor.Client.IPAddress
+		entry.UserAgent = actor.Client.UserAgent
+	}
+
+	// The request may be cancelled as soon as the response is written; the entry must still land.
+	if err := s.repo.Create(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("⚠️ Failed to record audit log %s %s/%s: %v", action, targetType, targetID, err)
+	}
+}
+
+func auditSnapshot(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal audit snapshot: %v", err)
+		return nil
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+func (s *auditService) GetAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, int64, error) {
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, 0, errors.New("format tanggal harus YYYY-MM-DD")
+		}
+	}
+	return s.repo.GetAuditLogs(ctx, filter)
+}
@@ -22,17 +22,33 @@ var (
 type authService struct {
 	userRepo     domain.UserRepository
 	otpRepo      domain.OTPRepository
+	refreshRepo  domain.RefreshTokenRepository
+	tokenVersion domain.TokenVersionRepository
+	twoFactor    domain.TwoFactorUseCase
+	loginGuard   domain.LoginSecurityUseCase
+	audit        domain.AuditRecorder
+	oidc         domain.OIDCProvider // nil when OIDC login is off
+	oidcRepo     domain.OIDCRepository
 	accessToken  *utils.JWTManager
 	refreshToken *utils.JWTManager
 }
 
-func NewAuthService(userRepo domain.UserRepository, otpRepo domain.OTPRepository, secret string) domain.AuthUseCase {
+// NewAuthService signs access and refresh tokens with different secrets, so a leaked
+// access-token secret can't be used to mint refresh tokens and vice versa.
+func NewAuthService(userRepo domain.UserRepository, otpRepo domain.OTPRepository, refreshRepo domain.RefreshTokenRepository, tokenVersion domain.TokenVersionRepository, twoFactor domain.TwoFactorUseCase, loginGuard domain.LoginSecurityUseCase, audit domain.AuditRecorder, oidc domain.OIDCProvider, oidcRepo domain.OIDCRepository, accessSecret, refreshSecret string) domain.AuthUseCase {
 	return &authService{
-		userRepo: userRepo,
-		otpRepo:  otpRepo,
+		userRepo:     userRepo,
+		otpRepo:      otpRepo,
+		refreshRepo:  refreshRepo,
+		tokenVersion: tokenVersion,
+		twoFactor:    twoFactor,
+		loginGuard:   loginGuard,
+		audit:        audit,
+		oidc:         oidc,
+		oidcRepo:     oidcRepo,
 		// accessToken:  utils.NewJWTManager(secret, time.Hour),
-		accessToken:  utils.NewJWTManager(secret, 24*time.Hour),
-		refreshToken: utils.NewJWTManager(secret, 7*24*time.Hour),
+		accessToken:  utils.NewJWTManager(accessSecret, 24*time.Hour),
+		refreshToken: utils.NewJWTManager(refreshSecret, 7*24*time.Hour),
 	}
 }
 
@@ -59,10 +75,6 @@ func (s *authService) ChangeEmail(ctx context.Context, userUUID, newEmail, passw
 	return s.userRepo.UpdateUser(ctx, user)
 }
 
-func (s *authService) GetRefreshTokenManager() *utils.JWTManager {
-	return s.refreshToken
-}
-
 func (s *authService) Me(ctx context.Context, userUUID string) (*domain.User, error) {
 	user, err := s.userRepo.GetUserByUUID(ctx, userUUID)
 	if err != nil {
@@ -74,6 +86,10 @@ func (s *authService) Me(ctx context.Context, userUUID string) (*domain.User, er
 }
 
 func (s *authService) ResendOTP(ctx context.Context, email string) error {
+	if err := s.otpRepo.AcquireResendSlot(ctx, email, domain.OTPResendCooldown); err != nil {
+		return err
+	}
+
 	// cek apakah OTP lama ada
 	data, err := s.otpRepo.GetOTP(ctx, email)
 	if err != nil {
@@ -112,28 +128,126 @@ func (s *authService) ResendOTP(ctx context.Context, email string) error {
 	return nil
 }
 
-func (s *authService) Login(ctx context.Context, email, password string) (*domain.AuthTokens, error) {
+// Login checks the password. Accounts with 2FA get a challenge to complete with
+// CompleteTwoFactorLogin instead of tokens.
+func (s *authService) Login(ctx context.Context, email, password string, client domain.ClientInfo) (*domain.LoginResult, error) {
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
+		_ = s.loginGuard.RecordFailedLogin(ctx, email, nil, domain.LoginResultUnknownEmail, client)
 		return nil, errors.New("email atau password salah")
 	}
 
 	if user.DeletedAt != nil {
 		return nil, errors.New("akun anda telah dinonaktifkan, silakan hubungi admin untuk informasi lebih lanjut")
 	}
+	// A locked account is rejected before the password is even checked.
+	if err := s.loginGuard.CheckLock(ctx, user, client); err != nil {
+		return nil, err
+	}
 	// Compare password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
+		if lockErr := s.loginGuard.RecordFailedLogin(ctx, email, user, domain.LoginResultInvalidPassword, client); lockErr != nil {
+			return nil, lockErr
+		}
 		return nil, errors.New("email atau password salah")
 	}
 
-	// Generate tokens dengan UUID + Role
-	accessToken, err := s.accessToken.GenerateToken(user.UUID, user.Role, user.Name)
+	return s.finishLogin(ctx, user, client)
+}
+
+// finishLogin completes the login of an authenticated user: tokens, or the 2FA challenge
+// for accounts that use it.
+func (s *authService) finishLogin(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.LoginResult, error) {
+	challenge, err := s.twoFactor.LoginChallenge(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		s.loginGuard.RecordSuccessfulLogin(ctx, user, domain.LoginResultTwoFactorRequired, client)
+		return &domain.LoginResult{Challenge: challenge}, nil
+	}
+
+	tokens, err := s.startSession(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
+	s.loginGuard.RecordSuccessfulLogin(ctx, user, domain.LoginResultSuccess, client)
+	return &domain.LoginResult{Tokens: tokens}, nil
+}
+
+// CompleteTwoFactorLogin finishes a login that Login answered with a 2FA challenge.
+func (s *authService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	userUUID, recoveryCodes, verifyErr := s.twoFactor.VerifyChallenge(ctx, challengeToken, code)
+	if verifyErr != nil && userUUID == "" {
+		return nil, verifyErr
+	}
+
+	user, err := s.userRepo.GetUserByUUID(ctx, userUUID)
+	if err != nil || user.DeletedAt != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	// Wrong codes count towards the lockout like wrong passwords do.
+	if verifyErr != nil {
+		if lockErr := s.loginGuard.RecordFailedLogin(ctx, user.Email, user, domain.LoginResultTwoFactorFailed, client); lockErr != nil {
+			return nil, lockErr
+		}
+		return nil, verifyErr
+	}
+	if err := s.loginGuard.CheckLock(ctx, user, client); err != nil {
+		return nil, err
+	}
 
-	refreshToken, err := s.refreshToken.GenerateToken(user.UUID, user.Role, user.Name)
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	s.loginGuard.RecordSuccessfulLogin(ctx, user, domain.LoginResultSuccess, client)
+	return &domain.LoginResult{Tokens: tokens, RecoveryCodes: recoveryCodes}, nil
+}
+
+// startSession issues the tokens of a completed login.
+func (s *authService) startSession(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.AuthTokens, error) {
+	// Generate tokens dengan UUID + Role; each login starts a new refresh token family.
+	familyID, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.refreshRepo.CreateFamily(ctx, &domain.RefreshTokenFamily{
+		FamilyID:       familyID,
+		UserUUID:       user.UUID,
+		CurrentTokenID: tokenID,
+		UserAgent:      truncateUserAgent(client.UserAgent),
+		IPAddress:      client.IPAddress,
+		CreatedAt:      now,
+		LastUsedAt:     now,
+	}, s.refreshToken.Duration()); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, familyID, tokenID)
+}
+
+func (s *authService) issueTokens(user *domain.User, familyID, tokenID string) (*domain.AuthTokens, error) {
+	accessToken, err := s.accessToken.GenerateAccessToken(utils.AccessClaims{
+		UserUUID:     user.UUID,
+		Role:         user.Role,
+		Name:         user.Name,
+		SessionID:    familyID,
+		TokenVersion: user.TokenVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.refreshToken.GenerateRefreshToken(user.UUID, familyID, tokenID)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +258,121 @@ func (s *authService) Login(ctx context.Context, email, password string) (*domai
 	}, nil
 }
 
+// RefreshTokens exchanges a refresh token for a new access and refresh token. The old
+// refresh token stops working; presenting it again revokes the whole session.
+func (s *authService) RefreshTokens(ctx context.Context, refreshToken string, client domain.ClientInfo) (*domain.AuthTokens, error) {
+	claims, err := s.refreshToken.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil, domain.ErrRefreshTokenRevoked
+	}
+
+	newTokenID, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.Rotate(ctx, claims.UserUUID, claims.FamilyID, claims.TokenID, newTokenID, client.IPAddress, time.Now(), s.refreshToken.Duration()); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			log.Printf("⚠️ Refresh token reuse detected for user %s, session %s revoked", claims.UserUUID, claims.FamilyID)
+		}
+		return nil, err
+	}
+
+	// Role and name come from the database, so a changed role takes effect on refresh.
+	user, err := s.userRepo.GetUserByUUID(ctx, claims.UserUUID)
+	if err != nil || user.DeletedAt != nil {
+		_ = s.refreshRepo.RevokeFamily(ctx, claims.FamilyID)
+		return nil, domain.ErrUserNotFound
+	}
+
+	return s.issueTokens(user, claims.FamilyID, newTokenID)
+}
+
+// Logout revokes the session of the given refresh token. Invalid or expired tokens are
+// ignored: there is nothing left to revoke.
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	claims, err := s.refreshToken.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return nil
+	}
+	return s.refreshRepo.RevokeFamily(ctx, claims.FamilyID)
+}
+
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) > domain.MaxUserAgentLength {
+		return userAgent[:domain.MaxUserAgentLength]
+	}
+	return userAgent
+}
+
+// GetSessions lists the user's active sessions; currentSessionID marks the caller's own.
+func (s *authService) GetSessions(ctx context.Context, userUUID, currentSessionID string) ([]domain.RefreshTokenFamily, error) {
+	sessions, err := s.refreshRepo.GetFamiliesByUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].FamilyID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userUUID, sessionID string) error {
+	session, err := s.refreshRepo.GetFamily(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// Someone else's session is reported as missing, not forbidden, so IDs can't be probed.
+	if session == nil || session.UserUUID != userUUID {
+		return errors.New("sesi tidak ditemukan")
+	}
+	return s.refreshRepo.RevokeFamily(ctx, sessionID)
+}
+
+func (s *authService) RevokeOtherSessions(ctx context.Context, userUUID, currentSessionID string) (int, error) {
+	if currentSessionID == "" {
+		return 0, errors.New("sesi saat ini tidak dikenali, silakan login kembali")
+	}
+	return s.refreshRepo.RevokeUserFamilies(ctx, userUUID, currentSessionID)
+}
+
+// ForceLogout revokes every session of a user, e.g. when staff leave, together with the
+// access tokens already issued.
+func (s *authService) ForceLogout(ctx context.Context, userUUID string) (int, error) {
+	if _, err := s.userRepo.GetUserByUUID(ctx, userUUID); err != nil {
+		return 0, errors.New("user tidak ditemukan")
+	}
+	revoked, err := s.revokeAllTokens(ctx, userUUID)
+	if err != nil {
+		return 0, err
+	}
+	s.audit.Record(ctx, domain.AuditActionUserForceLogout, domain.AuditTargetUser, userUUID, nil, map[string]int{"revoked_sessions": revoked})
+	return revoked, nil
+}
+
+// revokeAllTokens logs the user out everywhere: the token version bump invalidates
+// outstanding access tokens and revoking the families stops them from being refreshed.
+func (s *authService) revokeAllTokens(ctx context.Context, userUUID string) (int, error) {
+	if _, err := s.tokenVersion.BumpTokenVersion(ctx, userUUID); err != nil {
+		return 0, err
+	}
+	return s.refreshRepo.RevokeUserFamilies(ctx, userUUID, "")
+}
+
+// revokeAfterPasswordChange logs the user out everywhere after a password change, retrying
+// once. The new password is already saved, so a failure is reported rather than hidden:
+// the old sessions would otherwise outlive the password they were opened with.
+func (s *authService) revokeAfterPasswordChange(ctx context.Context, userUUID string) error {
+	_, err := s.revokeAllTokens(ctx, userUUID)
+	if err != nil {
+		_, err = s.revokeAllTokens(ctx, userUUID)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to revoke tokens after password change for %s: %v", userUUID, err)
+		return fmt.Errorf("%w: %v", domain.ErrSessionRevocationFailed, err)
+	}
+	return nil
+}
+
 func (s *authService) Register(ctx context.Context, email string, name string, telephone string, password string, gender string) error {
 	if _, err := s.userRepo.GetUserByEmail(ctx, email); err == nil {
 		return ErrEmailExists
@@ -189,6 +418,9 @@ func (s *authService) Register(ctx context.Context, email string, name string, t
 
 func (s *authService) VerifyOTP(ctx context.Context, email, otp string) error {
 	data, valid, err := s.otpRepo.VerifyOTP(ctx, email, otp)
+	if errors.Is(err, domain.ErrOTPTooManyAttempts) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to verify OTP: %w", err)
 	}
@@ -226,10 +458,18 @@ func (s *authService) VerifyOTP(ctx context.Context, email, otp string) error {
 	return nil
 }
 
+// ForgotPassword answers the same way whether or not the email belongs to an account, so
+// it can't be used to find out who is registered. The email is sent in the background
+// for the same reason: a slow SMTP round trip would give it away too.
 func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	if err := s.otpRepo.AcquireResendSlot(ctx, email, domain.OTPResendCooldown); err != nil {
+		return err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return errors.New("email not found")
+		log.Printf("ForgotPassword: no active account for %s", email)
+		return nil
 	}
 
 	otp, err := utils.GenerateOTP(6)
@@ -245,9 +485,11 @@ func (s *authService) ForgotPassword(ctx context.Context, email string) error {
 	subject := "MadEU Reset Password OTP"
 	body := fmt.Sprintf("Halo %s,\n\nKode OTP untuk reset password akun Anda adalah: %s\nKode ini hanya berlaku selama 5 menit.\n\nJika Anda tidak merasa melakukan permintaan ini, abaikan email ini.",
 		user.Name, otp)
-	if err := utils.SendEmail(email, subject, body); err != nil {
-		return fmt.Errorf("failed to send OTP email: %w", err)
-	}
+	go func() {
+		if err := utils.SendEmail(email, subject, body); err != nil {
+			log.Printf("❌ ForgotPassword: failed to send OTP email to %s: %v", email, err)
+		}
+	}()
 
 	return nil
 }
@@ -278,7 +520,9 @@ func (s *authService) ResetPassword(ctx context.Context, email, otp, newPassword
 	}
 
 	_ = s.otpRepo.DeleteOTP(ctx, email)
-	return nil
+
+	// Whoever had the old password may still hold a session.
+	return s.revokeAfterPasswordChange(ctx, user.UUID)
 }
 
 func (s *authService) ChangePassword(ctx context.Context, userUUID, oldPassword, newPassword string) error {
@@ -297,7 +541,12 @@ func (s *authService) ChangePassword(ctx context.Context, userUUID, oldPassword,
 	}
 
 	user.Password = string(hashed)
-	return s.userRepo.UpdateUser(ctx, user)
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	// Every session, including the current one, has to log in again with the new password.
+	return s.revokeAfterPasswordChange(ctx, user.UUID)
 }
 
 func (s *authService) GetAccessTokenManager() *utils.JWTManager {
@@ -0,0 +1,463 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var exportSheetNames = map[string]string{
+	domain.ExportReportPayments:       "Pembayaran",
+	domain.ExportReportClassHistories: "Riwayat Kelas",
+	domain.ExportReportStudents:       "Siswa",
+	domain.ExportReportTeachers:       "Guru",
+}
+
+type exportService struct {
+	repo      domain.ExportRepository
+	storage   domain.FileStorage
+	syncMax   int64
+	retention time.Duration
+	slots     chan struct{} // limits how many background exports run at once
+	loc       *time.Location
+}
+
+func NewExportService(repo domain.ExportRepository, storage domain.FileStorage) domain.ExportUseCase {
+	syncMax := int64(domain.DefaultExportSyncMaxRows)
+	if n, err := strconv.ParseInt(os.Getenv("EXPORT_SYNC_MAX_ROWS"), 10, 64); err == nil && n >= 0 {
+		syncMax = n
+	}
+
+	retention := domain.DefaultExportRetention
+	if hours, err := strconv.Atoi(os.Getenv("EXPORT_RETENTION_HOURS")); err == nil && hours > 0 {
+		retention = time.Duration(hours) * time.Hour
+	}
+
+	loc, err := time.LoadLocation("Asia/Makassar") // WITA timezone
+	if err != nil {
+		loc = time.FixedZone("WITA", 8*60*60)
+	}
+
+	return &exportService{
+		repo:      repo,
+		storage:   storage,
+		syncMax:   syncMax,
+		retention: retention,
+		slots:     make(chan struct{}, domain.MaxConcurrentExportJobs),
+		loc:       loc,
+	}
+}
+
+// StartExportMaintenance fails jobs interrupted by the last shutdown, then deletes expired
+// export files every hour until ctx is cancelled.
+func StartExportMaintenance(ctx context.Context, uc domain.ExportUseCase) {
+	if err := uc.FailInterruptedJobs(ctx); err != nil {
+		log.Printf("⚠️  Failed to close interrupted exports: %v", err)
+	}
+
+	ticker := time.NewTicker(domain.ExportPurgeInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := uc.PurgeExpiredFiles(ctx); err != nil {
+					log.Printf("❌ Export cleanup failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func exportFile(report, format string, now time.Time) *domain.ExportFile {
+	file := &domain.ExportFile{
+		FileName:    fmt.Sprintf("%s-%s.%s", report, now.Format("20060102-150405"), format),
+		ContentType: "text/csv; charset=utf-8",
+	}
+	if format == domain.ExportFormatXLSX {
+		file.ContentType = utils.XLSXContentType
+	}
+	return file
+}
+
+func (s *exportService) PrepareExport(ctx context.Context, adminUUID, report string, filter domain.ExportFilter) (*domain.ExportFile, *domain.ExportJob, error) {
+	if _, ok := exportSheetNames[report]; !ok {
+		return nil, nil, errors.New("laporan tidak ditemukan")
+	}
+	if filter.Format == "" {
+		filter.Format = domain.ExportFormatCSV
+	}
+
+	count, err := s.repo.CountRows(ctx, report, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	file := exportFile(report, filter.Format, time.Now().In(s.loc))
+	if !filter.Async && count <= s.syncMax {
+		return file, nil, nil
+	}
+
+	rawFilter, err := json.Marshal(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	job := &domain.ExportJob{
+		RequestedBy: adminUUID,
+		Report:      report,
+		Format:      filter.Format,
+		Filter:      string(rawFilter),
+		Status:      domain.ExportJobQueued,
+		FileName:    file.FileName,
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		return nil, nil, err
+	}
+
+	// The job must outlive the request, so it gets its own context.
+	go s.runJob(*job, filter)
+	return nil, job, nil
+}
+
+func (s *exportService) runJob(job domain.ExportJob, filter domain.ExportFilter) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), domain.ExportJobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("🔥 Export job %d panic recovered: %v", job.ID, r)
+			s.finishJob(&job, "", 0, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	now := time.Now()
+	job.Status = domain.ExportJobRunning
+	job.StartedAt = &now
+	if err := s.repo.UpdateJob(ctx, &job); err != nil {
+		log.Printf("⚠️  Export job %d: %v", job.ID, err)
+	}
+
+	key, err := generateFileKey("exports", "."+job.Format)
+	if err != nil {
+		s.finishJob(&job, "", 0, err)
+		return
+	}
+
+	type result struct {
+		rows int64
+		err  error
+	}
+	done := make(chan result, 1)
+	pr, pw := io.Pipe()
+	go func() {
+		rows, err := s.WriteExport(ctx, job.Report, filter, pw)
+		pw.CloseWithError(err)
+		done <- result{rows, err}
+	}()
+
+	saveErr := s.storage.Save(ctx, key, pr)
+	pr.CloseWithError(saveErr) // unblocks the writer if storage gave up early
+	res := <-done
+
+	err = res.err
+	if saveErr != nil {
+		err = saveErr
+	}
+	if err != nil {
+		if delErr := s.storage.Delete(context.Background(), key); delErr != nil {
+			log.Printf("⚠️ Failed to clean up partial export %s: %v", key, delErr)
+		}
+		key = ""
+	}
+	s.finishJob(&job, key, res.rows, err)
+}
+
+func (s *exportService) finishJob(job *domain.ExportJob, key string, rows int64, err error) {
+	now := time.Now()
+	job.CompletedAt = &now
+	job.RowCount = rows
+	if err != nil {
+		msg := err.Error()
+		job.Status = domain.ExportJobFailed
+		job.Error = &msg
+		log.Printf("❌ Export job %d (%s) failed: %v", job.ID, job.Report, err)
+	} else {
+		expiresAt := now.Add(s.retention)
+		job.Status = domain.ExportJobCompleted
+		job.FileKey = &key
+		job.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.UpdateJob(context.Background(), job); err != nil {
+		log.Printf("⚠️  Export job %d: %v", job.ID, err)
+	}
+}
+
+func (s *exportService) GetJob(ctx context.Context, id int) (*domain.ExportJob, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.withDownloadURL(job)
+	return job, nil
+}
+
+func (s *exportService) GetJobs(ctx context.Context, adminUUID string) ([]domain.ExportJob, error) {
+	jobs, err := s.repo.GetJobsByRequester(ctx, adminUUID, 50)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		s.withDownloadURL(&jobs[i])
+	}
+	return jobs, nil
+}
+
+func (s *exportService) withDownloadURL(job *domain.ExportJob) {
+	if job.Status == domain.ExportJobCompleted && job.FileKey != nil {
+		job.DownloadURL = fmt.Sprintf("/admin/exports/%d/download", job.ID)
+	}
+}
+
+func (s *exportService) OpenJobFile(ctx context.Context, id int) (*domain.ExportJob, *domain.ExportFile, io.ReadCloser, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	switch {
+	case job.Status == domain.ExportJobFailed:
+		return nil, nil, nil, errors.New("export gagal, silakan buat ulang")
+	case job.Status != domain.ExportJobCompleted:
+		return nil, nil, nil, errors.New("export belum selesai")
+	case job.FileKey == nil || (job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt)):
+		return nil, nil, nil, errors.New("file export sudah kedaluwarsa, silakan buat ulang")
+	}
+
+	rc, err := s.storage.Open(ctx, *job.FileKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	file := exportFile(job.Report, job.Format, job.CreatedAt)
+	file.FileName = job.FileName
+	return job, file, rc, nil
+}
+
+func (s *exportService) FailInterruptedJobs(ctx context.Context) error {
+	n, err := s.repo.FailInterruptedJobs(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("⚠️  Marked %d interrupted export job(s) as failed", n)
+	}
+	return nil
+}
+
+func (s *exportService) PurgeExpiredFiles(ctx context.Context) error {
+	jobs, err := s.repo.GetExpiredJobFiles(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if err := s.storage.Delete(ctx, *job.FileKey); err != nil {
+			log.Printf("⚠️ Failed to delete expired export %s: %v", *job.FileKey, err)
+			continue
+		}
+		job.FileKey = nil
+		if err := s.repo.UpdateJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowWriter is the common face of the CSV and XLSX writers.
+type rowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+// WriteRow formats values like the XLSX writer does. Text that a spreadsheet would run
+// as a formula is prefixed with a quote.
+func (c *csvRowWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch val := v.(type) {
+		case nil:
+		case *time.Time:
+			if val != nil {
+				record[i] = val.Format("2006-01-02 15:04:05")
+			}
+		case time.Time:
+			record[i] = val.Format("2006-01-02 15:04:05")
+		case float64:
+			record[i] = strconv.FormatFloat(val, 'f', -1, 64)
+		case string:
+			if val != "" && strings.ContainsRune("=+-@\t\r", rune(val[0])) {
+				val = "'" + val
+			}
+			record[i] = val
+		default:
+			record[i] = fmt.Sprint(val)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func newRowWriter(w io.Writer, format, sheetName string) (rowWriter, error) {
+	if format == domain.ExportFormatXLSX {
+		return utils.NewXLSXWriter(w, sheetName)
+	}
+	// The BOM makes Excel open the file as UTF-8.
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{w: csv.NewWriter(w)}, nil
+}
+
+// WriteExport writes the header row and every matching record, batch by batch.
+func (s *exportService) WriteExport(ctx context.Context, report string, filter domain.ExportFilter, w io.Writer) (int64, error) {
+	sheetName, ok := exportSheetNames[report]
+	if !ok {
+		return 0, errors.New("laporan tidak ditemukan")
+	}
+	out, err := newRowWriter(w, filter.Format, sheetName)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	write := func(values []interface{}) error {
+		rows++
+		return out.WriteRow(values)
+	}
+
+	switch report {
+	case domain.ExportReportPayments:
+		err = s.writePayments(ctx, filter, write)
+	case domain.ExportReportClassHistories:
+		err = s.writeClassHistories(ctx, filter, write)
+	case domain.ExportReportStudents:
+		err = s.writeUsers(ctx, domain.RoleStudent, filter, write)
+	case domain.ExportReportTeachers:
+		err = s.writeUsers(ctx, domain.RoleTeacher, filter, write)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+	return rows - 1, nil // minus the header
+}
+
+func (s *exportService) local(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.In(s.loc)
+}
+
+func deref(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func (s *exportService) writePayments(ctx context.Context, filter domain.ExportFilter, write func([]interface{}) error) error {
+	if err := write([]interface{}{
+		"ID", "External ID", "Dibuat", "Dibayar", "Nama Siswa", "Email Siswa", "Paket", "Sumber", "Metode",
+		"Channel", "No. Referensi", "Harga Paket", "Diskon", "Kode Voucher", "Jumlah Dibayar", "Dikembalikan", "Status",
+	}); err != nil {
+		return err
+	}
+	return s.repo.StreamPayments(ctx, filter, func(batch []domain.Payment) error {
+		for _, p := range batch {
+			if err := write([]interface{}{
+				p.ID, p.ExternalID, s.local(&p.CreatedAt), s.local(p.PaidAt), p.Student.Name, p.Student.Email,
+				p.Package.Name, p.Source, p.PaymentMethod, p.PaymentChannel, deref(p.ReferenceNumber),
+				p.OriginalAmount, p.DiscountAmount, deref(p.VoucherCode), p.Amount, p.RefundedAmount, p.Status,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *exportService) writeClassHistories(ctx context.Context, filter domain.ExportFilter, write func([]interface{}) error) error {
+	if err := write([]interface{}{
+		"ID", "Tanggal Kelas", "Jam Mulai", "Jam Selesai", "Siswa", "Guru", "Paket", "Instrumen",
+		"Status Booking", "Status Kelas", "Catatan", "Jumlah Dokumentasi",
+	}); err != nil {
+		return err
+	}
+	return s.repo.StreamClassHistories(ctx, filter, func(batch []domain.ClassHistory) error {
+		for _, h := range batch {
+			packageName, instrumentName := "", ""
+			if pkg := h.Booking.PackageUsed.Package; pkg != nil {
+				packageName, instrumentName = pkg.Name, pkg.Instrument.Name
+			}
+			if err := write([]interface{}{
+				h.ID, h.Booking.ClassDate.In(s.loc).Format("2006-01-02"), h.Booking.Schedule.StartTime, h.Booking.Schedule.EndTime,
+				h.Booking.Student.Name, h.Booking.Schedule.Teacher.Name, packageName, instrumentName,
+				h.Booking.Status, h.Status, deref(h.Notes), len(h.Documentations),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *exportService) writeUsers(ctx context.Context, role string, filter domain.ExportFilter, write func([]interface{}) error) error {
+	header := []interface{}{"UUID", "Nama", "Email", "Telepon", "Gender", "Terdaftar", "Dihapus"}
+	if role == domain.RoleTeacher {
+		header = append(header, "Instrumen")
+	}
+	if err := write(header); err != nil {
+		return err
+	}
+	return s.repo.StreamUsers(ctx, role, filter, func(batch []domain.User) error {
+		for _, u := range batch {
+			row := []interface{}{u.UUID, u.Name, u.Email, u.Phone, u.Gender, s.local(&u.CreatedAt), s.local(u.DeletedAt)}
+			if role == domain.RoleTeacher {
+				var instruments []string
+				if u.TeacherProfile != nil {
+					for _, inst := range u.TeacherProfile.Instruments {
+						instruments = append(instruments, inst.Name)
+					}
+				}
+				row = append(row, strings.Join(instruments, ", "))
+			}
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"chronosphere/domain"
+	"context"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var allowedUploadTypes = map[string]map[string]string{
+	domain.FilePurposeProfile: {
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/webp": ".webp",
+	},
+	domain.FilePurposeDocumentation: {
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/webp": ".webp",
+		"video/mp4":  ".mp4",
+	},
+	domain.FilePurposePaymentProof: {
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"image/webp":      ".webp",
+		"application/pdf": ".pdf",
+	},
+}
+
+type fileService struct {
+	repo      domain.FileRepository
+	storage   domain.FileStorage
+	secret    []byte
+	baseURL   string
+	maxBytes  int64
+	signedTTL time.Duration
+}
+
+// fileSigningKeyInfo separates the file URL key derived from JWT_SECRET from any other use
+// of that secret.
+const fileSigningKeyInfo = "chronosphere file url signing v1"
+
+// NewFileService signs file URLs with FILE_SIGNING_SECRET. Without it the key is derived
+// from jwtSecret with HKDF, so a signed URL never reveals anything about the secret that
+// signs access tokens.
+func NewFileService(repo domain.FileRepository, storage domain.FileStorage, jwtSecret string) domain.FileUseCase {
+	maxMB, err := strconv.Atoi(os.Getenv("FILE_MAX_UPLOAD_MB"))
+	if err != nil || maxMB <= 0 {
+		maxMB = domain.DefaultMaxUploadMB
+	}
+
+	ttl := domain.DefaultSignedURLTTL
+	if minutes, err := strconv.Atoi(os.Getenv("FILE_SIGNED_URL_TTL_MINUTES")); err == nil && minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+
+	baseURL := strings.TrimRight(os.Getenv("FILE_PUBLIC_BASE_URL"), "/")
+	if baseURL == "" {
+		port := os.Getenv("APP_PORT")
+		if port == "" {
+			port = "8080"
+		}
+		baseURL = "http://localhost:" + port
+	}
+
+	secret := []byte(os.Getenv("FILE_SIGNING_SECRET"))
+	if len(secret) == 0 || string(secret) == jwtSecret {
+		secret, err = hkdf.Key(sha256.New, []byte(jwtSecret), nil, fileSigningKeyInfo, sha256.Size)
+		if err != nil {
+			log.Fatalf("❌ Failed to derive file signing key: %v", err)
+		}
+	}
+
+	return &fileService{
+		repo:      repo,
+		storage:   storage,
+		secret:    secret,
+		baseURL:   baseURL,
+		maxBytes:  int64(maxMB) << 20,
+		signedTTL: ttl,
+	}
+}
+
+func (s *fileService) Upload(ctx context.Context, ownerUUID, purpose string, header *multipart.FileHeader) (*domain.StoredFile, error) {
+	allowed, ok := allowedUploadTypes[purpose]
+	if !ok {
+		return nil, errors.New("jenis upload tidak valid")
+	}
+	if header == nil || header.Size == 0 {
+		return nil, errors.New("file tidak boleh kosong")
+	}
+	if header.Size > s.maxBytes {
+		return nil, fmt.Errorf("ukuran file melebihi batas %d MB", s.maxBytes>>20)
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file: %w", err)
+	}
+	defer src.Close()
+
+	// The declared Content-Type is client controlled, so the type is sniffed from the bytes.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("gagal membaca file: %w", err)
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	ext, ok := allowed[contentType]
+	if !ok {
+		return nil, fmt.Errorf("tipe file %s tidak diizinkan", contentType)
+	}
+
+	key, err := generateFileKey(purpose, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), src), s.maxBytes)
+	if err := s.storage.Save(ctx, key, body); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan file: %w", err)
+	}
+
+	file := &domain.StoredFile{
+		Key:          key,
+		OwnerUUID:    ownerUUID,
+		Purpose:      purpose,
+		ContentType:  contentType,
+		Size:         header.Size,
+		OriginalName: filepath.Base(header.Filename),
+	}
+	if err := s.repo.Create(ctx, file); err != nil {
+		s.cleanup(key)
+		return nil, err
+	}
+	file.URL = s.fileURL(key)
+
+	if purpose == domain.FilePurposeProfile {
+		if err := s.repo.UpdateUserImage(ctx, ownerUUID, file.URL); err != nil {
+			_ = s.repo.Delete(ctx, file.ID)
+			s.cleanup(key)
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+func (s *fileService) Open(ctx context.Context, key, viewerUUID, viewerRole string) (*domain.StoredFile, io.ReadCloser, error) {
+	file, err := s.authorize(ctx, key, viewerUUID, viewerRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.storage.Open(ctx, file.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, rc, nil
+}
+
+func (s *fileService) OpenSigned(ctx context.Context, key, expires, sig string) (*domain.StoredFile, io.ReadCloser, error) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return nil, nil, errors.New("tautan tidak valid atau sudah kedaluwarsa")
+	}
+	expected := s.sign(key, exp)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return nil, nil, errors.New("tautan tidak valid atau sudah kedaluwarsa")
+	}
+
+	file, err := s.repo.GetByKey(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.storage.Open(ctx, file.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, rc, nil
+}
+
+func (s *fileService) SignURL(ctx context.Context, key, viewerUUID, viewerRole string) (string, time.Time, error) {
+	file, err := s.authorize(ctx, key, viewerUUID, viewerRole)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(s.signedTTL)
+	q := url.Values{}
+	q.Set(domain.SignedURLExpiresParam, strconv.FormatInt(expiresAt.Unix(), 10))
+	q.Set(domain.SignedURLSigParam, s.sign(file.Key, expiresAt.Unix()))
+
+	return s.fileURL(file.Key) + "?" + q.Encode(), expiresAt, nil
+}
+
+// authorize loads the file and checks whether the viewer may see it. Profile images are
+// visible to any signed-in user; class documentation only to the class participants and staff;
+// payment proofs only to the uploader and staff.
+func (s *fileService) authorize(ctx context.Context, key, viewerUUID, viewerRole string) (*domain.StoredFile, error) {
+	file, err := s.repo.GetByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Purpose == domain.FilePurposeProfile {
+		return file, nil
+	}
+	if file.OwnerUUID == viewerUUID || domain.IsStaffRole(viewerRole) {
+		return file, nil
+	}
+
+	ok, err := s.repo.IsDocumentationParticipant(ctx, file.Key, viewerUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("anda tidak memiliki akses ke file ini")
+	}
+	return file, nil
+}
+
+func (s *fileService) fileURL(key string) string {
+	return s.baseURL + domain.FileRoutePrefix + key
+}
+
+func (s *fileService) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "|" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *fileService) cleanup(key string) {
+	if err := s.storage.Delete(context.Background(), key); err != nil {
+		log.Printf("⚠️ Failed to clean up orphan file %s: %v", key, err)
+	}
+}
+
+func generateFileKey(purpose, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("gagal membuat nama file: %w", err)
+	}
+	return fmt.Sprintf("%s/%s/%s%s", purpose, time.Now().Format("2006/01"), hex.EncodeToString(buf), ext), nil
+}
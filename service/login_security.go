@@ -0,0 +1,185 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type loginSecurityService struct {
+	repo      domain.LoginSecurityRepository
+	userRepo  domain.UserRepository
+	audit     domain.AuditRecorder
+	messenger *whatsmeow.Client
+	loc       *time.Location
+}
+
+func NewLoginSecurityService(repo domain.LoginSecurityRepository, userRepo domain.UserRepository, audit domain.AuditRecorder, meow *whatsmeow.Client) domain.LoginSecurityUseCase {
+	loc, err := time.LoadLocation(domain.AnalyticsTimezone)
+	if err != nil {
+		loc = time.FixedZone("WITA", 8*60*60)
+	}
+	return &loginSecurityService{repo: repo, userRepo: userRepo, audit: audit, messenger: meow, loc: loc}
+}
+
+// recordHistory never fails the login it describes; a missing history row is only logged.
+func (s *loginSecurityService) recordHistory(ctx context.Context, email string, userUUID *string, result string, client domain.ClientInfo) {
+	entry := &domain.LoginHistory{
+		UserUUID:  userUUID,
+		Email:     email,
+		IPAddress: client.IPAddress,
+		UserAgent: truncateUserAgent(client.UserAgent),
+		Result:    result,
+	}
+	if err := s.repo.CreateHistory(ctx, entry); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+func (s *loginSecurityService) CheckLock(ctx context.Context, user *domain.User, client domain.ClientInfo) error {
+	lockout, err := s.repo.GetLockout(ctx, user.UUID)
+	if err != nil {
+		return err
+	}
+	if !lockout.IsLocked(time.Now()) {
+		return nil
+	}
+
+	s.recordHistory(ctx, user.Email, &user.UUID, domain.LoginResultLocked, client)
+	return &domain.AccountLockedError{Until: *lockout.LockedUntil}
+}
+
+// RecordFailedLogin logs the attempt and, for a known account, counts it towards the
+// lockout. Every LoginLockThreshold failures lock the account for the next, longer
+// duration.
+func (s *loginSecurityService) RecordFailedLogin(ctx context.Context, email string, user *domain.User, result string, client domain.ClientInfo) error {
+	if user == nil {
+		s.recordHistory(ctx, email, nil, result, client)
+		return nil
+	}
+	s.recordHistory(ctx, email, &user.UUID, result, client)
+
+	now := time.Now()
+	lockout, err := s.repo.UpdateLockout(ctx, user.UUID, func(l *domain.AccountLockout) {
+		l.FailedAttempts++
+		l.LastFailedAt = &now
+		if l.FailedAttempts < domain.LoginLockThreshold {
+			return
+		}
+
+		step := l.LockCount
+		if step >= len(domain.LoginLockDurations) {
+			step = len(domain.LoginLockDurations) - 1
+		}
+		until := now.Add(domain.LoginLockDurations[step])
+		l.LockedUntil = &until
+		l.LockCount++
+		l.FailedAttempts = 0
+	})
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+		return nil
+	}
+
+	if lockout.IsLocked(now) {
+		log.Printf("🔒 Account %s locked until %s after repeated failed logins", user.UUID, lockout.LockedUntil.Format(time.RFC3339))
+		return &domain.AccountLockedError{Until: *lockout.LockedUntil}
+	}
+	return nil
+}
+
+// RecordSuccessfulLogin logs the login. A completed login (tokens issued) resets the
+// lockout and alerts the user when it comes from a device or IP not seen before.
+func (s *loginSecurityService) RecordSuccessfulLogin(ctx context.Context, user *domain.User, result string, client domain.ClientInfo) {
+	if result != domain.LoginResultSuccess {
+		s.recordHistory(ctx, user.Email, &user.UUID, result, client)
+		return
+	}
+
+	userAgent := truncateUserAgent(client.UserAgent)
+	known, err := s.repo.GetKnownLogins(ctx, user.UUID, client.IPAddress, userAgent)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	s.recordHistory(ctx, user.Email, &user.UUID, result, client)
+	if err := s.repo.DeleteLockout(ctx, user.UUID); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	// The very first login has nothing to compare with and isn't worth an alert.
+	if known != nil && known.Total > 0 && (known.SameIP == 0 || known.SameDevice == 0) {
+		s.sendNewDeviceAlert(user, client, time.Now())
+	}
+}
+
+func (s *loginSecurityService) sendNewDeviceAlert(user *domain.User, client domain.ClientInfo, at time.Time) {
+	device := client.UserAgent
+	if device == "" {
+		device = "Tidak diketahui"
+	}
+	msg := fmt.Sprintf(`🔐 *Halo %s!*
+
+Akun anda baru saja login dari perangkat atau jaringan baru.
+
+┣ 🕒 Waktu: %s WITA
+┣ 🌐 IP: %s
+┗ 💻 Perangkat: %s
+
+Jika ini bukan anda, segera ganti password dan keluarkan sesi lain melalui menu keamanan akun.
+
+🌐 Website: %s
+🔔 %s Notification System`,
+		user.Name,
+		at.In(s.loc).Format("02/01/2006 15:04"),
+		client.IPAddress,
+		truncateUserAgent(device),
+		os.Getenv("TARGETED_DOMAIN"),
+		os.Getenv("APP_NAME"))
+
+	phone := utils.NormalizePhoneNumber(user.Phone)
+	go func() {
+		if s.messenger != nil && phone != "" {
+			jid := types.NewJID(phone, types.DefaultUserServer)
+			_, err := s.messenger.SendMessage(context.Background(), jid, &waE2E.Message{Conversation: &msg})
+			if err == nil {
+				log.Printf("🔔 Notifikasi login baru terkirim ke: %s (%s)", user.Name, phone)
+				return
+			}
+			log.Printf("🔕 Gagal mengirim notifikasi login baru via WhatsApp ke %s: %v", user.Name, err)
+		}
+
+		// Fall back to email when WhatsApp isn't connected or the message failed.
+		if err := utils.SendEmail(user.Email, "Login baru ke akun anda", msg); err != nil {
+			log.Printf("🔕 Gagal mengirim notifikasi login baru via email ke %s: %v", user.Email, err)
+		}
+	}()
+}
+
+func (s *loginSecurityService) Unlock(ctx context.Context, userUUID string) error {
+	if _, err := s.userRepo.GetUserByUUID(ctx, userUUID); err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	before, err := s.repo.GetLockout(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteLockout(ctx, userUUID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionUserUnlock, domain.AuditTargetUser, userUUID, before, nil)
+	return nil
+}
+
+func (s *loginSecurityService) GetLoginHistory(ctx context.Context, filter domain.LoginHistoryFilter) ([]domain.LoginHistory, int64, error) {
+	return s.repo.GetLoginHistory(ctx, filter)
+}
@@ -12,15 +12,17 @@ import (
 	"go.mau.fi/whatsmeow/types"
 )
 
-func NewManagerService(managerRepo domain.ManagerRepository, meow *whatsmeow.Client) domain.ManagerUseCase {
+func NewManagerService(managerRepo domain.ManagerRepository, audit domain.AuditRecorder, meow *whatsmeow.Client) domain.ManagerUseCase {
 	return &managerService{
 		managerRepo: managerRepo,
+		audit:       audit,
 		messenger:   meow,
 	}
 }
 
 type managerService struct {
 	managerRepo domain.ManagerRepository
+	audit       domain.AuditRecorder
 	messenger   *whatsmeow.Client
 }
 
@@ -51,11 +53,15 @@ func (s *managerService) GetStudentByUUID(ctx context.Context, uuid string) (*do
 
 // ✅ Modify Student Package Quota
 func (s *managerService) ModifyStudentPackageQuota(ctx context.Context, studentUUID string, packageID int, incomingQuota int) error {
-	data, err := s.managerRepo.ModifyStudentPackageQuota(ctx, studentUUID, packageID, incomingQuota)
+	data, previousQuota, err := s.managerRepo.ModifyStudentPackageQuota(ctx, studentUUID, packageID, incomingQuota)
 	if err != nil {
 		return err
 	}
 
+	s.audit.Record(ctx, domain.AuditActionQuotaModify, domain.AuditTargetUser, studentUUID,
+		map[string]int{"package_id": packageID, "remaining_quota": previousQuota},
+		map[string]int{"package_id": packageID, "remaining_quota": max(incomingQuota, 0)})
+
 	// Send notification to student
 	phoneNormalized := utils.NormalizePhoneNumber(data.Phone)
 	if phoneNormalized != "" && s.messenger != nil {
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// StartOIDCLogin creates the login state and returns the provider URL to send the user to.
+func (s *authService) StartOIDCLogin(ctx context.Context) (*domain.OIDCAuthorization, error) {
+	if s.oidc == nil {
+		return nil, domain.ErrOIDCDisabled
+	}
+
+	state, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+	// 64 hex characters, within the 43-128 PKCE allows.
+	verifier, err := utils.GenerateRandomID(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := s.oidc.AuthorizationURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return nil, fmt.Errorf("login OIDC gagal: %w", err)
+	}
+	if err := s.oidcRepo.SaveLoginState(ctx, state, domain.OIDCLoginState{Nonce: nonce, CodeVerifier: verifier}, domain.OIDCLoginStateTTL); err != nil {
+		return nil, err
+	}
+
+	return &domain.OIDCAuthorization{
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresAt:        time.Now().Add(domain.OIDCLoginStateTTL),
+	}, nil
+}
+
+// CompleteOIDCLogin redeems the provider's code. A known identity logs in its user; an
+// unknown one is linked to the account with the same verified email, or gets a signup to
+// complete when there is none. Lockout and 2FA apply as they do to password logins.
+func (s *authService) CompleteOIDCLogin(ctx context.Context, state, code string, client domain.ClientInfo) (*domain.LoginResult, error) {
+	if s.oidc == nil {
+		return nil, domain.ErrOIDCDisabled
+	}
+
+	loginState, err := s.oidcRepo.ConsumeLoginState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if loginState == nil {
+		return nil, domain.ErrOIDCStateInvalid
+	}
+
+	identity, err := s.oidc.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("login OIDC gagal: %w", err)
+	}
+	if identity.Nonce != loginState.Nonce {
+		return nil, domain.ErrOIDCStateInvalid
+	}
+
+	var user *domain.User
+	linked, err := s.oidcRepo.GetIdentity(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		user, err = s.userRepo.GetUserByUUID(ctx, linked.UserUUID)
+		if err != nil {
+			return nil, domain.ErrUserNotFound
+		}
+	} else {
+		if !identity.EmailVerified || identity.Email == "" {
+			return nil, domain.ErrOIDCEmailUnverified
+		}
+		email := strings.ToLower(identity.Email)
+
+		user, err = s.oidcRepo.FindUserByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return s.beginOIDCSignup(ctx, identity)
+		}
+		if user.DeletedAt == nil {
+			if err := s.oidcRepo.CreateIdentity(ctx, &domain.UserIdentity{
+				Issuer:   identity.Issuer,
+				Subject:  identity.Subject,
+				UserUUID: user.UUID,
+				Email:    email,
+			}); err != nil {
+				return nil, err
+			}
+			log.Printf("🔗 OIDC identity %s linked to user %s", identity.Subject, user.UUID)
+		}
+	}
+
+	if user.DeletedAt != nil {
+		return nil, errors.New("akun anda telah dinonaktifkan, silakan hubungi admin untuk informasi lebih lanjut")
+	}
+	if err := s.loginGuard.CheckLock(ctx, user, client); err != nil {
+		return nil, err
+	}
+
+	result, err := s.finishLogin(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.oidcRepo.TouchIdentity(ctx, identity.Issuer, identity.Subject, time.Now()); err != nil {
+		log.Printf("⚠️ Failed to update OIDC identity %s: %v", identity.Subject, err)
+	}
+	return result, nil
+}
+
+func (s *authService) beginOIDCSignup(ctx context.Context, identity *domain.OIDCIdentity) (*domain.LoginResult, error) {
+	token, err := utils.GenerateRandomID(32)
+	if err != nil {
+		return nil, err
+	}
+
+	signup := &domain.OIDCSignup{
+		Token:     token,
+		Email:     strings.ToLower(identity.Email),
+		Name:      strings.TrimSpace(identity.Name),
+		ExpiresAt: time.Now().Add(domain.OIDCSignupTTL),
+		Identity:  *identity,
+	}
+	if err := s.oidcRepo.SaveSignup(ctx, signup, domain.OIDCSignupTTL); err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{Signup: signup}, nil
+}
+
+// CompleteOIDCSignup creates the student account of a first OIDC login and logs it in.
+// The account gets an unusable random password; the student can set one with the
+// forgot-password flow if they ever want to log in without the provider.
+func (s *authService) CompleteOIDCSignup(ctx context.Context, req domain.OIDCSignupRequest, client domain.ClientInfo) (*domain.LoginResult, error) {
+	if s.oidc == nil {
+		return nil, domain.ErrOIDCDisabled
+	}
+
+	signup, err := s.oidcRepo.GetSignup(ctx, req.SignupToken)
+	if err != nil {
+		return nil, err
+	}
+	if signup == nil {
+		return nil, domain.ErrOIDCSignupInvalid
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = signup.Name
+	}
+	if len(name) < 3 || len(name) > 50 {
+		return nil, errors.New("nama wajib diisi, 3-50 karakter")
+	}
+
+	secret, err := utils.GenerateRandomID(32)
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("gagal mengenkripsi password")
+	}
+
+	user := &domain.User{
+		Name:     name,
+		Email:    signup.Email,
+		Phone:    req.Phone,
+		Gender:   req.Gender,
+		Password: string(hashed),
+		Role:     domain.RoleStudent,
+	}
+	now := time.Now()
+	identity := &domain.UserIdentity{
+		Issuer:      signup.Identity.Issuer,
+		Subject:     signup.Identity.Subject,
+		Email:       signup.Email,
+		LastLoginAt: &now,
+	}
+	if err := s.oidcRepo.CreateStudentWithIdentity(ctx, user, identity); err != nil {
+		return nil, err
+	}
+	if err := s.oidcRepo.DeleteSignup(ctx, req.SignupToken); err != nil {
+		log.Printf("⚠️ Failed to delete OIDC signup: %v", err)
+	}
+	log.Printf("✅ OIDC SIGNUP: Student created - UUID: %s", user.UUID)
+
+	return s.finishLogin(ctx, user, client)
+}
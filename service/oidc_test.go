@@ -0,0 +1,399 @@
+package service
+
+import (
+	"chronosphere/delivery"
+	"chronosphere/domain"
+	"chronosphere/gateway"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testOIDCClientID     = "chronosphere-test"
+	testOIDCClientSecret = "chronosphere-test-secret"
+	testOIDCRedirectURL  = "http://localhost:3000/auth/oidc/callback"
+)
+
+// oidcTestEnv runs the fake issuer's HTTP endpoints on an httptest server and an auth
+// service that logs in against it through the real OIDC client.
+type oidcTestEnv struct {
+	server *httptest.Server
+	svc    domain.AuthUseCase
+	repo   *memoryOIDCRepository
+	users  *memoryUserRepository
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	app := gin.New()
+	server := httptest.NewServer(app)
+	t.Cleanup(server.Close)
+
+	issuer, err := gateway.NewFakeOIDCIssuer(server.URL+domain.OIDCFakeIssuerPath, testOIDCClientID, testOIDCClientSecret, testOIDCRedirectURL)
+	if err != nil {
+		t.Fatalf("failed to create fake issuer: %v", err)
+	}
+	delivery.NewFakeOIDCHandler(app, issuer)
+
+	users := &memoryUserRepository{users: map[string]*domain.User{}}
+	repo := &memoryOIDCRepository{
+		users:      users,
+		states:     map[string]domain.OIDCLoginState{},
+		signups:    map[string]*domain.OIDCSignup{},
+		identities: map[string]*domain.UserIdentity{},
+	}
+	provider := gateway.NewOIDCClient(issuer.Issuer(), testOIDCClientID, testOIDCClientSecret, testOIDCRedirectURL, []string{"openid", "email", "profile"})
+	svc := NewAuthService(users, nil, &memoryRefreshTokenRepository{}, nil, noTwoFactor{}, openLoginGuard{}, noAudit{},
+		provider, repo, "test-access-secret", "test-refresh-secret")
+
+	return &oidcTestEnv{server: server, svc: svc, repo: repo, users: users}
+}
+
+// authorize starts a login and signs in at the fake issuer like a browser would, returning
+// the state and code the provider redirects back with.
+func (e *oidcTestEnv) authorize(t *testing.T, email string, emailVerified bool) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	authorization, err := e.svc.StartOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+	authURL, err := url.Parse(authorization.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization url: %v", err)
+	}
+	if !strings.HasPrefix(authorization.AuthorizationURL, e.server.URL+domain.OIDCFakeIssuerPath+"/authorize?") {
+		t.Fatalf("authorization url %q does not point at the fake issuer", authorization.AuthorizationURL)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	page, err := client.Get(authorization.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("GET authorize: %v", err)
+	}
+	page.Body.Close()
+	if page.StatusCode != http.StatusOK {
+		t.Fatalf("GET authorize: status %d", page.StatusCode)
+	}
+
+	form := authURL.Query()
+	form.Set("email", email)
+	form.Set("name", "Siswa OIDC")
+	if emailVerified {
+		form.Set("email_verified", "true")
+	}
+	resp, err := client.PostForm(e.server.URL+domain.OIDCFakeIssuerPath+"/authorize", form)
+	if err != nil {
+		t.Fatalf("POST authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("POST authorize: status %d", resp.StatusCode)
+	}
+
+	redirect, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	if !strings.HasPrefix(redirect.String(), testOIDCRedirectURL+"?") {
+		t.Fatalf("redirected to %q, want %s", redirect, testOIDCRedirectURL)
+	}
+	if redirect.Query().Get("state") != authorization.State {
+		t.Fatalf("state %q came back as %q", authorization.State, redirect.Query().Get("state"))
+	}
+	return authorization.State, redirect.Query().Get("code")
+}
+
+func TestCompleteOIDCLogin(t *testing.T) {
+	client := domain.ClientInfo{IPAddress: "127.0.0.1", UserAgent: "go-test"}
+
+	t.Run("links an existing account by email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		existing := env.users.add(&domain.User{UUID: "user-1", Name: "Siswa", Email: "Siswa@Example.com", Role: domain.RoleStudent})
+
+		state, code := env.authorize(t, "siswa@example.com", true)
+		result, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client)
+		if err != nil {
+			t.Fatalf("CompleteOIDCLogin: %v", err)
+		}
+		if result.Tokens == nil || result.Tokens.AccessToken == "" {
+			t.Fatalf("expected tokens, got %+v", result)
+		}
+		identity := env.repo.onlyIdentity(t)
+		if identity.UserUUID != existing.UUID {
+			t.Fatalf("identity linked to %q, want %q", identity.UserUUID, existing.UUID)
+		}
+
+		// The second login matches on the linked identity, not the email.
+		state, code = env.authorize(t, "siswa@example.com", true)
+		if _, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client); err != nil {
+			t.Fatalf("second CompleteOIDCLogin: %v", err)
+		}
+		env.repo.onlyIdentity(t)
+	})
+
+	t.Run("offers a signup for an unknown email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+
+		state, code := env.authorize(t, "baru@example.com", true)
+		result, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client)
+		if err != nil {
+			t.Fatalf("CompleteOIDCLogin: %v", err)
+		}
+		if result.Signup == nil || result.Signup.Email != "baru@example.com" || result.Tokens != nil {
+			t.Fatalf("expected a signup, got %+v", result)
+		}
+	})
+
+	t.Run("rejects a nonce mismatch", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.users.add(&domain.User{UUID: "user-1", Name: "Siswa", Email: "siswa@example.com", Role: domain.RoleStudent})
+
+		state, code := env.authorize(t, "siswa@example.com", true)
+		env.repo.tamperState(t, state, func(s *domain.OIDCLoginState) { s.Nonce = "another-nonce" })
+
+		_, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client)
+		if !errors.Is(err, domain.ErrOIDCStateInvalid) {
+			t.Fatalf("got %v, want %v", err, domain.ErrOIDCStateInvalid)
+		}
+		env.repo.noIdentities(t)
+	})
+
+	t.Run("rejects a wrong PKCE verifier", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.users.add(&domain.User{UUID: "user-1", Name: "Siswa", Email: "siswa@example.com", Role: domain.RoleStudent})
+
+		state, code := env.authorize(t, "siswa@example.com", true)
+		env.repo.tamperState(t, state, func(s *domain.OIDCLoginState) { s.CodeVerifier += "x" })
+
+		_, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client)
+		if err == nil || !strings.Contains(err.Error(), "code_verifier does not match") {
+			t.Fatalf("got %v, want a rejected code_verifier", err)
+		}
+		env.repo.noIdentities(t)
+	})
+
+	t.Run("rejects an unverified email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.users.add(&domain.User{UUID: "user-1", Name: "Siswa", Email: "siswa@example.com", Role: domain.RoleStudent})
+
+		state, code := env.authorize(t, "siswa@example.com", false)
+		_, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client)
+		if !errors.Is(err, domain.ErrOIDCEmailUnverified) {
+			t.Fatalf("got %v, want %v", err, domain.ErrOIDCEmailUnverified)
+		}
+		env.repo.noIdentities(t)
+	})
+
+	t.Run("rejects a reused state", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.users.add(&domain.User{UUID: "user-1", Name: "Siswa", Email: "siswa@example.com", Role: domain.RoleStudent})
+
+		state, code := env.authorize(t, "siswa@example.com", true)
+		if _, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client); err != nil {
+			t.Fatalf("CompleteOIDCLogin: %v", err)
+		}
+		_, err := env.svc.CompleteOIDCLogin(context.Background(), state, code, client)
+		if !errors.Is(err, domain.ErrOIDCStateInvalid) {
+			t.Fatalf("got %v, want %v", err, domain.ErrOIDCStateInvalid)
+		}
+	})
+}
+
+type memoryUserRepository struct {
+	domain.UserRepository
+	mu    sync.Mutex
+	users map[string]*domain.User
+}
+
+func (r *memoryUserRepository) add(user *domain.User) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.UUID] = user
+	return user
+}
+
+func (r *memoryUserRepository) GetUserByUUID(ctx context.Context, uuid string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[uuid]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+type memoryOIDCRepository struct {
+	mu         sync.Mutex
+	users      *memoryUserRepository
+	states     map[string]domain.OIDCLoginState
+	signups    map[string]*domain.OIDCSignup
+	identities map[string]*domain.UserIdentity
+}
+
+func (r *memoryOIDCRepository) tamperState(t *testing.T, state string, change func(*domain.OIDCLoginState)) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.states[state]
+	if !ok {
+		t.Fatalf("no login state saved for %q", state)
+	}
+	change(&data)
+	r.states[state] = data
+}
+
+func (r *memoryOIDCRepository) onlyIdentity(t *testing.T) *domain.UserIdentity {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.identities) != 1 {
+		t.Fatalf("got %d linked identities, want 1", len(r.identities))
+	}
+	for _, identity := range r.identities {
+		return identity
+	}
+	return nil
+}
+
+func (r *memoryOIDCRepository) noIdentities(t *testing.T) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.identities) != 0 {
+		t.Fatalf("got %d linked identities, want none", len(r.identities))
+	}
+}
+
+func (r *memoryOIDCRepository) SaveLoginState(ctx context.Context, state string, data domain.OIDCLoginState, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state] = data
+	return nil
+}
+
+func (r *memoryOIDCRepository) ConsumeLoginState(ctx context.Context, state string) (*domain.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.states[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.states, state)
+	return &data, nil
+}
+
+func (r *memoryOIDCRepository) SaveSignup(ctx context.Context, signup *domain.OIDCSignup, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.signups[signup.Token] = signup
+	return nil
+}
+
+func (r *memoryOIDCRepository) GetSignup(ctx context.Context, token string) (*domain.OIDCSignup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.signups[token], nil
+}
+
+func (r *memoryOIDCRepository) DeleteSignup(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.signups, token)
+	return nil
+}
+
+func (r *memoryOIDCRepository) GetIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.identities[issuer+" "+subject], nil
+}
+
+func (r *memoryOIDCRepository) CreateIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := identity.Issuer + " " + identity.Subject
+	if _, ok := r.identities[key]; ok {
+		return errors.New("identity already linked")
+	}
+	r.identities[key] = identity
+	return nil
+}
+
+func (r *memoryOIDCRepository) TouchIdentity(ctx context.Context, issuer, subject string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if identity, ok := r.identities[issuer+" "+subject]; ok {
+		identity.LastLoginAt = &at
+	}
+	return nil
+}
+
+// FindUserByEmail ignores case like the database query does.
+func (r *memoryOIDCRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.users.mu.Lock()
+	defer r.users.mu.Unlock()
+	for _, user := range r.users.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryOIDCRepository) CreateStudentWithIdentity(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error {
+	if existing, _ := r.FindUserByEmail(ctx, user.Email); existing != nil {
+		return errors.New("email atau nomor telepon sudah digunakan")
+	}
+	user.UUID = "user-" + identity.Subject
+	identity.UserUUID = user.UUID
+	r.users.add(user)
+	return r.CreateIdentity(ctx, identity)
+}
+
+type memoryRefreshTokenRepository struct {
+	domain.RefreshTokenRepository
+}
+
+func (memoryRefreshTokenRepository) CreateFamily(ctx context.Context, family *domain.RefreshTokenFamily, ttl time.Duration) error {
+	return nil
+}
+
+type noTwoFactor struct {
+	domain.TwoFactorUseCase
+}
+
+func (noTwoFactor) LoginChallenge(ctx context.Context, user *domain.User) (*domain.TwoFactorChallenge, error) {
+	return nil, nil
+}
+
+type openLoginGuard struct {
+	domain.LoginSecurityUseCase
+}
+
+func (openLoginGuard) CheckLock(ctx context.Context, user *domain.User, client domain.ClientInfo) error {
+	return nil
+}
+
+func (openLoginGuard) RecordSuccessfulLogin(ctx context.Context, user *domain.User, result string, client domain.ClientInfo) {
+}
+
+type noAudit struct{}
+
+func (noAudit) Record(ctx context.Context, action, targetType, targetID string, before, after interface{}) {
+}
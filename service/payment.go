@@ -7,42 +7,52 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/xendit/xendit-go/v6"
-	"github.com/xendit/xendit-go/v6/invoice"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type paymentService struct {
-	paymentRepo  domain.PaymentRepository
-	studentRepo  domain.StudentRepository
-	xenditClient *xendit.APIClient
-	db           *gorm.DB
-	messenger    *whatsmeow.Client
+	paymentRepo domain.PaymentRepository
+	studentRepo domain.StudentRepository
+	gateway     domain.PaymentGateway
+	receipts    domain.ReceiptUseCase
+	settings    domain.SettingUseCase
+	audit       domain.AuditRecorder
+	db          *gorm.DB
+	messenger   *whatsmeow.Client
+	// attachReceiptWA / attachReceiptEmail send the PDF receipt along with the
+	// payment-success notification.
+	attachReceiptWA    bool
+	attachReceiptEmail bool
 	// shutdownCtx is cancelled when the app begins graceful shutdown.
 	// WA goroutines respect this so they don’t get orphaned.
 	shutdownCtx context.Context
 }
 
-func NewPaymentService(paymentRepo domain.PaymentRepository, studentRepo domain.StudentRepository, db *gorm.DB, messenger *whatsmeow.Client) domain.PaymentUseCase {
-	xenditKey := os.Getenv("XENDIT_API_KEY")
-	if xenditKey == "" {
-		fmt.Println("WARNING: XENDIT_API_KEY is not set")
-	}
-
-	client := xendit.NewClient(xenditKey)
+func NewPaymentService(paymentRepo domain.PaymentRepository, studentRepo domain.StudentRepository, gateway domain.PaymentGateway, receipts domain.ReceiptUseCase, settings domain.SettingUseCase, audit domain.AuditRecorder, db *gorm.DB, messenger *whatsmeow.Client) domain.PaymentUseCase {
+	attachWA, _ := strconv.ParseBool(os.Getenv("RECEIPT_ATTACH_WHATSAPP"))
+	attachEmail, _ := strconv.ParseBool(os.Getenv("RECEIPT_ATTACH_EMAIL"))
 
 	return &paymentService{
-		paymentRepo:  paymentRepo,
-		studentRepo:  studentRepo,
-		xenditClient: client,
-		db:           db,
-		messenger:    messenger,
+		paymentRepo:        paymentRepo,
+		studentRepo:        studentRepo,
+		gateway:            gateway,
+		receipts:           receipts,
+		settings:           settings,
+		audit:              audit,
+		db:                 db,
+		messenger:          messenger,
+		attachReceiptWA:    attachWA,
+		attachReceiptEmail: attachEmail,
 		// Default to background; replaced via SetShutdownContext during bootstrap.
 		shutdownCtx: context.Background(),
 	}
@@ -56,6 +66,18 @@ func (s *paymentService) SetShutdownContext(ctx context.Context) {
 	s.shutdownCtx = ctx
 }
 
+func checkoutResponse(payment *domain.Payment, reused bool) *domain.CheckoutResponse {
+	return &domain.CheckoutResponse{
+		InvoiceURL:     payment.InvoiceURL,
+		ExternalID:     payment.ExternalID,
+		OriginalAmount: payment.OriginalAmount,
+		DiscountAmount: payment.DiscountAmount,
+		Amount:         payment.Amount,
+		ExpiresAt:      payment.ExpiresAt,
+		Reused:         reused,
+	}
+}
+
 func (s *paymentService) CreateInvoice(ctx context.Context, studentUUID string, req domain.CheckoutRequest) (*domain.CheckoutResponse, error) {
 	// 1. Get Package details
 	var pkg domain.Package
@@ -71,54 +93,218 @@ func (s *paymentService) CreateInvoice(ctx context.Context, studentUUID string,
 		return nil, errors.New("student not found")
 	}
 
-	// 2. Create Payment Record (Pending)
-	externalID := fmt.Sprintf("invoice-%s-%d-%d", studentUUID, req.PackageID, time.Now().Unix())
+	var voucherCode *string
+	if req.VoucherCode != "" {
+		code := domain.NormalizeVoucherCode(req.VoucherCode)
+		voucherCode = &code
+	}
 
-	payment := &domain.Payment{
-		ExternalID:  externalID,
-		StudentUUID: studentUUID,
-		PackageID:   req.PackageID,
-		Amount:      pkg.Price,
-		Status:      domain.PaymentStatusPending,
+	// Serialise checkouts per student until the payment row exists, so a double-click waits
+	// for the first request and then finds its payment. The lock ends with lockTx, before
+	// the gateway call: holding a pooled connection for an outbound request would let a
+	// slow gateway exhaust the pool.
+	lockTx := s.db.WithContext(ctx).Begin()
+	if lockTx.Error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", lockTx.Error)
+	}
+	defer lockTx.Rollback()
+	if err := lockTx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "checkout:"+studentUUID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock checkout: %w", err)
+	}
+
+	// 2. Replay a request we have already seen.
+	now := time.Now()
+	inFlightSince := now.Add(-domain.CheckoutInFlightWindow)
+	if req.IdempotencyKey != "" {
+		existing, err := s.paymentRepo.FindByIdempotencyKey(ctx, studentUUID, req.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			if existing.PackageID != req.PackageID || !sameVoucher(existing.VoucherCode, voucherCode) {
+				return nil, errors.New("Idempotency-Key sudah digunakan untuk checkout lain")
+			}
+			if existing.InvoiceURL == "" {
+				if existing.Status == domain.PaymentStatusPending && existing.CreatedAt.After(inFlightSince) {
+					return nil, domain.ErrCheckoutInProgress
+				}
+				return nil, errors.New("checkout dengan Idempotency-Key ini sebelumnya gagal, gunakan key baru")
+			}
+			return checkoutResponse(existing, true), nil
+		}
+	}
+
+	// 3. Hand back an open invoice for the same package instead of creating another one.
+	reusable, err := s.paymentRepo.FindReusablePending(ctx, studentUUID, req.PackageID, pkg.CurrentVersionID, voucherCode, now.Add(domain.InvoiceReuseMinRemaining), inFlightSince)
+	if err != nil {
+		return nil, err
+	}
+	if reusable != nil {
+		if reusable.InvoiceURL == "" {
+			return nil, domain.ErrCheckoutInProgress
+		}
+		return checkoutResponse(reusable, true), nil
 	}
 
-	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+	// 4. Enforce the admin cap on open invoices.
+	maxOpen, err := s.settings.GetInt(ctx, domain.SettingMaxOpenInvoices)
+	if err != nil {
 		return nil, err
 	}
+	if maxOpen > 0 {
+		open, err := s.paymentRepo.CountOpenInvoices(ctx, studentUUID, now)
+		if err != nil {
+			return nil, err
+		}
+		if open >= int64(maxOpen) {
+			return nil, fmt.Errorf("batas invoice terbuka tercapai (%d), selesaikan atau batalkan invoice yang masih aktif", maxOpen)
+		}
+	}
 
-	// 3. Call Xendit API
-	createInvoiceRequest := *invoice.NewCreateInvoiceRequest(externalID, pkg.Price)
-	createInvoiceRequest.SetPayerEmail(student.Email)
-	createInvoiceRequest.SetDescription(fmt.Sprintf("Payment for %s", pkg.Name))
+	// 5. Create Payment Record (Pending)
+	externalID := fmt.Sprintf("invoice-%s-%d-%d", studentUUID, req.PackageID, now.UnixNano())
+
+	payment := &domain.Payment{
+		ExternalID:       externalID,
+		StudentUUID:      studentUUID,
+		PackageID:        req.PackageID,
+		PackageVersionID: pkg.CurrentVersionID,
+		OriginalAmount:   pkg.Price,
+		Amount:           pkg.Price,
+		Status:           domain.PaymentStatusPending,
+		Source:           domain.PaymentSourceGateway,
+	}
+	if req.IdempotencyKey != "" {
+		payment.IdempotencyKey = &req.IdempotencyKey
+	}
 
-	// Add success redirect URL if needed, maybe from env
-	// createInvoiceRequest.SetSuccessRedirectUrl("https://your-frontend.com/success")
+	if voucherCode == nil {
+		if err := s.paymentRepo.Create(ctx, payment); err != nil {
+			return nil, err
+		}
+	} else if err := s.createPaymentWithVoucher(ctx, payment, *voucherCode, &pkg); err != nil {
+		return nil, err
+	}
 
-	resp, _, err := s.xenditClient.InvoiceApi.CreateInvoice(ctx).CreateInvoiceRequest(createInvoiceRequest).Execute()
+	// The PENDING row is committed, so later checkouts see it; release the lock.
+	lockTx.Rollback()
+
+	// 6. Call the payment gateway
+	inv, err := s.gateway.CreateInvoice(ctx, domain.GatewayInvoiceRequest{
+		ExternalID:         externalID,
+		Amount:             payment.Amount,
+		PayerEmail:         student.Email,
+		Description:        fmt.Sprintf("Payment for %s", pkg.Name),
+		SuccessRedirectURL: os.Getenv("PAYMENT_SUCCESS_REDIRECT_URL"),
+		FailureRedirectURL: os.Getenv("PAYMENT_FAILURE_REDIRECT_URL"),
+	})
 	if err != nil {
 		// Update status to FAILED
 		s.paymentRepo.UpdateStatus(ctx, externalID, domain.PaymentStatusFailed, nil)
-		return nil, fmt.Errorf("xendit error: %v", err)
+		return nil, fmt.Errorf("%s error: %v", s.gateway.Name(), err)
 	}
 
-	// 4. Update Payment with Invoice URL and Xendit Invoice ID.
-	payment.InvoiceURL = resp.InvoiceUrl
-	payment.XenditInvoiceID = *resp.Id
+	// 7. Update Payment with Invoice URL, the provider invoice ID and its expiry.
+	payment.InvoiceURL = inv.InvoiceURL
+	payment.XenditInvoiceID = &inv.ID
+	if !inv.ExpiresAt.IsZero() {
+		payment.ExpiresAt = &inv.ExpiresAt
+	}
 
-	if err := s.db.WithContext(ctx).Save(payment).Error; err != nil {
+	// Only write the invoice fields: the row is visible now, and a callback may already
+	// have moved it on.
+	if err := s.db.WithContext(ctx).Model(payment).Updates(map[string]interface{}{
+		"invoice_url":       payment.InvoiceURL,
+		"xendit_invoice_id": payment.XenditInvoiceID,
+		"expires_at":        payment.ExpiresAt,
+	}).Error; err != nil {
 		return nil, err
 	}
 
-	return &domain.CheckoutResponse{
-		InvoiceURL: resp.InvoiceUrl,
-		ExternalID: externalID,
-	}, nil
+	return checkoutResponse(payment, false), nil
 }
 
-func (s *paymentService) HandleCallback(ctx context.Context, payload *invoice.Invoice) error {
+func sameVoucher(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// createPaymentWithVoucher applies the voucher and reserves one use of it together with
+// the payment. The voucher row is locked for the whole transaction, so concurrent
+// checkouts can't both take the last use.
+func (s *paymentService) createPaymentWithVoucher(ctx context.Context, payment *domain.Payment, code string, pkg *domain.Package) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		voucher, discount, err := applyVoucher(tx, code, payment.StudentUUID, pkg, true)
+		if err != nil {
+			return err
+		}
+
+		payment.DiscountAmount = discount
+		payment.Amount = pkg.Price - discount
+		payment.VoucherID = &voucher.ID
+		payment.VoucherCode = &voucher.Code
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&domain.VoucherRedemption{
+			VoucherID:   voucher.ID,
+			PaymentID:   payment.ID,
+			StudentUUID: payment.StudentUUID,
+			Discount:    discount,
+			Status:      domain.RedemptionReserved,
+		}).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan penggunaan voucher: %w", err)
+		}
+
+		// The WHERE guard keeps the global limit even if the row lock is ever dropped.
+		result := tx.Model(&domain.Voucher{}).
+			Where("id = ? AND (max_redemptions IS NULL OR redeemed_count < max_redemptions)", voucher.ID).
+			UpdateColumn("redeemed_count", gorm.Expr("redeemed_count + 1"))
+		if result.Error != nil {
+			return fmt.Errorf("gagal memperbarui kuota voucher: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("kuota voucher sudah habis")
+		}
+		return nil
+	})
+}
+
+// redeemVoucherRedemption confirms the voucher use of a paid payment. A use that was
+// released when the payment was cancelled or expired is counted against the voucher again,
+// since the student did get the discount; a voucher already at its limit goes over it
+// rather than leaving the paid discount uncounted.
+func redeemVoucherRedemption(tx *gorm.DB, paymentID int) error {
+	var redemption domain.VoucherRedemption
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("payment_id = ? AND status <> ?", paymentID, domain.RedemptionRedeemed).
+		First(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch voucher redemption: %w", err)
+	}
+
+	if redemption.Status == domain.RedemptionReleased {
+		if err := tx.Model(&domain.Voucher{}).Where("id = ?", redemption.VoucherID).
+			UpdateColumn("redeemed_count", gorm.Expr("redeemed_count + 1")).Error; err != nil {
+			return fmt.Errorf("failed to confirm voucher redemption: %w", err)
+		}
+	}
+	if err := tx.Model(&redemption).Update("status", domain.RedemptionRedeemed).Error; err != nil {
+		return fmt.Errorf("failed to confirm voucher redemption: %w", err)
+	}
+	return nil
+}
+
+func (s *paymentService) HandleCallback(ctx context.Context, payload *domain.PaymentCallback) error {
 
 	// 1. Verify payment exists and has both Student + Package preloaded.
-	payment, err := s.paymentRepo.FindByExternalID(ctx, payload.ExternalId)
+	payment, err := s.paymentRepo.FindByExternalID(ctx, payload.ExternalID)
 	if err != nil {
 		return err
 	}
@@ -126,12 +312,12 @@ func (s *paymentService) HandleCallback(ctx context.Context, payload *invoice.In
 		return errors.New("payment not found")
 	}
 
-	// 2. Idempotency guard — already processed.
-	if payment.Status == domain.PaymentStatusPaid {
+	// 2. Idempotency guard — already processed (a refunded payment must never be re-activated).
+	if domain.IsSettledPaymentStatus(payment.Status) {
 		return nil
 	}
 
-	if string(payload.Status) == "PAID" || string(payload.Status) == "SETTLED" {
+	if payload.Status == "PAID" || payload.Status == "SETTLED" {
 		// Guard: Package must be loaded; if preload silently failed we’d panic below.
 		if payment.Package.ID == 0 {
 			return fmt.Errorf("package (id=%d) not loaded for payment %s — preload may have failed",
@@ -148,14 +334,45 @@ func (s *paymentService) HandleCallback(ctx context.Context, payload *invoice.In
 			}
 		}()
 
-		// Update payment status.
-		now := time.Now()
-		payment.Status = domain.PaymentStatusPaid
-		payment.PaidAt = &now
-		if err := tx.Save(payment).Error; err != nil {
+		// Lock the row so a webhook and the reconciler can't both activate the same payment.
+		var locked domain.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", payment.ID).
+			First(&locked).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
+		if domain.IsSettledPaymentStatus(locked.Status) {
+			tx.Rollback()
+			return nil
+		}
+
+		// The money arrived, so a payment cancelled or expired in the meantime is still
+		// activated; its released voucher use is taken back below.
+		if locked.Status != domain.PaymentStatusPending {
+			log.Printf("⚠️  Payment %s paid after it was %s, activating anyway", locked.ExternalID, locked.Status)
+		}
+
+		// Update payment status. Only the paid fields are written: payment was read before
+		// the lock and must not overwrite anything that changed since.
+		// A late callback or a reconciliation run reports when the student actually paid.
+		paidAt := time.Now()
+		if !payload.PaidAt.IsZero() && payload.PaidAt.Before(paidAt) {
+			paidAt = payload.PaidAt
+		}
+		if err := tx.Model(&locked).Updates(map[string]interface{}{
+			"status":          domain.PaymentStatusPaid,
+			"paid_at":         paidAt,
+			"payment_method":  payload.PaymentMethod,
+			"payment_channel": payload.PaymentChannel,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		payment.Status = domain.PaymentStatusPaid
+		payment.PaidAt = &paidAt
+		payment.PaymentMethod = payload.PaymentMethod
+		payment.PaymentChannel = payload.PaymentChannel
 
 		// Ensure student profile exists.
 		var count int64
@@ -167,12 +384,17 @@ func (s *paymentService) HandleCallback(ctx context.Context, payload *invoice.In
 			}
 		}
 
+		// The student gets the terms of the version they were invoiced for, even if the
+		// package was edited while the invoice was open.
+		purchased := payment.PurchasedPackage()
 		studentPackage := domain.StudentPackage{
-			StudentUUID:    payment.StudentUUID,
-			PackageID:      payment.PackageID,
-			RemainingQuota: payment.Package.Quota,
-			StartDate:      now,
-			EndDate:        now.AddDate(0, 0, payment.Package.ExpiredDuration),
+			StudentUUID:      payment.StudentUUID,
+			PackageID:        payment.PackageID,
+			PackageVersionID: payment.PackageVersionID,
+			RemainingQuota:   purchased.Quota,
+			StartDate:        paidAt,
+			EndDate:          paidAt.AddDate(0, 0, purchased.ExpiredDuration),
+			PaymentID:        &payment.ID,
 		}
 
 		if err := tx.Create(&studentPackage).Error; err != nil {
@@ -180,15 +402,18 @@ func (s *paymentService) HandleCallback(ctx context.Context, payload *invoice.In
 			return err
 		}
 
-		if err := tx.Commit().Error; err != nil {
+		if err := redeemVoucherRedemption(tx, payment.ID); err != nil {
+			tx.Rollback()
 			return err
 		}
 
-		if s.messenger != nil {
-			s.sendPaymentSuccessNotification(&payment.Student, &payment.Package)
+		if err := tx.Commit().Error; err != nil {
+			return err
 		}
 
-	} else if string(payload.Status) == "EXPIRED" {
+		s.notifyPaymentActivated(ctx, payment)
+
+	} else if payload.Status == "EXPIRED" {
 		s.paymentRepo.UpdateStatus(ctx, payment.ExternalID, domain.PaymentStatusExpired, nil)
 	} else {
 		s.paymentRepo.UpdateStatus(ctx, payment.ExternalID, domain.PaymentStatusFailed, nil)
@@ -197,7 +422,253 @@ func (s *paymentService) HandleCallback(ctx context.Context, payload *invoice.In
 	return nil
 }
 
-func (s *paymentService) sendPaymentSuccessNotification(student *domain.User, pkg *domain.Package) {
+// notifyPaymentActivated issues the receipt of a freshly paid payment and tells the student.
+// Payment must have Student and Package loaded.
+func (s *paymentService) notifyPaymentActivated(ctx context.Context, payment *domain.Payment) {
+	// Number the receipt now so receipt numbers follow payment order.
+	var receipt *domain.ReceiptDocument
+	var err error
+	if s.attachReceiptWA || s.attachReceiptEmail {
+		receipt, err = s.receipts.GetReceiptPDF(ctx, payment.ID)
+	} else {
+		_, err = s.receipts.IssueReceipt(ctx, payment.ID)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to issue receipt for payment %s: %v", payment.ExternalID, err)
+	}
+
+	purchased := payment.PurchasedPackage()
+	if s.messenger != nil {
+		var waReceipt *domain.ReceiptDocument
+		if s.attachReceiptWA {
+			waReceipt = receipt
+		}
+		s.sendPaymentSuccessNotification(&payment.Student, &purchased, waReceipt)
+	}
+	if s.attachReceiptEmail && receipt != nil && payment.Student.Email != "" {
+		s.sendReceiptEmail(&payment.Student, &purchased, receipt)
+	}
+}
+
+// RecordOfflinePayment records a payment taken at the studio (cash, manual transfer, EDC...)
+// and activates the package in the same transaction, so it counts as revenue like any
+// gateway payment.
+func (s *paymentService) RecordOfflinePayment(ctx context.Context, staffUUID string, req domain.OfflinePaymentRequest) (*domain.Payment, error) {
+	req.ReferenceNumber = strings.TrimSpace(req.ReferenceNumber)
+	req.Notes = strings.TrimSpace(req.Notes)
+	if req.Method != domain.OfflineMethodCash && req.ReferenceNumber == "" {
+		return nil, errors.New("nomor referensi wajib diisi untuk metode selain CASH")
+	}
+
+	now := time.Now()
+	paidAt := now
+	if req.PaidAt != nil {
+		if req.PaidAt.After(now) {
+			return nil, errors.New("tanggal pembayaran tidak valid: tidak boleh di masa depan")
+		}
+		paidAt = *req.PaidAt
+	}
+
+	payment := &domain.Payment{
+		ExternalID:    fmt.Sprintf("offline-%s-%d-%d", req.StudentUUID, req.PackageID, now.UnixNano()),
+		StudentUUID:   req.StudentUUID,
+		PackageID:     req.PackageID,
+		Amount:        req.Amount,
+		Status:        domain.PaymentStatusPaid,
+		PaymentMethod: req.Method,
+		Source:        domain.PaymentSourceOffline,
+		ProofFileKey:  req.ProofFileKey,
+		RecordedBy:    &staffUUID,
+		PaidAt:        &paidAt,
+	}
+	if req.ReferenceNumber != "" {
+		payment.ReferenceNumber = &req.ReferenceNumber
+	}
+	if req.Notes != "" {
+		payment.Notes = &req.Notes
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("uuid = ? AND role = ? AND deleted_at IS NULL", req.StudentUUID, domain.RoleStudent).
+			First(&payment.Student).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("siswa tidak ditemukan")
+			}
+			return err
+		}
+		if err := tx.Preload("Instrument").Where("id = ? AND deleted_at IS NULL", req.PackageID).
+			First(&payment.Package).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("paket tidak ditemukan")
+			}
+			return err
+		}
+		payment.PackageVersionID = payment.Package.CurrentVersionID
+		payment.OriginalAmount = payment.Package.Price
+		payment.DiscountAmount = math.Max(payment.Package.Price-req.Amount, 0)
+
+		// The same slip must not be recorded twice, e.g. by two staff at once.
+		if payment.ReferenceNumber != nil {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "offline-payment:"+req.Method+":"+req.ReferenceNumber).Error; err != nil {
+				return err
+			}
+			var dup int64
+			if err := tx.Model(&domain.Payment{}).
+				Where("source = ? AND payment_method = ? AND reference_number = ?", domain.PaymentSourceOffline, req.Method, req.ReferenceNumber).
+				Count(&dup).Error; err != nil {
+				return err
+			}
+			if dup > 0 {
+				return errors.New("pembayaran dengan nomor referensi ini sudah ada")
+			}
+		}
+
+		if err := tx.Omit("Student", "Package").Create(payment).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		tx.Model(&domain.StudentProfile{}).Where("user_uuid = ?", payment.StudentUUID).Count(&count)
+		if count == 0 {
+			if err := tx.Create(&domain.StudentProfile{UserUUID: payment.StudentUUID}).Error; err != nil {
+				return fmt.Errorf("failed to create student profile: %w", err)
+			}
+		}
+
+		return tx.Create(&domain.StudentPackage{
+			StudentUUID:      payment.StudentUUID,
+			PackageID:        payment.PackageID,
+			PackageVersionID: payment.PackageVersionID,
+			RemainingQuota:   payment.Package.Quota,
+			StartDate:        now,
+			EndDate:          now.AddDate(0, 0, payment.Package.ExpiredDuration),
+			PaymentID:        &payment.ID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, domain.AuditActionPaymentOffline, domain.AuditTargetPayment, strconv.Itoa(payment.ID), nil, payment)
+	s.notifyPaymentActivated(ctx, payment)
+	return payment, nil
+}
+
+func (s *paymentService) GetMyPayments(ctx context.Context, studentUUID string, filter domain.StudentPaymentFilter) ([]domain.Payment, int64, error) {
+	return s.paymentRepo.GetPaymentsByStudent(ctx, studentUUID, filter)
+}
+
+// findOwnPayment loads a payment and checks that it belongs to the student.
+func (s *paymentService) findOwnPayment(ctx context.Context, studentUUID string, id int) (*domain.Payment, error) {
+	payment, err := s.paymentRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.StudentUUID != studentUUID {
+		return nil, errors.New("anda tidak memiliki akses ke pembayaran ini")
+	}
+	return payment, nil
+}
+
+func (s *paymentService) GetMyPayment(ctx context.Context, studentUUID string, id int) (*domain.Payment, error) {
+	payment, err := s.findOwnPayment(ctx, studentUUID, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusPending || payment.XenditInvoiceID == nil {
+		return payment, nil
+	}
+
+	// A gateway outage should not hide the payment; the stored invoice is still shown.
+	inv, err := s.gateway.GetInvoice(ctx, *payment.XenditInvoiceID)
+	if err != nil {
+		log.Printf("⚠️ failed to refresh invoice %s: %v", payment.ExternalID, err)
+		return payment, nil
+	}
+
+	if err := s.applyGatewayInvoice(ctx, payment, inv); err != nil {
+		log.Printf("⚠️ failed to apply invoice status for %s: %v", payment.ExternalID, err)
+		return payment, nil
+	}
+	return s.paymentRepo.FindByID(ctx, id)
+}
+
+func (s *paymentService) CancelMyPayment(ctx context.Context, studentUUID string, id int) (*domain.Payment, error) {
+	payment, err := s.findOwnPayment(ctx, studentUUID, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusPending {
+		return nil, fmt.Errorf("hanya pembayaran berstatus PENDING yang dapat dibatalkan (status saat ini: %s)", payment.Status)
+	}
+
+	// Close the invoice at the gateway first so the link can no longer be paid. If that
+	// fails, the invoice may have been paid just now; check before giving up.
+	if payment.XenditInvoiceID != nil {
+		if _, err := s.gateway.ExpireInvoice(ctx, *payment.XenditInvoiceID); err != nil {
+			inv, getErr := s.gateway.GetInvoice(ctx, *payment.XenditInvoiceID)
+			if getErr != nil {
+				return nil, fmt.Errorf("gagal membatalkan invoice: %v", err)
+			}
+			switch inv.Status {
+			case domain.PaymentStatusPaid:
+				if err := s.applyGatewayInvoice(ctx, payment, inv); err != nil {
+					return nil, err
+				}
+				return nil, errors.New("pembayaran sudah lunas dan tidak dapat dibatalkan")
+			case domain.PaymentStatusExpired, domain.PaymentStatusFailed:
+				// Already expired or failed at the gateway; cancelling locally is safe.
+			default:
+				return nil, fmt.Errorf("gagal membatalkan invoice: %v", err)
+			}
+		}
+	}
+
+	if _, err := s.paymentRepo.CancelPending(ctx, payment.ID); err != nil {
+		return nil, err
+	}
+	return s.paymentRepo.FindByID(ctx, id)
+}
+
+// applyGatewayInvoice brings a pending payment in line with its gateway invoice, the
+// same way the reconciler does: paid invoices activate the package, expired ones close.
+func (s *paymentService) applyGatewayInvoice(ctx context.Context, payment *domain.Payment, inv *domain.GatewayInvoice) error {
+	switch inv.Status {
+	case domain.PaymentStatusPending:
+		expiresAt := inv.ExpiresAt
+		if inv.InvoiceURL == "" || (inv.InvoiceURL == payment.InvoiceURL && payment.ExpiresAt != nil && payment.ExpiresAt.Equal(expiresAt)) {
+			return nil
+		}
+		return s.db.WithContext(ctx).Model(&domain.Payment{}).
+			Where("id = ? AND status = ?", payment.ID, domain.PaymentStatusPending).
+			Updates(map[string]interface{}{"invoice_url": inv.InvoiceURL, "expires_at": expiresAt}).Error
+
+	case domain.PaymentStatusPaid:
+		// Never activate a package for the wrong amount; the reconciler reports it for review.
+		if math.Abs(inv.Amount-payment.Amount) > 0.009 {
+			return fmt.Errorf("gateway amount %.2f does not match local amount %.2f", inv.Amount, payment.Amount)
+		}
+		callback := &domain.PaymentCallback{
+			ID:             inv.ID,
+			ExternalID:     payment.ExternalID,
+			Status:         domain.PaymentStatusPaid,
+			Amount:         inv.Amount,
+			PaymentMethod:  inv.PaymentMethod,
+			PaymentChannel: inv.PaymentChannel,
+		}
+		if inv.PaidAt != nil {
+			callback.PaidAt = *inv.PaidAt
+		}
+		return s.HandleCallback(ctx, callback)
+
+	case domain.PaymentStatusExpired:
+		_, err := s.paymentRepo.UpdateStatus(ctx, payment.ExternalID, domain.PaymentStatusExpired, nil)
+		return err
+	}
+	return nil
+}
+
+func (s *paymentService) sendPaymentSuccessNotification(student *domain.User, pkg *domain.Package, receipt *domain.ReceiptDocument) {
 	// Normalize phone number
 	studentPhone := utils.NormalizePhoneNumber(student.Phone)
 	studentJID := types.NewJID(studentPhone, types.DefaultUserServer)
@@ -251,5 +722,59 @@ Terima kasih telah memilih MadEU! 🌟
 			log.Printf("🔔 Notifikasi WhatsApp berhasil dikirim ke: %s (%s)",
 				student.Name, student.Phone)
 		}
+
+		if receipt != nil {
+			s.sendReceiptDocument(shutdownCtx, studentJID, student, receipt)
+		}
+	}()
+}
+
+// sendReceiptDocument uploads the PDF receipt to WhatsApp and sends it as a document.
+func (s *paymentService) sendReceiptDocument(ctx context.Context, jid types.JID, student *domain.User, receipt *domain.ReceiptDocument) {
+	uploaded, err := s.messenger.Upload(ctx, receipt.Content, whatsmeow.MediaDocument)
+	if err != nil {
+		log.Printf("🔕 Gagal mengunggah kwitansi %s untuk %s: %v", receipt.Receipt.Number, student.Name, err)
+		return
+	}
+
+	caption := "🧾 Kwitansi pembayaran " + receipt.Receipt.Number
+	mimetype := domain.ReceiptContentType
+	fileName := receipt.FileName
+	msg := &waE2E.Message{
+		DocumentMessage: &waE2E.DocumentMessage{
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      &mimetype,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    &uploaded.FileLength,
+			FileName:      &fileName,
+			Title:         &fileName,
+			Caption:       &caption,
+		},
+	}
+
+	if _, err := s.messenger.SendMessage(ctx, jid, msg); err != nil {
+		log.Printf("🔕 Gagal mengirim kwitansi %s ke %s: %v", receipt.Receipt.Number, student.Name, err)
+		return
+	}
+	log.Printf("🧾 Kwitansi %s terkirim ke: %s", receipt.Receipt.Number, student.Name)
+}
+
+func (s *paymentService) sendReceiptEmail(student *domain.User, pkg *domain.Package, receipt *domain.ReceiptDocument) {
+	subject := fmt.Sprintf("Kwitansi Pembayaran %s - %s", receipt.Receipt.Number, os.Getenv("APP_NAME"))
+	body := fmt.Sprintf(`Halo %s,
+
+Pembayaran untuk paket "%s" telah kami terima dan paket kamu sudah aktif.
+Kwitansi pembayaran (%s) terlampir pada email ini.
+
+Terima kasih,
+%s`, student.Name, pkg.Name, receipt.Receipt.Number, os.Getenv("APP_NAME"))
+
+	go func() {
+		if err := utils.SendEmailWithAttachment(student.Email, subject, body, receipt.FileName, domain.ReceiptContentType, receipt.Content); err != nil {
+			log.Printf("🔕 Gagal mengirim email kwitansi ke %s: %v", student.Email, err)
+		}
 	}()
 }
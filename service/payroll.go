@@ -0,0 +1,269 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type payrollService struct {
+	repo  domain.PayrollRepository
+	audit domain.AuditRecorder
+}
+
+func NewPayrollService(repo domain.PayrollRepository, audit domain.AuditRecorder) domain.PayrollUseCase {
+	return &payrollService{repo: repo, audit: audit}
+}
+
+func validatePayRate(rate *domain.TeacherPayRate) error {
+	if rate.CompletedRate < 0 || rate.NoShowRate < 0 || rate.LateCancelRate < 0 {
+		return errors.New("tarif tidak boleh bernilai negatif")
+	}
+	if rate.PackageDuration != nil && *rate.PackageDuration != 30 && *rate.PackageDuration != 60 {
+		return errors.New("durasi paket harus 30 atau 60 menit")
+	}
+	return nil
+}
+
+func (s *payrollService) CreatePayRate(ctx context.Context, rate *domain.TeacherPayRate) (*domain.TeacherPayRate, error) {
+	if err := validatePayRate(rate); err != nil {
+		return nil, err
+	}
+	created, err := s.repo.CreatePayRate(ctx, rate)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, domain.AuditActionPayRateCreate, domain.AuditTargetPayRate, strconv.Itoa(created.ID), nil, created)
+	return created, nil
+}
+
+func (s *payrollService) GetAllPayRates(ctx context.Context) ([]domain.TeacherPayRate, error) {
+	return s.repo.GetAllPayRates(ctx)
+}
+
+func (s *payrollService) UpdatePayRate(ctx context.Context, rate *domain.TeacherPayRate) error {
+	if err := validatePayRate(rate); err != nil {
+		return err
+	}
+	before := s.findPayRate(ctx, rate.ID)
+	if err := s.repo.UpdatePayRate(ctx, rate); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionPayRateUpdate, domain.AuditTargetPayRate, strconv.Itoa(rate.ID), before, rate)
+	return nil
+}
+
+func (s *payrollService) DeletePayRate(ctx context.Context, id int) error {
+	before := s.findPayRate(ctx, id)
+	if err := s.repo.DeletePayRate(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionPayRateDelete, domain.AuditTargetPayRate, strconv.Itoa(id), before, nil)
+	return nil
+}
+
+// findPayRate looks up a pay rate for the audit log's before value.
+func (s *payrollService) findPayRate(ctx context.Context, id int) *domain.TeacherPayRate {
+	rates, err := s.repo.GetAllPayRates(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range rates {
+		if rates[i].ID == id {
+			return &rates[i]
+		}
+	}
+	return nil
+}
+
+func (s *payrollService) PreviewPayroll(ctx context.Context, req domain.PayrollPeriodRequest) (*domain.PayrollPreview, error) {
+	start, end, err := parsePayrollPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.calculate(ctx, start, end)
+}
+
+func (s *payrollService) RunPayroll(ctx context.Context, adminUUID string, req domain.PayrollPeriodRequest) (*domain.PayrollRun, error) {
+	start, end, err := parsePayrollPeriod(req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.calculate(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.MissingRateFor) > 0 {
+		return nil, fmt.Errorf("tarif belum diatur untuk guru: %s", strings.Join(preview.MissingRateFor, ", "))
+	}
+	if len(preview.Payslips) == 0 {
+		return nil, errors.New("tidak ada kelas yang perlu dibayarkan pada periode ini")
+	}
+
+	now := time.Now()
+	run := &domain.PayrollRun{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Status:      domain.PayrollStatusLocked,
+		TotalAmount: preview.TotalAmount,
+		CreatedBy:   adminUUID,
+		LockedAt:    now,
+		Payslips:    preview.Payslips,
+	}
+
+	if err := s.repo.CreatePayrollRun(ctx, run); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, domain.AuditActionPayrollRun, domain.AuditTargetPayrollRun, strconv.Itoa(run.ID), nil, map[string]interface{}{
+		"period_start": run.PeriodStart,
+		"period_end":   run.PeriodEnd,
+		"total_amount": run.TotalAmount,
+		"payslips":     len(run.Payslips),
+	})
+	return run, nil
+}
+
+func (s *payrollService) GetAllPayrollRuns(ctx context.Context) ([]domain.PayrollRun, error) {
+	return s.repo.GetAllPayrollRuns(ctx)
+}
+
+func (s *payrollService) GetPayrollRunByID(ctx context.Context, id int) (*domain.PayrollRun, error) {
+	return s.repo.GetPayrollRunByID(ctx, id)
+}
+
+func (s *payrollService) GetMyPayslips(ctx context.Context, teacherUUID string) ([]domain.Payslip, error) {
+	return s.repo.GetPayslipsByTeacher(ctx, teacherUUID)
+}
+
+// calculate builds one payslip per teacher from the unpaid lessons in the period.
+func (s *payrollService) calculate(ctx context.Context, start, end time.Time) (*domain.PayrollPreview, error) {
+	lessons, err := s.repo.GetUnpaidLessons(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.repo.GetAllPayRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &domain.PayrollPreview{
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Payslips:       []domain.Payslip{},
+		MissingRateFor: []string{},
+	}
+
+	slipIndex := make(map[string]int)
+	missing := make(map[string]bool)
+
+	for _, lesson := range lessons {
+		payoutType := classifyLesson(lesson)
+		if payoutType == "" {
+			continue
+		}
+
+		rate := pickPayRate(rates, lesson)
+		if rate == nil {
+			if !missing[lesson.TeacherUUID] {
+				missing[lesson.TeacherUUID] = true
+				preview.MissingRateFor = append(preview.MissingRateFor, lesson.TeacherName)
+			}
+			continue
+		}
+
+		amount := rate.RateFor(payoutType)
+
+		idx, ok := slipIndex[lesson.TeacherUUID]
+		if !ok {
+			preview.Payslips = append(preview.Payslips, domain.Payslip{
+				TeacherUUID: lesson.TeacherUUID,
+				Teacher:     domain.User{UUID: lesson.TeacherUUID, Name: lesson.TeacherName},
+				Status:      domain.PayrollStatusLocked,
+				Items:       []domain.PayslipItem{},
+			})
+			idx = len(preview.Payslips) - 1
+			slipIndex[lesson.TeacherUUID] = idx
+		}
+
+		slip := &preview.Payslips[idx]
+		slip.Items = append(slip.Items, domain.PayslipItem{
+			ClassHistoryID: lesson.ClassHistoryID,
+			BookingID:      lesson.BookingID,
+			ClassDate:      lesson.ClassDate,
+			StudentName:    lesson.StudentName,
+			InstrumentName: lesson.InstrumentName,
+			Duration:       lesson.Duration,
+			PayoutType:     payoutType,
+			PayRateID:      rate.ID,
+			Amount:         amount,
+		})
+		slip.TotalLessons++
+		slip.TotalAmount += amount
+		preview.TotalAmount += amount
+	}
+
+	return preview, nil
+}
+
+// classifyLesson returns the payout type for a lesson, or "" when the teacher isn't paid for it.
+func classifyLesson(lesson domain.PayableLesson) string {
+	switch lesson.HistoryStatus {
+	case domain.StatusCompleted:
+		return domain.PayoutCompleted
+	case domain.StatusNoShow:
+		return domain.PayoutNoShow
+	case domain.StatusCancelled:
+		// Only student cancellations close to the class are compensated.
+		if lesson.CanceledBy == nil || *lesson.CanceledBy != lesson.StudentUUID || lesson.CancelledAt == nil {
+			return ""
+		}
+		if lesson.CancelledAt.After(lesson.ClassDate.Add(-domain.LateCancelWindow)) {
+			return domain.PayoutLateCancel
+		}
+	}
+	return ""
+}
+
+// pickPayRate returns the most specific rule matching the lesson; the newest rule wins a tie.
+func pickPayRate(rates []domain.TeacherPayRate, lesson domain.PayableLesson) *domain.TeacherPayRate {
+	candidates := make([]*domain.TeacherPayRate, 0, len(rates))
+	for i := range rates {
+		if rates[i].Matches(lesson.TeacherUUID, lesson.InstrumentID, lesson.Duration) {
+			candidates = append(candidates, &rates[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		si, sj := candidates[i].Specificity(), candidates[j].Specificity()
+		if si != sj {
+			return si > sj
+		}
+		return candidates[i].ID > candidates[j].ID
+	})
+	return candidates[0]
+}
+
+func parsePayrollPeriod(req domain.PayrollPeriodRequest) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", req.PeriodStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("format period_start harus YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", req.PeriodEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("format period_end harus YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("period_end tidak boleh sebelum period_start")
+	}
+	return start, end, nil
+}
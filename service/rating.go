@@ -0,0 +1,187 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const teacherRecentCommentLimit = 10
+
+type ratingService struct {
+	repo           domain.RatingRepository
+	messenger      *whatsmeow.Client
+	windowDays     int
+	alertThreshold int
+}
+
+func NewRatingService(repo domain.RatingRepository, meow *whatsmeow.Client) domain.RatingUseCase {
+	windowDays, err := strconv.Atoi(os.Getenv("RATING_WINDOW_DAYS"))
+	if err != nil || windowDays <= 0 {
+		windowDays = domain.DefaultRatingWindowDays
+	}
+
+	threshold, err := strconv.Atoi(os.Getenv("RATING_ALERT_THRESHOLD"))
+	if err != nil || threshold < domain.RatingMin || threshold > domain.RatingMax {
+		threshold = domain.DefaultLowRatingThreshold
+	}
+
+	return &ratingService{
+		repo:           repo,
+		messenger:      meow,
+		windowDays:     windowDays,
+		alertThreshold: threshold,
+	}
+}
+
+func (s *ratingService) SubmitRating(ctx context.Context, studentUUID string, classHistoryID int, rating int, comment *string) (*domain.ClassRating, error) {
+	if rating < domain.RatingMin || rating > domain.RatingMax {
+		return nil, fmt.Errorf("nilai harus antara %d dan %d", domain.RatingMin, domain.RatingMax)
+	}
+
+	history, err := s.repo.GetClassHistoryForRating(ctx, classHistoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if history.Booking.StudentUUID != studentUUID {
+		return nil, errors.New("anda tidak memiliki akses ke kelas ini")
+	}
+
+	if history.Status != domain.StatusCompleted || history.Booking.Status != domain.StatusCompleted {
+		return nil, errors.New("hanya kelas yang sudah selesai yang dapat dinilai")
+	}
+
+	finishedAt := history.CreatedAt
+	if history.Booking.CompletedAt != nil {
+		finishedAt = *history.Booking.CompletedAt
+	}
+	if time.Since(finishedAt) > time.Duration(s.windowDays)*24*time.Hour {
+		return nil, fmt.Errorf("batas waktu penilaian (%d hari) sudah lewat", s.windowDays)
+	}
+
+	if comment != nil {
+		trimmed := strings.TrimSpace(*comment)
+		if trimmed == "" {
+			comment = nil
+		} else {
+			comment = &trimmed
+		}
+	}
+
+	newRating := &domain.ClassRating{
+		ClassHistoryID: history.ID,
+		StudentUUID:    studentUUID,
+		TeacherUUID:    history.Booking.Schedule.TeacherUUID,
+		Rating:         rating,
+		Comment:        comment,
+	}
+
+	if err := s.repo.CreateRating(ctx, newRating); err != nil {
+		return nil, err
+	}
+
+	if rating <= s.alertThreshold {
+		s.sendLowRatingAlert(history, newRating)
+	}
+
+	return newRating, nil
+}
+
+func (s *ratingService) GetMyRatings(ctx context.Context, studentUUID string) ([]domain.ClassRating, error) {
+	return s.repo.GetRatingsByStudent(ctx, studentUUID)
+}
+
+func (s *ratingService) GetMyRatingSummary(ctx context.Context, teacherUUID string) (*domain.TeacherRatingSummary, error) {
+	return s.repo.GetTeacherRatingSummary(ctx, teacherUUID, teacherRecentCommentLimit)
+}
+
+func (s *ratingService) GetTeacherRatingAverages(ctx context.Context, filter domain.RatingFilter) ([]domain.TeacherRatingAverage, error) {
+	return s.repo.GetTeacherRatingAverages(ctx, filter, s.alertThreshold)
+}
+
+func (s *ratingService) GetTeacherRatingTrend(ctx context.Context, teacherUUID string, filter domain.RatingFilter) ([]domain.RatingTrendPoint, error) {
+	if teacherUUID == "" {
+		return nil, errors.New("uuid guru tidak boleh kosong")
+	}
+	return s.repo.GetTeacherRatingTrend(ctx, teacherUUID, filter)
+}
+
+func (s *ratingService) sendLowRatingAlert(history *domain.ClassHistory, rating *domain.ClassRating) {
+	if s.messenger == nil {
+		return
+	}
+
+	// Managers are looked up outside the request context so the alert still goes out
+	// after the handler has returned.
+	managers, err := s.repo.GetActiveManagers(context.Background())
+	if err != nil {
+		log.Printf("🔕 Gagal mengambil data manager untuk notifikasi rating: %v", err)
+		return
+	}
+
+	comment := "-"
+	if rating.Comment != nil {
+		comment = *rating.Comment
+	}
+
+	loc, _ := time.LoadLocation("Asia/Makassar") // WITA timezone
+	classDate := history.Booking.ClassDate.In(loc)
+
+	instrumentName := "-"
+	if history.Booking.PackageUsed.Package != nil {
+		instrumentName = history.Booking.PackageUsed.Package.Instrument.Name
+	}
+
+	msg := fmt.Sprintf(`*PERINGATAN RATING RENDAH*
+
+Sebuah kelas mendapatkan penilaian rendah:
+👨‍🏫 *Guru:* %s
+👤 *Siswa:* %s
+🎵 *Instrument:* %s
+📅 *Tanggal:* %s, %s
+⭐ *Nilai:* %d/%d
+
+*Komentar:* %s
+
+Mohon ditindaklanjuti.
+
+🌐 Website: %s
+🔔 %s Notification System`,
+		history.Booking.Schedule.Teacher.Name,
+		history.Booking.Student.Name,
+		instrumentName,
+		utils.GetDayName(classDate.Weekday()),
+		classDate.Format("02/01/2006"),
+		rating.Rating,
+		domain.RatingMax,
+		comment,
+		os.Getenv("TARGETED_DOMAIN"),
+		os.Getenv("APP_NAME"))
+
+	go func() {
+		for _, manager := range managers {
+			phone := utils.NormalizePhoneNumber(manager.Phone)
+			if phone == "" {
+				continue
+			}
+			jid := types.NewJID(phone, types.DefaultUserServer)
+			if _, err := s.messenger.SendMessage(context.Background(), jid, &waE2E.Message{Conversation: &msg}); err != nil {
+				log.Printf("🔕 Failed to send low rating alert to manager %s: %v", phone, err)
+			} else {
+				log.Printf("🔔 Low rating alert sent to manager: %s", manager.Name)
+			}
+		}
+	}()
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type studioInfo struct {
+	name    string
+	address string
+	phone   string
+	email   string
+	website string
+}
+
+type receiptService struct {
+	repo   domain.ReceiptRepository
+	studio studioInfo
+}
+
+func NewReceiptService(repo domain.ReceiptRepository) domain.ReceiptUseCase {
+	name := os.Getenv("STUDIO_NAME")
+	if name == "" {
+		name = os.Getenv("APP_NAME")
+	}
+
+	return &receiptService{
+		repo: repo,
+		studio: studioInfo{
+			name:    name,
+			address: os.Getenv("STUDIO_ADDRESS"),
+			phone:   os.Getenv("STUDIO_PHONE"),
+			email:   os.Getenv("STUDIO_EMAIL"),
+			website: os.Getenv("TARGETED_DOMAIN"),
+		},
+	}
+}
+
+func (s *receiptService) IssueReceipt(ctx context.Context, paymentID int) (*domain.Receipt, error) {
+	payment, err := s.repo.GetPaymentForReceipt(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, payment)
+}
+
+func (s *receiptService) issue(ctx context.Context, payment *domain.Payment) (*domain.Receipt, error) {
+	if !domain.IsSettledPaymentStatus(payment.Status) {
+		return nil, errors.New("kwitansi hanya tersedia untuk pembayaran yang sudah lunas")
+	}
+
+	issuedAt := time.Now()
+	if payment.PaidAt != nil {
+		issuedAt = *payment.PaidAt
+	}
+	return s.repo.GetOrCreate(ctx, payment.ID, issuedAt)
+}
+
+func (s *receiptService) GetReceiptPDF(ctx context.Context, paymentID int) (*domain.ReceiptDocument, error) {
+	payment, err := s.repo.GetPaymentForReceipt(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(ctx, payment)
+}
+
+func (s *receiptService) GetStudentReceiptPDF(ctx context.Context, studentUUID string, paymentID int) (*domain.ReceiptDocument, error) {
+	payment, err := s.repo.GetPaymentForReceipt(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.StudentUUID != studentUUID {
+		return nil, errors.New("anda tidak memiliki akses ke pembayaran ini")
+	}
+	return s.render(ctx, payment)
+}
+
+func (s *receiptService) render(ctx context.Context, payment *domain.Payment) (*domain.ReceiptDocument, error) {
+	receipt, err := s.issue(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := renderReceiptPDF(s.studio, payment, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ReceiptDocument{
+		Receipt:  *receipt,
+		FileName: receipt.Number + ".pdf",
+		Content:  content,
+	}, nil
+}
+
+func formatIndonesianDate(t time.Time) string {
+	return fmt.Sprintf("%02d %s %d", t.Day(), utils.GetMonthName(t.Month()), t.Year())
+}
+
+// fitText shortens text with an ellipsis until it fits in maxWidth points.
+func fitText(text string, maxWidth, size float64, bold bool) string {
+	if utils.PDFTextWidth(text, size, bold) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && utils.PDFTextWidth(string(runes)+"...", size, bold) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func receiptStatusLabel(status string) string {
+	switch status {
+	case domain.PaymentStatusPartiallyRefunded:
+		return "LUNAS - REFUND SEBAGIAN"
+	case domain.PaymentStatusRefunded:
+		return "DIREFUND"
+	}
+	return "LUNAS"
+}
+
+func renderReceiptPDF(studio studioInfo, payment *domain.Payment, receipt *domain.Receipt) ([]byte, error) {
+	const (
+		left  = 50.0
+		right = utils.PDFPageWidth - 50
+	)
+
+	loc, _ := time.LoadLocation("Asia/Makassar") // WITA timezone
+	paidAt := receipt.IssuedAt.In(loc)
+	if payment.PaidAt != nil {
+		paidAt = payment.PaidAt.In(loc)
+	}
+
+	doc := utils.NewPDFDocument("Kwitansi " + receipt.Number)
+
+	// Studio header
+	doc.SetColor(0.1, 0.1, 0.1)
+	doc.Text(left, 60, 18, true, fitText(studio.name, 300, 18, true))
+	y := 78.0
+	for _, line := range []string{studio.address, strings.Trim(studio.phone+" | "+studio.email, " |"), studio.website} {
+		if line == "" {
+			continue
+		}
+		doc.Text(left, y, 9, false, fitText(line, 300, 9, false))
+		y += 12
+	}
+
+	doc.TextRight(right, 60, 20, true, "KWITANSI")
+	doc.TextRight(right, 78, 10, false, "No. "+receipt.Number)
+	doc.TextRight(right, 91, 10, false, "Tanggal: "+formatIndonesianDate(paidAt))
+
+	doc.Line(left, 125, right, 125, 1)
+
+	// Payer and status
+	doc.Text(left, 150, 9, true, "DITERIMA DARI")
+	doc.Text(left, 165, 11, true, fitText(payment.Student.Name, 260, 11, true))
+	doc.Text(left, 178, 9, false, fitText(payment.Student.Email, 260, 9, false))
+	doc.Text(left, 190, 9, false, payment.Student.Phone)
+
+	doc.Text(330, 150, 9, true, "STATUS")
+	doc.Text(330, 165, 11, true, receiptStatusLabel(payment.Status))
+	doc.Text(330, 178, 9, false, "No. Invoice:")
+	doc.Text(330, 190, 8, false, fitText(payment.ExternalID, right-330, 8, false))
+
+	// Item table
+	doc.SetColor(0.93, 0.93, 0.93)
+	doc.FillRect(left, 215, right-left, 22)
+	doc.SetColor(0.1, 0.1, 0.1)
+	doc.Text(left+8, 230, 9, true, "DESKRIPSI")
+	doc.TextRight(400, 230, 9, true, "JUMLAH KELAS")
+	doc.TextRight(right-8, 230, 9, true, "HARGA")
+
+	original := payment.OriginalAmount
+	if original == 0 {
+		// Payments made before vouchers existed only recorded the charged amount.
+		original = payment.Amount + payment.DiscountAmount
+	}
+
+	// The receipt shows the terms the student bought, not the package as it is today.
+	pkg := payment.PurchasedPackage()
+	doc.Text(left+8, 258, 10, true, fitText(pkg.Name, 250, 10, true))
+	detail := fmt.Sprintf("%d menit / sesi, masa aktif %d hari", pkg.Duration, pkg.ExpiredDuration)
+	if pkg.Instrument.Name != "" {
+		detail = pkg.Instrument.Name + " - " + detail
+	}
+	doc.Text(left+8, 271, 8, false, fitText(detail, 250, 8, false))
+	doc.TextRight(400, 258, 10, false, fmt.Sprintf("%d sesi", pkg.Quota))
+	doc.TextRight(right-8, 258, 10, false, formatRupiah(original))
+
+	doc.Line(left, 285, right, 285, 0.5)
+
+	// Totals
+	y = 305
+	summary := func(label, value string, bold bool) {
+		doc.TextRight(400, y, 10, bold, label)
+		doc.TextRight(right-8, y, 10, bold, value)
+		y += 16
+	}
+	summary("Subtotal", formatRupiah(original), false)
+	if payment.DiscountAmount > 0 {
+		label := "Diskon"
+		if payment.VoucherCode != nil {
+			label = fmt.Sprintf("Diskon (%s)", *payment.VoucherCode)
+		}
+		summary(label, "- "+formatRupiah(payment.DiscountAmount), false)
+	}
+	summary("Total Dibayar", formatRupiah(payment.Amount), true)
+	if payment.RefundedAmount > 0 {
+		summary("Dikembalikan", "- "+formatRupiah(payment.RefundedAmount), false)
+	}
+
+	// Payment details
+	y += 14
+	method := strings.Trim(payment.PaymentMethod+" - "+payment.PaymentChannel, " -")
+	if method == "" {
+		method = "-"
+	}
+	if payment.ReferenceNumber != nil {
+		method += " (Ref. " + *payment.ReferenceNumber + ")"
+	}
+	method = fitText(method, right-left-130, 9, false)
+	doc.Text(left, y, 9, true, "METODE PEMBAYARAN")
+	doc.Text(left+130, y, 9, false, method)
+	doc.Text(left, y+14, 9, true, "DIBAYAR PADA")
+	doc.Text(left+130, y+14, 9, false, formatIndonesianDate(paidAt)+", "+paidAt.Format("15:04")+" WITA")
+
+	// Footer
+	doc.Line(left, 770, right, 770, 0.5)
+	doc.SetColor(0.45, 0.45, 0.45)
+	doc.Text(left, 785, 8, false, "Kwitansi ini dibuat secara otomatis dan sah tanpa tanda tangan.")
+	if studio.name != "" {
+		doc.TextRight(right, 785, 8, false, "Terima kasih telah belajar bersama "+studio.name)
+	}
+
+	return doc.Bytes()
+}
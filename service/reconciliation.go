@@ -0,0 +1,227 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type reconciliationService struct {
+	repo        domain.ReconciliationRepository
+	paymentRepo domain.PaymentRepository
+	paymentUC   domain.PaymentUseCase
+	gateway     domain.PaymentGateway
+	staleAfter  time.Duration
+	// running keeps a scheduled run and a manual run from overlapping in one process.
+	running sync.Mutex
+}
+
+func NewReconciliationService(repo domain.ReconciliationRepository, paymentRepo domain.PaymentRepository, paymentUC domain.PaymentUseCase, gateway domain.PaymentGateway) domain.ReconciliationUseCase {
+	staleAfter := domain.DefaultReconcileStaleAfter
+	if minutes, err := strconv.Atoi(os.Getenv("PAYMENT_RECONCILE_STALE_MINUTES")); err == nil && minutes > 0 {
+		staleAfter = time.Duration(minutes) * time.Minute
+	}
+
+	return &reconciliationService{
+		repo:        repo,
+		paymentRepo: paymentRepo,
+		paymentUC:   paymentUC,
+		gateway:     gateway,
+		staleAfter:  staleAfter,
+	}
+}
+
+// StartPaymentReconciler runs the reconciler every PAYMENT_RECONCILE_INTERVAL_MINUTES
+// (default 15) until ctx is cancelled. A negative interval disables it.
+func StartPaymentReconciler(ctx context.Context, uc domain.ReconciliationUseCase) {
+	interval := domain.DefaultReconcileInterval
+	if raw := os.Getenv("PAYMENT_RECONCILE_INTERVAL_MINUTES"); raw != "" {
+		minutes, err := strconv.Atoi(raw)
+		if err == nil && minutes < 0 {
+			log.Println("⚠️  Payment reconciler disabled")
+			return
+		}
+		if err == nil && minutes > 0 {
+			interval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run, err := uc.ReconcilePendingPayments(ctx, domain.ReconcileTriggerScheduled, nil)
+				if err != nil {
+					log.Printf("❌ Payment reconciliation failed: %v", err)
+					continue
+				}
+				if run.Checked > 0 {
+					log.Printf("🔁 Payment reconciliation: checked=%d activated=%d expired=%d discrepancies=%d",
+						run.Checked, run.Activated, run.Expired, run.Discrepancies)
+				}
+			}
+		}
+	}()
+}
+
+func (s *reconciliationService) ReconcilePendingPayments(ctx context.Context, trigger string, triggeredBy *string) (*domain.ReconciliationRun, error) {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	run := &domain.ReconciliationRun{
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+		Items:       []domain.PaymentDiscrepancy{},
+	}
+
+	payments, err := s.paymentRepo.GetStalePendingPayments(ctx, run.StartedAt.Add(-s.staleAfter), domain.ReconcileBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		run.Checked++
+		s.reconcileOne(ctx, run, payment)
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Discrepancies = len(run.Items)
+
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *reconciliationService) reconcileOne(ctx context.Context, run *domain.ReconciliationRun, payment domain.Payment) {
+	report := func(kind, gatewayStatus string, gatewayAmount float64, detail string, resolved bool) {
+		run.Items = append(run.Items, domain.PaymentDiscrepancy{
+			PaymentID:     payment.ID,
+			ExternalID:    payment.ExternalID,
+			Type:          kind,
+			LocalStatus:   payment.Status,
+			GatewayStatus: gatewayStatus,
+			LocalAmount:   payment.Amount,
+			GatewayAmount: gatewayAmount,
+			Detail:        detail,
+			Resolved:      resolved,
+		})
+	}
+
+	if payment.XenditInvoiceID == nil || *payment.XenditInvoiceID == "" {
+		// Nothing can ever be paid against it, so close it instead of reporting it every run.
+		if _, err := s.paymentRepo.UpdateStatus(ctx, payment.ExternalID, domain.PaymentStatusFailed, nil); err != nil {
+			if errors.Is(err, domain.ErrPaymentNotPending) {
+				return // settled by a callback while this run was checking it
+			}
+			report(domain.DiscrepancyMissingInvoice, "", 0, "payment has no gateway invoice id; failed to mark failed: "+err.Error(), false)
+			return
+		}
+		run.Failed++
+		report(domain.DiscrepancyMissingInvoice, "", 0, "payment has no gateway invoice id; marked FAILED", true)
+		return
+	}
+
+	inv, err := s.gateway.GetInvoice(ctx, *payment.XenditInvoiceID)
+	if err != nil {
+		report(domain.DiscrepancyGatewayError, "", 0, err.Error(), false)
+		return
+	}
+	// The gateway answered with a status we understand, so an earlier lookup failure no
+	// longer needs attention.
+	if knownGatewayStatus(inv.Status) {
+		if err := s.repo.ResolveDiscrepancies(ctx, payment.ID, domain.DiscrepancyGatewayError); err != nil {
+			log.Printf("⚠️  Reconciliation: %v", err)
+		}
+	}
+
+	switch inv.Status {
+	case domain.PaymentStatusPending:
+		return
+
+	case domain.PaymentStatusPaid:
+		// Never activate a package for the wrong amount; leave it for an admin to review.
+		if math.Abs(inv.Amount-payment.Amount) > 0.009 {
+			report(domain.DiscrepancyAmountMismatch, inv.Status, inv.Amount,
+				fmt.Sprintf("gateway amount %.2f does not match local amount %.2f", inv.Amount, payment.Amount), false)
+			return
+		}
+
+		callback := &domain.PaymentCallback{
+			ID:             inv.ID,
+			ExternalID:     payment.ExternalID,
+			Status:         domain.PaymentStatusPaid,
+			Amount:         inv.Amount,
+			PaymentMethod:  inv.PaymentMethod,
+			PaymentChannel: inv.PaymentChannel,
+		}
+		if inv.PaidAt != nil {
+			callback.PaidAt = *inv.PaidAt
+		}
+
+		if err := s.paymentUC.HandleCallback(ctx, callback); err != nil {
+			report(domain.DiscrepancyPaidNotActivated, inv.Status, inv.Amount, "activation failed: "+err.Error(), false)
+			return
+		}
+		run.Activated++
+		report(domain.DiscrepancyPaidNotActivated, inv.Status, inv.Amount, "callback was missed; package activated by reconciler", true)
+
+	case domain.PaymentStatusExpired:
+		if _, err := s.paymentRepo.UpdateStatus(ctx, payment.ExternalID, domain.PaymentStatusExpired, nil); err != nil {
+			if errors.Is(err, domain.ErrPaymentNotPending) {
+				return // settled by a callback while this run was checking it
+			}
+			report(domain.DiscrepancyGatewayError, inv.Status, inv.Amount, "failed to mark expired: "+err.Error(), false)
+			return
+		}
+		run.Expired++
+
+	case domain.PaymentStatusFailed:
+		if _, err := s.paymentRepo.UpdateStatus(ctx, payment.ExternalID, domain.PaymentStatusFailed, nil); err != nil {
+			if errors.Is(err, domain.ErrPaymentNotPending) {
+				return // settled by a callback while this run was checking it
+			}
+			report(domain.DiscrepancyGatewayError, inv.Status, inv.Amount, "failed to mark failed: "+err.Error(), false)
+			return
+		}
+		run.Failed++
+
+	default:
+		// The invoice may well have been paid; leave the payment PENDING for an admin.
+		report(domain.DiscrepancyGatewayError, inv.Status, inv.Amount,
+			fmt.Sprintf("unknown gateway status %q; payment left PENDING", inv.Status), false)
+	}
+}
+
+func knownGatewayStatus(status string) bool {
+	switch status {
+	case domain.PaymentStatusPending, domain.PaymentStatusPaid, domain.PaymentStatusExpired, domain.PaymentStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (s *reconciliationService) GetReconciliationRuns(ctx context.Context, page, limit int) ([]domain.ReconciliationRun, int64, error) {
+	return s.repo.GetRuns(ctx, page, limit)
+}
+
+func (s *reconciliationService) GetReconciliationRunByID(ctx context.Context, id int) (*domain.ReconciliationRun, error) {
+	return s.repo.GetRunByID(ctx, id)
+}
+
+func (s *reconciliationService) GetDiscrepancies(ctx context.Context, filter domain.DiscrepancyFilter) ([]domain.PaymentDiscrepancy, int64, error) {
+	return s.repo.GetDiscrepancies(ctx, filter)
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type refundService struct {
+	repo      domain.RefundRepository
+	gateway   domain.PaymentGateway
+	audit     domain.AuditRecorder
+	db        *gorm.DB
+	messenger *whatsmeow.Client
+}
+
+func NewRefundService(repo domain.RefundRepository, gateway domain.PaymentGateway, audit domain.AuditRecorder, db *gorm.DB, messenger *whatsmeow.Client) domain.RefundUseCase {
+	return &refundService{
+		repo:      repo,
+		gateway:   gateway,
+		audit:     audit,
+		db:        db,
+		messenger: messenger,
+	}
+}
+
+// refundState is everything a refund looks at: the payment, the package it activated
+// and the package's future bookings, latest first (the order they get cancelled in).
+type refundState struct {
+	payment        domain.Payment
+	studentPackage *domain.StudentPackage
+	futureBookings []domain.Booking
+}
+
+func loadRefundState(tx *gorm.DB, paymentID int, lock bool) (*refundState, error) {
+	locking := func(db *gorm.DB) *gorm.DB {
+		if lock {
+			return db.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		return db
+	}
+
+	var state refundState
+	if err := locking(tx).First(&state.payment, paymentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pembayaran tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil pembayaran: %w", err)
+	}
+	if err := tx.Preload("Student").Preload("Package").Preload("PackageVersion").First(&state.payment, paymentID).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil detail pembayaran: %w", err)
+	}
+
+	var sp domain.StudentPackage
+	err := locking(tx).Where("payment_id = ?", paymentID).First(&sp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) && state.payment.PaidAt != nil {
+		// Packages activated before payments were linked: take the same student's package
+		// for the same product that started closest to the payment time.
+		err = locking(tx).
+			Where("student_uuid = ? AND package_id = ? AND payment_id IS NULL", state.payment.StudentUUID, state.payment.PackageID).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "ABS(EXTRACT(EPOCH FROM (start_date - ?)))",
+				Vars: []interface{}{*state.payment.PaidAt},
+			}}).
+			First(&sp).Error
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("gagal mengambil paket siswa: %w", err)
+	}
+	if err == nil {
+		state.studentPackage = &sp
+
+		if err := tx.Where("student_package_id = ? AND status IN ? AND class_date > ?",
+			sp.ID, []string{domain.StatusBooked, domain.StatusRescheduled}, time.Now()).
+			Order("class_date DESC, id DESC").
+			Find(&state.futureBookings).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil booking mendatang: %w", err)
+		}
+	}
+
+	return &state, nil
+}
+
+// quoteRefund works out the refund amount and what it takes away from the package.
+// A prorated refund pays back paid amount / package quota for each lesson; lessons come
+// out of the unbooked quota first and only then out of future bookings.
+func quoteRefund(state *refundState, req domain.RefundRequest) (*domain.RefundQuote, error) {
+	payment := state.payment
+	if payment.Status != domain.PaymentStatusPaid && payment.Status != domain.PaymentStatusPartiallyRefunded {
+		return nil, fmt.Errorf("pembayaran dengan status %s tidak dapat direfund", payment.Status)
+	}
+
+	refundable := payment.Amount - payment.RefundedAmount
+	if refundable < 0.01 {
+		return nil, errors.New("pembayaran sudah direfund sepenuhnya")
+	}
+
+	quote := &domain.RefundQuote{
+		PaymentID:        payment.ID,
+		Mode:             req.Mode,
+		PaidAmount:       payment.Amount,
+		RefundedAmount:   payment.RefundedAmount,
+		RefundableAmount: refundable,
+		TotalLessons:     payment.PurchasedPackage().Quota,
+	}
+
+	remaining := 0
+	if state.studentPackage != nil {
+		quote.StudentPackageID = &state.studentPackage.ID
+		remaining = max(state.studentPackage.RemainingQuota, 0)
+		quote.UnusedLessons = remaining + len(state.futureBookings)
+	}
+	quote.UsedLessons = max(quote.TotalLessons-quote.UnusedLessons, 0)
+
+	switch req.Mode {
+	case domain.RefundModeFull:
+		quote.Lessons = quote.UnusedLessons
+		quote.Amount = refundable
+
+	case domain.RefundModeProrated:
+		if state.studentPackage == nil {
+			return nil, errors.New("paket siswa untuk pembayaran ini tidak ditemukan, gunakan refund penuh")
+		}
+		if quote.TotalLessons <= 0 {
+			return nil, errors.New("kuota paket tidak valid untuk refund prorata")
+		}
+		if quote.UnusedLessons == 0 {
+			return nil, errors.New("tidak ada sisa kelas yang dapat direfund")
+		}
+
+		quote.Lessons = quote.UnusedLessons
+		if req.Lessons != nil {
+			if *req.Lessons > quote.UnusedLessons {
+				return nil, fmt.Errorf("jumlah kelas melebihi sisa kelas (%d)", quote.UnusedLessons)
+			}
+			quote.Lessons = *req.Lessons
+		}
+		quote.Amount = math.Min(math.Round(payment.Amount*float64(quote.Lessons)/float64(quote.TotalLessons)), refundable)
+
+	default:
+		return nil, errors.New("mode refund tidak valid")
+	}
+
+	if quote.Amount <= 0 {
+		return nil, errors.New("jumlah refund tidak valid")
+	}
+
+	quote.QuotaRevoked = min(quote.Lessons, remaining)
+	quote.BookingsToCancel = quote.Lessons - quote.QuotaRevoked
+	quote.PackageRevoked = state.studentPackage != nil && (req.Mode == domain.RefundModeFull || quote.Lessons == quote.UnusedLessons)
+
+	return quote, nil
+}
+
+func (s *refundService) QuoteRefund(ctx context.Context, paymentID int, req domain.RefundRequest) (*domain.RefundQuote, error) {
+	state, err := loadRefundState(s.db.WithContext(ctx), paymentID, false)
+	if err != nil {
+		return nil, err
+	}
+	return quoteRefund(state, req)
+}
+
+func (s *refundService) RefundPayment(ctx context.Context, paymentID int, adminUUID string, req domain.RefundRequest) (*domain.RefundResult, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// The payment row stays locked until commit, so two admins can't refund it at once.
+	state, err := loadRefundState(tx, paymentID, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	quote, err := quoteRefund(state, req)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if req.Method == domain.RefundMethodGateway && state.payment.XenditInvoiceID == nil {
+		tx.Rollback()
+		return nil, errors.New("pembayaran tidak memiliki invoice gateway, gunakan refund manual")
+	}
+
+	var previous int64
+	if err := tx.Model(&domain.PaymentRefund{}).Where("payment_id = ?", paymentID).Count(&previous).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal menghitung refund sebelumnya: %w", err)
+	}
+
+	now := time.Now()
+	refund := domain.PaymentRefund{
+		PaymentID:        paymentID,
+		StudentPackageID: quote.StudentPackageID,
+		// Deterministic, so retrying after a failed commit doesn't refund twice at the gateway.
+		ReferenceID:     fmt.Sprintf("refund-%d-%d", paymentID, previous+1),
+		Mode:            req.Mode,
+		Method:          req.Method,
+		Amount:          quote.Amount,
+		Lessons:         quote.Lessons,
+		QuotaRevoked:    quote.QuotaRevoked,
+		PackageRevoked:  quote.PackageRevoked,
+		Status:          domain.RefundStatusSucceeded,
+		ManualReference: req.ManualReference,
+		Reason:          req.Reason,
+		CreatedBy:       adminUUID,
+	}
+
+	// 1. Cancel the future bookings the package can no longer cover. Their quota was
+	// already taken when they were booked, so nothing goes back to the package.
+	cancelled := state.futureBookings[:quote.BookingsToCancel]
+	note := "Dibatalkan karena refund: " + req.Reason
+	for i := range cancelled {
+		booking := &cancelled[i]
+		if err := tx.Model(booking).UpdateColumns(map[string]interface{}{
+			"status":       domain.StatusCancelled,
+			"cancelled_at": now,
+			"canceled_by":  adminUUID,
+			"notes":        note,
+		}).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("gagal membatalkan booking: %w", err)
+		}
+
+		if err := tx.Model(&domain.TeacherSchedule{}).
+			Where("id = ?", booking.ScheduleID).
+			Update("is_booked", false).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("gagal memperbarui jadwal pengajar: %w", err)
+		}
+
+		history := domain.ClassHistory{BookingID: booking.ID, Status: domain.StatusCancelled, Notes: &note}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "booking_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "notes"}),
+		}).Create(&history).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("gagal membuat riwayat kelas (cancel): %w", err)
+		}
+
+		booking.Status = domain.StatusCancelled
+		booking.CancelledAt = &now
+		booking.CanceledBy = &adminUUID
+		booking.Notes = &note
+	}
+	refund.CancelledBookings = len(cancelled)
+
+	// 2. Reduce or revoke the package.
+	if sp := state.studentPackage; sp != nil {
+		updates := map[string]interface{}{
+			"remaining_quota": gorm.Expr("remaining_quota - ?", quote.QuotaRevoked),
+		}
+		if quote.PackageRevoked && sp.EndDate.After(now) {
+			updates["end_date"] = now
+		}
+		if err := tx.Model(&domain.StudentPackage{}).Where("id = ?", sp.ID).UpdateColumns(updates).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("gagal mengurangi kuota paket: %w", err)
+		}
+	}
+
+	// 3. Mark the payment.
+	payment := state.payment
+	payment.RefundedAmount += quote.Amount
+	payment.Status = domain.PaymentStatusPartiallyRefunded
+	if payment.Amount-payment.RefundedAmount < 0.01 {
+		payment.Status = domain.PaymentStatusRefunded
+	}
+	if err := tx.Model(&domain.Payment{}).Where("id = ?", payment.ID).UpdateColumns(map[string]interface{}{
+		"refunded_amount": payment.RefundedAmount,
+		"status":          payment.Status,
+		"updated_at":      now,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal memperbarui status pembayaran: %w", err)
+	}
+
+	// 4. Move the money last, so a gateway failure rolls everything above back.
+	if req.Method == domain.RefundMethodGateway {
+		result, err := s.gateway.Refund(ctx, domain.GatewayRefundRequest{
+			InvoiceID:   *payment.XenditInvoiceID,
+			ExternalID:  payment.ExternalID,
+			ReferenceID: refund.ReferenceID,
+			Amount:      quote.Amount,
+			Reason:      req.Reason,
+		})
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("refund %s gagal: %w", s.gateway.Name(), err)
+		}
+		refund.GatewayRefundID = &result.ID
+		if !strings.EqualFold(result.Status, domain.RefundStatusSucceeded) {
+			refund.Status = domain.RefundStatusPending
+		}
+	}
+
+	if err := tx.Create(&refund).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("gagal menyimpan refund: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		if refund.GatewayRefundID != nil {
+			log.Printf("❌ Refund %s sent to gateway (id=%s) but not saved: %v", refund.ReferenceID, *refund.GatewayRefundID, err)
+		}
+		return nil, fmt.Errorf("gagal menyimpan refund: %w", err)
+	}
+
+	s.audit.Record(ctx, domain.AuditActionPaymentRefund, domain.AuditTargetPayment, strconv.Itoa(paymentID),
+		map[string]interface{}{"status": state.payment.Status, "refunded_amount": state.payment.RefundedAmount},
+		map[string]interface{}{"status": payment.Status, "refunded_amount": payment.RefundedAmount, "refund": refund})
+	s.sendRefundNotification(&payment, &refund, cancelled)
+
+	return &domain.RefundResult{
+		Refund:            refund,
+		Payment:           payment,
+		CancelledBookings: cancelled,
+	}, nil
+}
+
+func (s *refundService) GetRefundsByPayment(ctx context.Context, paymentID int) ([]domain.PaymentRefund, error) {
+	return s.repo.GetRefundsByPayment(ctx, paymentID)
+}
+
+func (s *refundService) sendRefundNotification(payment *domain.Payment, refund *domain.PaymentRefund, cancelled []domain.Booking) {
+	if s.messenger == nil {
+		return
+	}
+
+	phone := utils.NormalizePhoneNumber(payment.Student.Phone)
+	if phone == "" {
+		return
+	}
+
+	loc, _ := time.LoadLocation("Asia/Makassar") // WITA timezone
+
+	packageInfo := fmt.Sprintf("Kuota paket dikurangi %d kelas.", refund.Lessons)
+	if refund.PackageRevoked {
+		packageInfo = "Paket kamu sudah tidak aktif lagi."
+	}
+
+	var cancelledInfo strings.Builder
+	if len(cancelled) > 0 {
+		cancelledInfo.WriteString("\n\n❌ *Kelas yang dibatalkan:*")
+		for _, booking := range cancelled {
+			classDate := booking.ClassDate.In(loc)
+			fmt.Fprintf(&cancelledInfo, "\n• %s, %s %s WITA",
+				utils.GetDayName(classDate.Weekday()), classDate.Format("02/01/2006"), classDate.Format("15:04"))
+		}
+	}
+
+	msg := fmt.Sprintf(`💸 *Halo %s!*
+
+Refund untuk pembelian paket *"%s"* telah diproses.
+
+┣ 💰 Jumlah: %s
+┣ 🧾 Referensi: %s
+┗ 📝 Alasan: %s
+
+%s%s
+
+🌐 Website: %s
+🔔 %s Notification System`,
+		payment.Student.Name,
+		payment.PurchasedPackage().Name,
+		formatRupiah(refund.Amount),
+		refund.ReferenceID,
+		refund.Reason,
+		packageInfo,
+		cancelledInfo.String(),
+		os.Getenv("TARGETED_DOMAIN"),
+		os.Getenv("APP_NAME"))
+
+	jid := types.NewJID(phone, types.DefaultUserServer)
+	go func() {
+		if _, err := s.messenger.SendMessage(context.Background(), jid, &waE2E.Message{Conversation: &msg}); err != nil {
+			log.Printf("🔕 Gagal mengirim notifikasi refund ke %s (%s): %v", payment.Student.Name, phone, err)
+		} else {
+			log.Printf("🔔 Notifikasi refund berhasil dikirim ke: %s (%s)", payment.Student.Name, phone)
+		}
+	}()
+}
+
+// formatRupiah renders an amount as "Rp 1.250.000".
+func formatRupiah(amount float64) string {
+	digits := strconv.FormatInt(int64(math.Round(amount)), 10)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+
+	if negative {
+		return "Rp -" + b.String()
+	}
+	return "Rp " + b.String()
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rolePermissionCacheTTL bounds how long another instance keeps serving permissions an
+// admin has since changed; edits made through this instance apply immediately.
+const rolePermissionCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+type roleService struct {
+	repo         domain.RoleRepository
+	tokenVersion domain.TokenVersionRepository
+	audit        domain.AuditRecorder
+
+	mu          sync.RWMutex
+	permissions map[string]map[string]bool
+	loadedAt    time.Time
+}
+
+func NewRoleService(repo domain.RoleRepository, tokenVersion domain.TokenVersionRepository, audit domain.AuditRecorder) domain.RoleUseCase {
+	return &roleService{repo: repo, tokenVersion: tokenVersion, audit: audit}
+}
+
+// HasPermission reports whether a role grants a permission. Admins hold every
+// non-portal permission regardless of what is stored.
+func (s *roleService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	if role == domain.RoleAdmin {
+		info, ok := domain.LookupPermission(permission)
+		return ok && !info.Portal, nil
+	}
+
+	permissions, err := s.rolePermissions(ctx)
+	if err != nil {
+		return false, err
+	}
+	return permissions[role][permission], nil
+}
+
+func (s *roleService) GetPermissions(ctx context.Context, role string) ([]string, error) {
+	if role == domain.RoleAdmin {
+		return adminPermissions(), nil
+	}
+
+	permissions, err := s.rolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	granted := make([]string, 0, len(permissions[role]))
+	for permission := range permissions[role] {
+		granted = append(granted, permission)
+	}
+	sort.Strings(granted)
+	return granted, nil
+}
+
+func (s *roleService) rolePermissions(ctx context.Context) (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	if s.permissions != nil && time.Since(s.loadedAt) < rolePermissionCacheTTL {
+		defer s.mu.RUnlock()
+		return s.permissions, nil
+	}
+	s.mu.RUnlock()
+
+	byRole, err := s.repo.GetAllRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make(map[string]map[string]bool, len(byRole))
+	for role, names := range byRole {
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			set[name] = true
+		}
+		permissions[role] = set
+	}
+
+	s.mu.Lock()
+	s.permissions = permissions
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return permissions, nil
+}
+
+func (s *roleService) invalidate() {
+	s.mu.Lock()
+	s.permissions = nil
+	s.mu.Unlock()
+}
+
+func adminPermissions() []string {
+	permissions := make([]string, 0, len(domain.PermissionCatalog))
+	for _, p := range domain.PermissionCatalog {
+		if !p.Portal {
+			permissions = append(permissions, p.Name)
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+func (s *roleService) GetRoles(ctx context.Context) ([]domain.Role, error) {
+	roles, err := s.repo.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		if roles[i].Name == domain.RoleAdmin {
+			roles[i].Permissions = adminPermissions()
+		}
+	}
+	return roles, nil
+}
+
+func (s *roleService) CreateRole(ctx context.Context, actorRole string, req domain.CreateRoleRequest) (*domain.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("nama role harus 2-50 karakter huruf kecil, angka atau garis bawah dan diawali huruf")
+	}
+
+	permissions, err := normalizePermissions(name, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGrantable(ctx, actorRole, permissions); err != nil {
+		return nil, err
+	}
+
+	role := &domain.Role{
+		Name:        name,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissions,
+	}
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	s.audit.Record(ctx, domain.AuditActionRoleCreate, domain.AuditTargetRole, role.Name, nil, role)
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(ctx context.Context, actorRole, name string, req domain.UpdateRoleRequest) (*domain.Role, error) {
+	if name == domain.RoleAdmin {
+		return nil, errors.New("role admin selalu memiliki semua izin dan tidak dapat diubah")
+	}
+	if name == actorRole {
+		return nil, errors.New("tidak dapat mengubah role yang anda miliki sendiri")
+	}
+
+	existing, err := s.repo.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("role tidak ditemukan")
+	}
+
+	permissions, err := normalizePermissions(name, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGrantable(ctx, actorRole, permissions); err != nil {
+		return nil, err
+	}
+
+	before := *existing
+	updated := *existing
+	updated.DisplayName = strings.TrimSpace(req.DisplayName)
+	updated.Description = strings.TrimSpace(req.Description)
+	updated.Permissions = permissions
+	if err := s.repo.UpdateRole(ctx, &updated); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	s.audit.Record(ctx, domain.AuditActionRoleUpdate, domain.AuditTargetRole, name, before, updated)
+	return &updated, nil
+}
+
+// normalizePermissions checks every permission exists and deduplicates them. Portal
+// permissions stay with the student and teacher roles, whose self-service routes need a
+// matching profile.
+func normalizePermissions(role string, requested []string) ([]string, error) {
+	seen := make(map[string]bool, len(requested))
+	permissions := make([]string, 0, len(requested))
+	for _, name := range requested {
+		name = strings.TrimSpace(name)
+		info, ok := domain.LookupPermission(name)
+		if !ok {
+			return nil, fmt.Errorf("izin %s tidak dikenal", name)
+		}
+		if info.Portal && !portalAllowed(role, name) {
+			return nil, fmt.Errorf("izin %s hanya untuk role bawaan murid atau guru", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		permissions = append(permissions, name)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// checkGrantable keeps roles.manage from being a path to more access: a caller can only
+// put permissions they hold themselves into a role. Admins hold every permission.
+func (s *roleService) checkGrantable(ctx context.Context, actorRole string, permissions []string) error {
+	if actorRole == domain.RoleAdmin {
+		return nil
+	}
+	for _, permission := range permissions {
+		if info, _ := domain.LookupPermission(permission); info.Portal {
+			continue // portal permissions only go to the student and teacher roles
+		}
+		held, err := s.HasPermission(ctx, actorRole, permission)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return fmt.Errorf("tidak dapat memberikan izin %s yang tidak anda miliki", permission)
+		}
+	}
+	return nil
+}
+
+func portalAllowed(role, permission string) bool {
+	switch permission {
+	case domain.PermissionStudentPortal:
+		return role == domain.RoleStudent
+	case domain.PermissionTeacherPortal:
+		return role == domain.RoleTeacher
+	}
+	return false
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.repo.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role tidak ditemukan")
+	}
+	if role.IsSystem {
+		return errors.New("role bawaan tidak dapat dihapus")
+	}
+
+	if err := s.repo.DeleteRole(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate()
+	s.audit.Record(ctx, domain.AuditActionRoleDelete, domain.AuditTargetRole, name, role, nil)
+	return nil
+}
+
+// AssignRole moves a back-office user to another role. The role travels in the access
+// token, so the user's outstanding tokens are revoked; their next refresh picks up the
+// new role. Only admins can make someone an admin or take the role away.
+func (s *roleService) AssignRole(ctx context.Context, actorUUID, actorRole, userUUID, roleName string) error {
+	if actorUUID == userUUID {
+		return errors.New("tidak dapat mengubah role anda sendiri")
+	}
+
+	roleName = strings.ToLower(strings.TrimSpace(roleName))
+	if roleName == domain.RoleStudent || roleName == domain.RoleTeacher {
+		return fmt.Errorf("role %s tidak dapat diberikan secara manual", roleName)
+	}
+	if roleName == domain.RoleAdmin && actorRole != domain.RoleAdmin {
+		return errors.New("hanya admin yang dapat memberikan atau mencabut role admin")
+	}
+
+	role, err := s.repo.GetRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role tidak ditemukan")
+	}
+
+	previous, err := s.repo.AssignUserRole(ctx, userUUID, roleName, actorRole == domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionUserRoleAssign, domain.AuditTargetUser, userUUID,
+		map[string]string{"role": previous}, map[string]string{"role": roleName})
+
+	if _, err := s.tokenVersion.BumpTokenVersion(ctx, userUUID); err != nil {
+		return fmt.Errorf("role berhasil diubah, tetapi gagal mencabut token lama pengguna: %w", err)
+	}
+	return nil
+}
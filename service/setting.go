@@ -0,0 +1,111 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type settingDefinition struct {
+	defaultValue string
+	validate     func(value string) error
+}
+
+func intSetting(defaultValue, minValue, maxValue int) settingDefinition {
+	return settingDefinition{
+		defaultValue: strconv.Itoa(defaultValue),
+		validate: func(value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < minValue || n > maxValue {
+				return fmt.Errorf("nilai harus berupa angka antara %d dan %d", minValue, maxValue)
+			}
+			return nil
+		},
+	}
+}
+
+// knownSettings lists every setting admins may change, with its default.
+var knownSettings = map[string]settingDefinition{
+	domain.SettingMaxOpenInvoices:  intSetting(domain.DefaultMaxOpenInvoices, 0, domain.SettingMaxOpenInvoicesMaximum),
+	domain.SettingRequireTwoFactor: intSetting(0, 0, 1),
+}
+
+type settingService struct {
+	repo  domain.SettingRepository
+	audit domain.AuditRecorder
+}
+
+func NewSettingService(repo domain.SettingRepository, audit domain.AuditRecorder) domain.SettingUseCase {
+	return &settingService{repo: repo, audit: audit}
+}
+
+// GetSettings returns every known setting, filling in defaults for the ones never set.
+func (s *settingService) GetSettings(ctx context.Context) ([]domain.AppSetting, error) {
+	stored, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]domain.AppSetting, len(stored))
+	for _, setting := range stored {
+		byKey[setting.Key] = setting
+	}
+
+	settings := make([]domain.AppSetting, 0, len(knownSettings))
+	for key, def := range knownSettings {
+		setting, ok := byKey[key]
+		if !ok {
+			setting = domain.AppSetting{Key: key, Value: def.defaultValue}
+		}
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings, nil
+}
+
+func (s *settingService) UpdateSetting(ctx context.Context, key, value, updatedBy string) (*domain.AppSetting, error) {
+	def, ok := knownSettings[key]
+	if !ok {
+		return nil, errors.New("pengaturan tidak ditemukan")
+	}
+
+	value = strings.TrimSpace(value)
+	if err := def.validate(value); err != nil {
+		return nil, err
+	}
+
+	previous := def.defaultValue
+	if stored, err := s.repo.Get(ctx, key); err == nil && stored != nil {
+		previous = stored.Value
+	}
+
+	setting := &domain.AppSetting{Key: key, Value: value, UpdatedBy: &updatedBy}
+	if err := s.repo.Upsert(ctx, setting); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, domain.AuditActionSettingUpdate, domain.AuditTargetSetting, key,
+		map[string]string{"value": previous}, map[string]string{"value": value})
+	return setting, nil
+}
+
+// GetInt reads an integer setting, falling back to its default when unset.
+func (s *settingService) GetInt(ctx context.Context, key string) (int, error) {
+	def, ok := knownSettings[key]
+	if !ok {
+		return 0, fmt.Errorf("unknown setting %s", key)
+	}
+
+	value := def.defaultValue
+	setting, err := s.repo.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if setting != nil {
+		value = setting.Value
+	}
+	return strconv.Atoi(value)
+}
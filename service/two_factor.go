@@ -0,0 +1,332 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"chronosphere/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type twoFactorService struct {
+	repo     domain.TwoFactorRepository
+	userRepo domain.UserRepository
+	settings domain.SettingUseCase
+	audit    domain.AuditRecorder
+}
+
+func NewTwoFactorService(repo domain.TwoFactorRepository, userRepo domain.UserRepository, settings domain.SettingUseCase, audit domain.AuditRecorder) domain.TwoFactorUseCase {
+	return &twoFactorService{repo: repo, userRepo: userRepo, settings: settings, audit: audit}
+}
+
+// supportsTwoFactor limits 2FA to back-office staff, the accounts that can create
+// teachers, assign packages and change quotas.
+func supportsTwoFactor(role string) bool {
+	return domain.IsStaffRole(role)
+}
+
+func (s *twoFactorService) isRequired(ctx context.Context, role string) (bool, error) {
+	if !supportsTwoFactor(role) {
+		return false, nil
+	}
+	required, err := s.settings.GetInt(ctx, domain.SettingRequireTwoFactor)
+	if err != nil {
+		return false, err
+	}
+	return required == 1, nil
+}
+
+func (s *twoFactorService) GetStatus(ctx context.Context, userUUID, role string) (*domain.TwoFactorStatus, error) {
+	required, err := s.isRequired(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	status := &domain.TwoFactorStatus{Required: required}
+
+	tf, err := s.repo.GetTwoFactor(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Enabled {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = tf.EnabledAt
+	if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, userUUID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// BeginSetup generates a new secret. 2FA is only enabled once ConfirmSetup sees a code
+// from it, so an abandoned setup never locks the user out.
+func (s *twoFactorService) BeginSetup(ctx context.Context, userUUID string) (*domain.TwoFactorSetup, error) {
+	user, err := s.userRepo.GetUserByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+	if !supportsTwoFactor(user.Role) {
+		return nil, errors.New("2FA hanya tersedia untuk akun admin dan manajemen")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePendingSecret(ctx, userUUID, secret); err != nil {
+		return nil, err
+	}
+
+	provisioningURL := utils.TOTPProvisioningURL(domain.TwoFactorIssuer, user.Email, secret)
+	png, err := qrcode.Encode(provisioningURL, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURL: provisioningURL,
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmSetup enables 2FA and returns the recovery codes, which are shown only this once.
+func (s *twoFactorService) ConfirmSetup(ctx context.Context, userUUID, code string) ([]string, error) {
+	tf, err := s.repo.GetTwoFactor(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, errors.New("setup 2FA belum dimulai")
+	}
+	if tf.Enabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+
+	step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Enable(ctx, userUUID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userUUID, role, password, code string) error {
+	required, err := s.isRequired(ctx, role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("2FA wajib untuk akun anda dan tidak dapat dinonaktifkan")
+	}
+
+	user, err := s.userRepo.GetUserByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("password salah")
+	}
+	if err := s.verifyCode(ctx, userUUID, code); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userUUID)
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error) {
+	if err := s.verifyCode(ctx, userUUID, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userUUID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetForUser removes a user's 2FA for an admin, e.g. after a lost phone and lost
+// recovery codes. If 2FA is mandatory the user has to set it up again on the next login.
+func (s *twoFactorService) ResetForUser(ctx context.Context, userUUID string) error {
+	if _, err := s.userRepo.GetUserByUUID(ctx, userUUID); err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	before, err := s.repo.GetTwoFactor(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userUUID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionUserTwoFactorReset, domain.AuditTargetUser, userUUID, before, nil)
+	return nil
+}
+
+// verifyCode accepts a current TOTP code or an unused recovery code; either is spent.
+func (s *twoFactorService) verifyCode(ctx context.Context, userUUID, code string) error {
+	tf, err := s.repo.GetTwoFactor(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		return errors.New("2FA belum diaktifkan")
+	}
+
+	if step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now()); ok {
+		consumed, err := s.repo.ConsumeStep(ctx, userUUID, step)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, userUUID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *twoFactorService) LoginChallenge(ctx context.Context, user *domain.User) (*domain.TwoFactorChallenge, error) {
+	if !supportsTwoFactor(user.Role) {
+		return nil, nil
+	}
+
+	tf, err := s.repo.GetTwoFactor(ctx, user.UUID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := tf != nil && tf.Enabled
+	if !enabled {
+		required, err := s.isRequired(ctx, user.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+	}
+
+	token, err := utils.GenerateRandomID(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := &domain.TwoFactorChallenge{
+		Token:         token,
+		UserUUID:      user.UUID,
+		SetupRequired: !enabled,
+		ExpiresAt:     time.Now().Add(domain.TwoFactorChallengeTTL),
+	}
+	if err := s.repo.CreateChallenge(ctx, challenge, domain.TwoFactorChallengeTTL); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *twoFactorService) getChallenge(ctx context.Context, token string) (*domain.TwoFactorChallenge, error) {
+	challenge, err := s.repo.GetChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, domain.ErrTwoFactorChallengeInvalid
+	}
+	return challenge, nil
+}
+
+// BeginChallengeSetup lets a user for whom 2FA is mandatory enrol in the middle of logging
+// in, since without tokens they can't reach the regular setup endpoint.
+func (s *twoFactorService) BeginChallengeSetup(ctx context.Context, challengeToken string) (*domain.TwoFactorSetup, error) {
+	challenge, err := s.getChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.SetupRequired {
+		return nil, errors.New("2FA sudah aktif, masukkan kode dari aplikasi authenticator")
+	}
+	return s.BeginSetup(ctx, challenge.UserUUID)
+}
+
+// VerifyChallenge checks the code for a login challenge and spends the challenge. For a
+// challenge that required setup, the code confirms the setup and the new recovery codes
+// are returned. The user is also returned with a wrong code, so the failure can be
+// counted against the account.
+func (s *twoFactorService) VerifyChallenge(ctx context.Context, challengeToken, code string) (string, []string, error) {
+	challenge, err := s.getChallenge(ctx, challengeToken)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var recoveryCodes []string
+	if challenge.SetupRequired {
+		recoveryCodes, err = s.ConfirmSetup(ctx, challenge.UserUUID, code)
+	} else {
+		err = s.verifyCode(ctx, challenge.UserUUID, code)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			attempts, failErr := s.repo.RecordChallengeFailure(ctx, challengeToken)
+			if failErr == nil && (attempts < 0 || attempts >= domain.TwoFactorMaxAttempts) {
+				_, _ = s.repo.DeleteChallenge(ctx, challengeToken)
+				return challenge.UserUUID, nil, domain.ErrTwoFactorTooManyAttempts
+			}
+			return challenge.UserUUID, nil, err
+		}
+		return "", nil, err
+	}
+
+	deleted, err := s.repo.DeleteChallenge(ctx, challengeToken)
+	if err != nil {
+		return "", nil, err
+	}
+	if !deleted {
+		// Completed by a concurrent request with the same challenge.
+		return "", nil, domain.ErrTwoFactorChallengeInvalid
+	}
+	return challenge.UserUUID, recoveryCodes, nil
+}
+
+// generateRecoveryCodes returns the codes to show the user and the hashes to store.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, domain.TwoFactorRecoveryCodeCount)
+	hashes := make([]string, domain.TwoFactorRecoveryCodeCount)
+	for i := range codes {
+		raw, err := utils.GenerateRandomID(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed loosely. The codes
+// are random, so a plain SHA-256 is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type voucherService struct {
+	repo  domain.VoucherRepository
+	audit domain.AuditRecorder
+	db    *gorm.DB
+}
+
+func NewVoucherService(repo domain.VoucherRepository, audit domain.AuditRecorder, db *gorm.DB) domain.VoucherUseCase {
+	return &voucherService{repo: repo, audit: audit, db: db}
+}
+
+func validateVoucherRules(voucher *domain.Voucher) error {
+	voucher.Code = domain.NormalizeVoucherCode(voucher.Code)
+	if voucher.Code == "" {
+		return errors.New("kode voucher tidak boleh kosong")
+	}
+
+	switch voucher.Type {
+	case domain.VoucherTypePercent:
+		if voucher.Value <= 0 || voucher.Value > 100 {
+			return errors.New("nilai voucher persen harus antara 0 dan 100")
+		}
+	case domain.VoucherTypeFixed:
+		if voucher.Value <= 0 {
+			return errors.New("nilai voucher harus lebih dari 0")
+		}
+		voucher.MaxDiscount = nil
+	default:
+		return errors.New("tipe voucher harus percent atau fixed")
+	}
+
+	if voucher.StartsAt != nil && voucher.EndsAt != nil && !voucher.EndsAt.After(*voucher.StartsAt) {
+		return errors.New("tanggal berakhir harus setelah tanggal mulai")
+	}
+	return nil
+}
+
+// applyVoucher looks up a voucher by code and checks every rule for this student and
+// package. With lock set the voucher row is locked FOR UPDATE, so the limit checks and
+// the redemption that follows are atomic within tx.
+func applyVoucher(tx *gorm.DB, code, studentUUID string, pkg *domain.Package, lock bool) (*domain.Voucher, float64, error) {
+	query := tx.Preload("Packages")
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var voucher domain.Voucher
+	if err := query.Where("code = ? AND deleted_at IS NULL", domain.NormalizeVoucherCode(code)).First(&voucher).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, errors.New("voucher tidak ditemukan")
+		}
+		return nil, 0, fmt.Errorf("gagal mengambil voucher: %w", err)
+	}
+
+	now := time.Now()
+	if !voucher.IsActive {
+		return nil, 0, errors.New("voucher tidak aktif")
+	}
+	if voucher.StartsAt != nil && now.Before(*voucher.StartsAt) {
+		return nil, 0, errors.New("voucher belum berlaku")
+	}
+	if voucher.EndsAt != nil && now.After(*voucher.EndsAt) {
+		return nil, 0, errors.New("voucher sudah kedaluwarsa")
+	}
+	if !voucher.AppliesTo(pkg.ID) {
+		return nil, 0, errors.New("voucher tidak berlaku untuk paket ini")
+	}
+	if voucher.MaxRedemptions != nil && voucher.RedeemedCount >= *voucher.MaxRedemptions {
+		return nil, 0, errors.New("kuota voucher sudah habis")
+	}
+
+	if voucher.MaxPerUser != nil {
+		var used int64
+		if err := tx.Model(&domain.VoucherRedemption{}).
+			Where("voucher_id = ? AND student_uuid = ? AND status <> ?", voucher.ID, studentUUID, domain.RedemptionReleased).
+			Count(&used).Error; err != nil {
+			return nil, 0, fmt.Errorf("gagal memeriksa penggunaan voucher: %w", err)
+		}
+		if used >= int64(*voucher.MaxPerUser) {
+			return nil, 0, errors.New("batas penggunaan voucher untuk akun ini sudah tercapai")
+		}
+	}
+
+	if voucher.FirstPurchaseOnly {
+		var purchases int64
+		if err := tx.Model(&domain.Payment{}).
+			Where("student_uuid = ? AND status IN ?", studentUUID,
+				[]string{domain.PaymentStatusPaid, domain.PaymentStatusPartiallyRefunded, domain.PaymentStatusRefunded}).
+			Count(&purchases).Error; err != nil {
+			return nil, 0, fmt.Errorf("gagal memeriksa riwayat pembelian: %w", err)
+		}
+		if purchases > 0 {
+			return nil, 0, errors.New("voucher hanya berlaku untuk pembelian pertama")
+		}
+
+		// An open checkout with a voucher holds the first purchase until it is paid,
+		// cancelled or expires; otherwise each open invoice could carry its own discount.
+		// Checkouts of one student are serialized, so two can't both pass this check.
+		var pending int64
+		if err := tx.Model(&domain.Payment{}).
+			Where("student_uuid = ? AND status = ? AND voucher_id IS NOT NULL", studentUUID, domain.PaymentStatusPending).
+			Where("expires_at IS NULL OR expires_at > ?", now).
+			Count(&pending).Error; err != nil {
+			return nil, 0, fmt.Errorf("gagal memeriksa riwayat pembelian: %w", err)
+		}
+		if pending > 0 {
+			return nil, 0, errors.New("voucher hanya berlaku untuk pembelian pertama, selesaikan atau batalkan pembayaran dengan voucher yang masih menunggu")
+		}
+	}
+
+	discount := voucher.DiscountFor(pkg.Price)
+	if pkg.Price-discount < domain.MinCheckoutAmount {
+		return nil, 0, fmt.Errorf("total setelah diskon kurang dari minimum pembayaran (Rp %d)", domain.MinCheckoutAmount)
+	}
+	return &voucher, discount, nil
+}
+
+func (s *voucherService) CreateVoucher(ctx context.Context, voucher *domain.Voucher, packageIDs []int) (*domain.Voucher, error) {
+	if err := validateVoucherRules(voucher); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, voucher, packageIDs); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, domain.AuditActionVoucherCreate, domain.AuditTargetVoucher, strconv.Itoa(voucher.ID), nil, voucher)
+	return voucher, nil
+}
+
+func (s *voucherService) UpdateVoucher(ctx context.Context, voucher *domain.Voucher, packageIDs []int) (*domain.Voucher, error) {
+	if err := validateVoucherRules(voucher); err != nil {
+		return nil, err
+	}
+	before, _ := s.repo.GetByID(ctx, voucher.ID)
+	if err := s.repo.Update(ctx, voucher, packageIDs); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetByID(ctx, voucher.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, domain.AuditActionVoucherUpdate, domain.AuditTargetVoucher, strconv.Itoa(voucher.ID), before, after)
+	return after, nil
+}
+
+func (s *voucherService) DeleteVoucher(ctx context.Context, id int) error {
+	before, _ := s.repo.GetByID(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, domain.AuditActionVoucherDelete, domain.AuditTargetVoucher, strconv.Itoa(id), before, nil)
+	return nil
+}
+
+func (s *voucherService) GetVoucherByID(ctx context.Context, id int) (*domain.Voucher, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *voucherService) GetVouchers(ctx context.Context, filter domain.VoucherFilter) ([]domain.Voucher, int64, error) {
+	return s.repo.GetAll(ctx, filter)
+}
+
+func (s *voucherService) GetVoucherRedemptions(ctx context.Context, voucherID, page, limit int) ([]domain.VoucherRedemption, int64, error) {
+	if _, err := s.repo.GetByID(ctx, voucherID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.GetRedemptions(ctx, voucherID, page, limit)
+}
+
+// CheckVoucher previews the price at checkout. It reserves nothing, so the voucher can
+// still run out before the student actually checks out.
+func (s *voucherService) CheckVoucher(ctx context.Context, studentUUID string, req domain.VoucherCheckRequest) (*domain.PriceBreakdown, error) {
+	var pkg domain.Package
+	if err := s.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", req.PackageID).First(&pkg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("paket tidak ditemukan")
+		}
+		return nil, err
+	}
+
+	voucher, discount, err := applyVoucher(s.db.WithContext(ctx), req.VoucherCode, studentUUID, &pkg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PriceBreakdown{
+		PackageID:      pkg.ID,
+		VoucherCode:    &voucher.Code,
+		OriginalAmount: pkg.Price,
+		DiscountAmount: discount,
+		Amount:         pkg.Price - discount,
+	}, nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"chronosphere/domain"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// redactedWebhookHeaders are never written to the webhook log.
+var redactedWebhookHeaders = map[string]bool{
+	"x-callback-token": true,
+	"authorization":    true,
+	"cookie":           true,
+}
+
+type webhookService struct {
+	repo       domain.WebhookRepository
+	paymentUC  domain.PaymentUseCase
+	token      string
+	production bool
+}
+
+func NewWebhookService(repo domain.WebhookRepository, paymentUC domain.PaymentUseCase) domain.WebhookUseCase {
+	return &webhookService{
+		repo:       repo,
+		paymentUC:  paymentUC,
+		token:      os.Getenv("XENDIT_WEBHOOK_TOKEN"),
+		production: os.Getenv("APP_ENV") == "production",
+	}
+}
+
+func (s *webhookService) ReceivePaymentWebhook(ctx context.Context, headers http.Header, body []byte, remoteIP string) (*domain.WebhookEvent, error) {
+	event := &domain.WebhookEvent{
+		Provider: domain.WebhookProviderPayment,
+		Headers:  encodeWebhookHeaders(headers),
+		Body:     string(body),
+		RemoteIP: remoteIP,
+		Status:   domain.WebhookStatusReceived,
+	}
+
+	verified, accepted, result := s.verifyToken(headers.Get("x-callback-token"))
+	event.Verified = verified
+	event.VerifyResult = result
+	if !accepted {
+		event.Status = domain.WebhookStatusRejected
+		if err := s.repo.Create(ctx, event); err != nil {
+			return nil, err
+		}
+		return event, fmt.Errorf("webhook ditolak: %s", result)
+	}
+
+	payload, err := parsePaymentCallback(body)
+	if err != nil {
+		event.Status = domain.WebhookStatusFailed
+		msg := err.Error()
+		event.Error = &msg
+		if createErr := s.repo.Create(ctx, event); createErr != nil {
+			return nil, createErr
+		}
+		return event, err
+	}
+
+	event.EventID = headers.Get("webhook-id")
+	if event.EventID == "" {
+		// Invoice callbacks carry no event id; an invoice only reaches each status once.
+		event.EventID = payload.ID + ":" + payload.Status
+	}
+
+	if err := s.repo.Create(ctx, event); err != nil {
+		return nil, err
+	}
+
+	duplicate, err := s.repo.HasProcessedEvent(ctx, event.Provider, event.EventID, event.ID)
+	if err != nil {
+		return event, err
+	}
+	if duplicate {
+		event.Status = domain.WebhookStatusDuplicate
+		if err := s.repo.Update(ctx, event); err != nil {
+			return event, err
+		}
+		return event, nil
+	}
+
+	return event, s.process(ctx, event, payload)
+}
+
+func (s *webhookService) GetWebhookEvents(ctx context.Context, filter domain.WebhookFilter) ([]domain.WebhookEvent, int64, error) {
+	return s.repo.GetAll(ctx, filter)
+}
+
+func (s *webhookService) GetWebhookEventByID(ctx context.Context, id int) (*domain.WebhookEvent, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *webhookService) ReprocessWebhookEvent(ctx context.Context, id int) (*domain.WebhookEvent, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != domain.WebhookStatusFailed {
+		return event, fmt.Errorf("hanya webhook berstatus %s yang dapat diproses ulang", domain.WebhookStatusFailed)
+	}
+
+	payload, err := parsePaymentCallback([]byte(event.Body))
+	if err != nil {
+		return event, err
+	}
+
+	if event.EventID != "" {
+		duplicate, err := s.repo.HasProcessedEvent(ctx, event.Provider, event.EventID, event.ID)
+		if err != nil {
+			return event, err
+		}
+		if duplicate {
+			event.Status = domain.WebhookStatusDuplicate
+			if err := s.repo.Update(ctx, event); err != nil {
+				return event, err
+			}
+			return event, errors.New("event ini sudah diproses oleh webhook lain")
+		}
+	}
+
+	return event, s.process(ctx, event, payload)
+}
+
+func (s *webhookService) process(ctx context.Context, event *domain.WebhookEvent, payload *domain.PaymentCallback) error {
+	event.Attempts++
+	processErr := s.paymentUC.HandleCallback(ctx, payload)
+
+	now := time.Now()
+	event.ProcessedAt = &now
+	if processErr != nil {
+		msg := processErr.Error()
+		event.Status = domain.WebhookStatusFailed
+		event.Error = &msg
+	} else {
+		event.Status = domain.WebhookStatusProcessed
+		event.Error = nil
+	}
+
+	if err := s.repo.Update(ctx, event); err != nil {
+		return err
+	}
+	return processErr
+}
+
+// verifyToken returns whether the token was checked and matched, whether the webhook may be
+// processed, and a short description for the log.
+func (s *webhookService) verifyToken(got string) (verified bool, accepted bool, result string) {
+	if s.token == "" {
+		if s.production {
+			return false, false, "no webhook token configured"
+		}
+		return false, true, "no webhook token configured, accepted outside production"
+	}
+	if got == "" {
+		return false, false, "missing callback token"
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+		return false, false, "invalid callback token"
+	}
+	return true, true, "callback token valid"
+}
+
+func parsePaymentCallback(body []byte) (*domain.PaymentCallback, error) {
+	var payload domain.PaymentCallback
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("payload webhook tidak valid: %v", err)
+	}
+	if payload.ExternalID == "" || payload.Status == "" {
+		return nil, errors.New("payload webhook tidak valid: external_id dan status wajib diisi")
+	}
+	return &payload, nil
+}
+
+func encodeWebhookHeaders(headers http.Header) string {
+	clean := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		if redactedWebhookHeaders[strings.ToLower(key)] {
+			clean[key] = []string{"[REDACTED]"}
+			continue
+		}
+		clean[key] = values
+	}
+	encoded, err := json.Marshal(clean)
+	if err != nil {
+		return "{}"
+	}
+	return string(encoded)
+}
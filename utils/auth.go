@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 )
 
 func GenerateOTP(length int) (string, error) {
@@ -17,3 +18,13 @@ func GenerateOTP(length int) (string, error) {
 	}
 	return string(otp), nil
 }
+
+// GenerateRandomID returns a random hex string of n bytes (2n characters), suitable as an
+// unguessable identifier such as a token ID.
+func GenerateRandomID(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -22,20 +22,50 @@ func NewJWTManager(secretKey string, duration time.Duration) *JWTManager {
 
 
 func (j *JWTManager) GenerateToken(userUUID string, role, name string) (string, error) {
+	return j.GenerateAccessToken(AccessClaims{UserUUID: userUUID, Role: role, Name: name})
+}
+
+// GenerateAccessToken is GenerateToken with the session (refresh token family) the token
+// was issued for and the user's token version, so requests can tell which session they
+// come from and whether the token has been revoked since.
+func (j *JWTManager) GenerateAccessToken(c AccessClaims) (string, error) {
 	claims := jwt.MapClaims{
-		"sub":  userUUID,
-		"name": name,
-		"role": role,
+		"sub":  c.UserUUID,
+		"name": c.Name,
+		"role": c.Role,
+		"ver":  c.TokenVersion,
 		"exp":  time.Now().Add(j.tokenDuration).Unix(),
 		"iat":  time.Now().Unix(),
 	}
+	if c.SessionID != "" {
+		claims["sid"] = c.SessionID
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(j.secretKey)
 }
 
+// AccessClaims are the claims of an access token. SessionID is empty for tokens issued
+// before sessions were tracked, and TokenVersion is 0 for tokens issued before versions.
+type AccessClaims struct {
+	UserUUID     string
+	Role         string
+	Name         string
+	SessionID    string
+	TokenVersion int
+}
+
 // VerifyToken memverifikasi token dan mengembalikan UUID + Role
 func (j *JWTManager) VerifyToken(tokenStr string) (string, string, string, error) {
+	claims, err := j.VerifyAccessToken(tokenStr)
+	if err != nil {
+		return "", "", "", err
+	}
+	return claims.UserUUID, claims.Role, claims.Name, nil
+}
+
+// VerifyAccessToken checks an access token and returns all of its claims.
+func (j *JWTManager) VerifyAccessToken(tokenStr string) (*AccessClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -44,15 +74,84 @@ func (j *JWTManager) VerifyToken(tokenStr string) (string, string, string, error
 	})
 
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userUUID, _ := claims["sub"].(string)
-		role, _ := claims["role"].(string)
-		name, _ := claims["name"].(string)
-		return userUUID, role, name, nil
+		result := &AccessClaims{}
+		result.UserUUID, _ = claims["sub"].(string)
+		result.Role, _ = claims["role"].(string)
+		result.Name, _ = claims["name"].(string)
+		result.SessionID, _ = claims["sid"].(string)
+		if ver, ok := claims["ver"].(float64); ok {
+			result.TokenVersion = int(ver)
+		}
+		return result, nil
 	}
 
-	return "", "", "", errors.New("invalid token claims")
+	return nil, errors.New("invalid token claims")
+}
+
+// RefreshClaims are the claims of a refresh token. TokenID (jti) identifies this token and
+// FamilyID the login session it was rotated from; both are tracked server-side.
+type RefreshClaims struct {
+	UserUUID  string
+	TokenID   string
+	FamilyID  string
+	ExpiresAt time.Time
+}
+
+// Duration is how long tokens issued by this manager stay valid.
+func (j *JWTManager) Duration() time.Duration {
+	return j.tokenDuration
+}
+
+// GenerateRefreshToken signs a refresh token for one link of a token family.
+func (j *JWTManager) GenerateRefreshToken(userUUID, familyID, tokenID string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userUUID,
+		"jti": tokenID,
+		"fid": familyID,
+		"typ": "refresh",
+		"exp": now.Add(j.tokenDuration).Unix(),
+		"iat": now.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+// VerifyRefreshToken checks the signature and expiry of a refresh token and returns its
+// claims. Tokens issued before rotation existed have no token ID and are rejected.
+func (j *JWTManager) VerifyRefreshToken(tokenStr string) (*RefreshClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return j.secretKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token claims")
+	}
+	if typ, _ := claims["typ"].(string); typ != "refresh" {
+		return nil, errors.New("not a refresh token")
+	}
+
+	result := &RefreshClaims{}
+	result.UserUUID, _ = claims["sub"].(string)
+	result.TokenID, _ = claims["jti"].(string)
+	result.FamilyID, _ = claims["fid"].(string)
+	if result.UserUUID == "" || result.TokenID == "" || result.FamilyID == "" {
+		return nil, errors.New("invalid token claims")
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.ExpiresAt = exp.Time
+	}
+	return result, nil
 }
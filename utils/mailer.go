@@ -91,4 +91,50 @@ func SendQRCodeEmail(to, subject, body, qrText string) error {
 	addr := fmt.Sprintf("%s:%s", host, port)
 	auth := smtp.PlainAuth("", from, pass, host)
 	return smtp.SendMail(addr, auth, from, []string{to}, msg.Bytes())
-}
\ No newline at end of file
+}
+
+// SendEmailWithAttachment sends a plain text email with a single file attached
+func SendEmailWithAttachment(to, subject, body, filename, contentType string, data []byte) error {
+	from := os.Getenv("SMTP_USER")
+	pass := os.Getenv("SMTP_PASS")
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+
+	boundary := fmt.Sprintf("boundary-%d", time.Now().UnixNano())
+
+	var msg bytes.Buffer
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", os.Getenv("SMTP_FROM")))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\r\n", boundary))
+	msg.WriteString("\r\n")
+
+	// Text part
+	msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 7bit\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+	msg.WriteString("\r\n\r\n")
+
+	// Attachment
+	msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
+	msg.WriteString(fmt.Sprintf("Content-Type: %s\r\n", contentType))
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=\"%s\"\r\n", filename))
+	msg.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for i := 0; i < len(encoded); i += 76 {
+		end := min(i+76, len(encoded))
+		msg.WriteString(encoded[i:end] + "\r\n")
+	}
+
+	msg.WriteString("\r\n")
+	msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
+
+	addr := fmt.Sprintf("%s:%s", host, port)
+	auth := smtp.PlainAuth("", from, pass, host)
+	return smtp.SendMail(addr, auth, from, []string{to}, msg.Bytes())
+}
@@ -101,3 +101,12 @@ func GetDayName(weekday time.Weekday) string {
 	}
 	return dayNames[weekday]
 }
+
+// GetMonthName returns Indonesian month name from time.Month
+func GetMonthName(month time.Month) string {
+	monthNames := [...]string{
+		"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+		"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+	}
+	return monthNames[month-1]
+}